@@ -0,0 +1,88 @@
+package grammatic
+
+import (
+	"fmt"
+	"github.com/jsanchesleao/grammatic/model"
+)
+
+// Builds the value of a node from its token or from the values of its children, which are already set when it is called
+type Action = func(node *model.Node) (any, error)
+
+// Returned by ParseValue when an action fails, with the position of the first token of the node it was called with
+type ActionError struct {
+	RuleType string
+	Token    model.Token
+	Err      error
+}
+
+func (e *ActionError) Error() string {
+	return fmt.Sprintf("Action for rule %q failed at line %d, column %d: %v", e.RuleType, e.Token.Line, e.Token.Col, e.Err)
+}
+
+func (e *ActionError) Unwrap() error {
+	return e.Err
+}
+
+// Registers the action that computes the value of the nodes produced by the rule, used by ParseValue.
+// Registering another action for the same rule replaces the previous one
+func (g *Grammar) Action(ruleType string, action Action) error {
+	if g.frozen {
+		return ErrFrozenGrammar
+	}
+	if g.actions == nil {
+		g.actions = map[string]Action{}
+	}
+	g.actions[ruleType] = action
+	return nil
+}
+
+// Parses the input like Parse, then runs the actions from the leaves of the tree up to the node of ruleType, and returns its value.
+// Nodes of rules without an action get the value of their child when they have exactly one, and nil otherwise
+func (g *Grammar) ParseValue(ruleType, input string) (any, error) {
+	tree, err := g.parseUnshaped(ruleType, input)
+	if err != nil {
+		return nil, err
+	}
+
+	// the node of ruleType is kept even when its rule is inlined or collapsed, and only its children are shaped
+	node := g.shapeTree(&tree.Rules[0])
+	if err := g.runActions(node); err != nil {
+		return nil, err
+	}
+	return node.Value, nil
+}
+
+func (g *Grammar) runActions(node *model.Node) error {
+	for i := range node.Rules {
+		if err := g.runActions(&node.Rules[i]); err != nil {
+			return err
+		}
+	}
+
+	action := g.actions[node.Type]
+	if action == nil {
+		if len(node.Rules) == 1 {
+			node.Value = node.Rules[0].Value
+		}
+		return nil
+	}
+
+	value, err := action(node)
+	if err != nil {
+		actionError := &ActionError{RuleType: node.Type, Err: err}
+		if token := firstToken(node); token != nil {
+			actionError.Token = *token
+		}
+		return actionError
+	}
+	node.Value = value
+	return nil
+}
+
+func copyActions(actions map[string]Action) map[string]Action {
+	result := make(map[string]Action, len(actions))
+	for name, action := range actions {
+		result[name] = action
+	}
+	return result
+}
@@ -0,0 +1,142 @@
+package grammatic
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jsanchesleao/grammatic/model"
+	"strconv"
+	"testing"
+)
+
+func calculatorGrammar() Grammar {
+	grammar := Compile(`
+Expr := Number | Operation | Parens
+
+Operation := Number Operator Expr
+
+Parens := LParen Expr RParen
+
+Operator := /[-+*\/]/
+Number := /\d+/
+LParen := /\(/
+RParen := /\)/
+Space := $EmptySpaceFormat (ignore)`)
+
+	grammar.Action("Number", func(n *model.Node) (any, error) {
+		return strconv.Atoi(n.Token.Value)
+	})
+	grammar.Action("Parens", func(n *model.Node) (any, error) {
+		return n.Rules[1].Value, nil
+	})
+	grammar.Action("Operation", func(n *model.Node) (any, error) {
+		left := n.Rules[0].Value.(int)
+		right := n.Rules[2].Value.(int)
+		switch n.Rules[1].Token.Value {
+		case "+":
+			return left + right, nil
+		case "-":
+			return left - right, nil
+		case "*":
+			return left * right, nil
+		}
+		if right == 0 {
+			return nil, errors.New("division by zero")
+		}
+		return left / right, nil
+	})
+	return grammar
+}
+
+func TestParseValue(t *testing.T) {
+	grammar := calculatorGrammar()
+
+	value, err := grammar.ParseValue("Expr", "2 * (3 + 4)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != 14 {
+		t.Fatalf("Expected value 14, got %v", value)
+	}
+}
+
+func TestParseValueActionError(t *testing.T) {
+	grammar := calculatorGrammar()
+
+	_, err := grammar.ParseValue("Expr", "1 + (4 / 0)")
+	if err == nil {
+		t.Fatal("Expected the action error to be returned")
+	}
+
+	expectedError := `Action for rule "Operation" failed at line 1, column 6: division by zero`
+	if err.Error() != expectedError {
+		t.Fatalf("Expected error %q, got %q", expectedError, err.Error())
+	}
+
+	var actionError *ActionError
+	if !errors.As(err, &actionError) || actionError.RuleType != "Operation" {
+		t.Fatalf("Expected an *ActionError for rule Operation, got %#v", err)
+	}
+}
+
+func shapedCalculatorGrammar() Grammar {
+	grammar := Compile(`
+Statement := Sum | Number (collapse)
+
+Sum := _Atom '+' _Atom
+
+_Atom := Number | _Pair
+
+_Pair := Number Number
+
+Number := /\d+/
+Space := $EmptySpaceFormat (ignore)`)
+
+	grammar.Action("Number", func(n *model.Node) (any, error) {
+		return strconv.Atoi(n.Token.Value)
+	})
+	grammar.Action("Sum", func(n *model.Node) (any, error) {
+		return n.Rules[0].Value.(int) + n.Rules[len(n.Rules)-1].Value.(int), nil
+	})
+	grammar.Action("_Atom", func(n *model.Node) (any, error) {
+		if len(n.Rules) == 2 {
+			return n.Rules[0].Value.(int)*10 + n.Rules[1].Value.(int), nil
+		}
+		return n.Rules[0].Value.(int) * 10, nil
+	})
+	grammar.Action("Statement", func(n *model.Node) (any, error) {
+		return fmt.Sprintf("result: %v", n.Rules[0].Value), nil
+	})
+	return grammar
+}
+
+func TestParseValueInlineRoot(t *testing.T) {
+	grammar := shapedCalculatorGrammar()
+
+	value, err := grammar.ParseValue("_Atom", "5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != 50 {
+		t.Fatalf("Expected the action of _Atom to give 50, got %v", value)
+	}
+
+	value, err = grammar.ParseValue("_Atom", "4 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != 42 {
+		t.Fatalf("Expected the action of _Atom to see both children of the inlined _Pair and give 42, got %v", value)
+	}
+}
+
+func TestParseValueCollapsedRoot(t *testing.T) {
+	grammar := shapedCalculatorGrammar()
+
+	value, err := grammar.ParseValue("Statement", "1 + 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != "result: 3" {
+		t.Fatalf("Expected the action of the collapsed Statement to run, got %v", value)
+	}
+}
@@ -0,0 +1,65 @@
+package grammatic
+
+import (
+	"fmt"
+	"github.com/jsanchesleao/grammatic/model"
+	"strings"
+)
+
+// Describes the first point where two parse trees for the same input differ
+type Ambiguity struct {
+	Trees  [2]*model.Node
+	Path   []string
+	First  *model.Node
+	Second *model.Node
+}
+
+// Returns where the trees differ. The Root node that wraps every tree is left out of the path
+func findAmbiguity(first, second *model.Node) *Ambiguity {
+	ambiguity := &Ambiguity{Trees: [2]*model.Node{first, second}}
+	ambiguity.First, ambiguity.Second, ambiguity.Path = firstDifference(first, second, []string{})
+	if len(ambiguity.Path) > 0 {
+		ambiguity.Path = ambiguity.Path[1:]
+	}
+	return ambiguity
+}
+
+func firstDifference(first, second *model.Node, path []string) (*model.Node, *model.Node, []string) {
+	if first.Type != second.Type || len(first.Rules) != len(second.Rules) || (first.Token == nil) != (second.Token == nil) {
+		return first, second, path
+	}
+	if first.Token != nil && *first.Token != *second.Token {
+		return first, second, path
+	}
+	for i := range first.Rules {
+		if !first.Rules[i].Equals(&second.Rules[i]) {
+			return firstDifference(&first.Rules[i], &second.Rules[i], append(path, first.Type))
+		}
+	}
+	return first, second, path
+}
+
+func firstToken(node *model.Node) *model.Token {
+	if node.Token != nil {
+		return node.Token
+	}
+	for i := range node.Rules {
+		if token := firstToken(&node.Rules[i]); token != nil {
+			return token
+		}
+	}
+	return nil
+}
+
+// Returns a description of the location where the trees differ, and what each of them matched there
+func (a *Ambiguity) String() string {
+	location := ""
+	if token := firstToken(a.First); token != nil {
+		location = fmt.Sprintf(" at line %d, column %d", token.Line, token.Col)
+	}
+	under := ""
+	if len(a.Path) > 0 {
+		under = " under " + strings.Join(a.Path, " > ")
+	}
+	return fmt.Sprintf("Ambiguous parse%s%s: %s or %s", under, location, a.First.Type, a.Second.Type)
+}
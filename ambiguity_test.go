@@ -0,0 +1,134 @@
+package grammatic
+
+import (
+	"testing"
+)
+
+const danglingElseGrammar = `
+Statement := IfThen | IfThenElse | Other
+
+IfThen := If Cond Statement
+
+IfThenElse := If Cond Statement Else Statement
+
+If := /if/
+Else := /else/
+Cond := /cond/
+Other := /stmt/
+Space := $EmptySpaceFormat (ignore)`
+
+func TestParseAll(t *testing.T) {
+	grammar := Compile(danglingElseGrammar)
+
+	trees, err := grammar.ParseAll("Statement", "if cond if cond stmt else stmt", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(trees) != 2 {
+		t.Fatalf("Expected 2 parse trees, but found %d", len(trees))
+	}
+
+	trees, err = grammar.ParseAll("Statement", "if cond if cond stmt else stmt", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(trees) != 1 {
+		t.Fatalf("Expected the limit to return 1 parse tree, but found %d", len(trees))
+	}
+
+	_, err = grammar.ParseAll("Statement", "if cond else", 0)
+	if err == nil {
+		t.Fatalf("Expected invalid input to produce an error, but it did not")
+	}
+}
+
+func TestIsAmbiguous(t *testing.T) {
+	grammar := Compile(danglingElseGrammar)
+
+	ambiguous, ambiguity, err := grammar.IsAmbiguous("Statement", "if cond stmt else stmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ambiguous || ambiguity != nil {
+		t.Fatalf("Expected input to not be ambiguous, but it was: %s", ambiguity)
+	}
+
+	ambiguous, ambiguity, err = grammar.IsAmbiguous("Statement", "if cond if cond stmt else stmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ambiguous {
+		t.Fatalf("Expected input to be ambiguous, but it was not")
+	}
+
+	expectedDescription := "Ambiguous parse under Statement at line 1, column 1: IfThen or IfThenElse"
+	if ambiguity.String() != expectedDescription {
+		t.Fatalf("Expected ambiguity to be described as\n%q\nbut was\n%q", expectedDescription, ambiguity.String())
+	}
+}
+
+func TestParseAllKeepsTreesBeforeFatalError(t *testing.T) {
+	grammar := Compile(`
+Statement := Call | Assign
+
+Call := Name Arguments
+
+Assign := Name ^ '=' Name
+
+Arguments := '(' ')'
+
+Name := /[a-z]+/
+Space := $EmptySpaceFormat (ignore)`)
+
+	trees, err := grammar.ParseAll("Statement", "print ( )", 0)
+	if err == nil {
+		t.Fatal("Expected the cut of Assign to stop parsing with an error")
+	}
+	if len(trees) != 1 || trees[0].GetNodeWithType("Statement").GetNodeWithType("Call") == nil {
+		t.Fatalf("Expected the Call tree found before the error to be returned, got %d trees", len(trees))
+	}
+}
+
+func TestIsAmbiguousWithCutAfterTree(t *testing.T) {
+	grammar := Compile(`
+Statement := Call | Assign
+
+Call := Name Arguments
+
+Assign := Name ^ '=' Name
+
+Arguments := '(' ')'
+
+Name := /[a-z]+/
+Space := $EmptySpaceFormat (ignore)`)
+
+	if _, err := grammar.Parse("Statement", "print ( )"); err != nil {
+		t.Fatal(err)
+	}
+
+	ambiguous, ambiguity, err := grammar.IsAmbiguous("Statement", "print ( )")
+	if err != nil {
+		t.Fatalf("Expected the failed cut after the first tree to not be an error, got %v", err)
+	}
+	if ambiguous || ambiguity != nil {
+		t.Fatalf("Expected input to not be ambiguous, but it was: %s", ambiguity)
+	}
+
+	if _, _, err := grammar.IsAmbiguous("Statement", "print = ("); err == nil {
+		t.Fatal("Expected an error when the cut fails before any tree is found")
+	}
+}
+
+func TestAmbiguityPathLeavesOutRoot(t *testing.T) {
+	grammar := Compile(danglingElseGrammar)
+
+	_, ambiguity, err := grammar.IsAmbiguous("Statement", "if cond if cond stmt else stmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ambiguity.Path) != 1 || ambiguity.Path[0] != "Statement" {
+		t.Fatalf("Expected the path to only have Statement, got %v", ambiguity.Path)
+	}
+}
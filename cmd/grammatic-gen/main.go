@@ -0,0 +1,65 @@
+// Command grammatic-gen reads a grammar file and writes Go source for it.
+// With -mode types it writes the types of the parse trees of a rule, and with -mode parser it writes
+// a lexer and a parser that work without building the grammar at runtime.
+// It is meant to be run by go generate:
+//
+//	//go:generate go run github.com/jsanchesleao/grammatic/cmd/grammatic-gen -grammar json.grammar -rule Value -output ast.go
+//	//go:generate go run github.com/jsanchesleao/grammatic/cmd/grammatic-gen -mode parser -grammar json.grammar -output parser.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/jsanchesleao/grammatic"
+	"os"
+)
+
+func main() {
+	mode := flag.String("mode", "types", "what to generate, either types or parser")
+	grammarFile := flag.String("grammar", "", "grammar file to read")
+	rule := flag.String("rule", "", "root rule of the generated types")
+	packageName := flag.String("package", "", "package of the generated file, defaults to $GOPACKAGE")
+	output := flag.String("output", "", "file to write, defaults to the standard output")
+	flag.Parse()
+
+	if *packageName == "" {
+		*packageName = os.Getenv("GOPACKAGE")
+	}
+	if *grammarFile == "" || *packageName == "" || (*mode == "types" && *rule == "") || (*mode != "types" && *mode != "parser") {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*mode, *grammarFile, *rule, *packageName, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "grammatic-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(mode, grammarFile, rule, packageName, output string) error {
+	text, err := os.ReadFile(grammarFile)
+	if err != nil {
+		return err
+	}
+
+	grammar := grammatic.NewGrammar()
+	if err := grammar.Load(string(text)); err != nil {
+		return fmt.Errorf("%s: %w", grammarFile, err)
+	}
+
+	var source []byte
+	if mode == "parser" {
+		source, err = grammar.GenerateParser(packageName)
+	} else {
+		source, err = grammar.GenerateTypes(packageName, rule)
+	}
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		_, err = os.Stdout.Write(source)
+		return err
+	}
+	return os.WriteFile(output, source, 0644)
+}
@@ -0,0 +1,504 @@
+// Command grammatic tries grammar files on inputs, without writing Go code.
+//
+// Usage:
+//
+//	grammatic parse -g file.grammar -r Rule [-json] [input]
+//	grammatic tokens -g file.grammar [input]
+//	grammatic check file.grammar...
+//	grammatic fmt [-l] [-w] [file.grammar...]
+//	grammatic export [-f ebnf|abnf|w3c] file.grammar
+//	grammatic railroad [-o dir] file.grammar
+//	grammatic import [-f antlr|w3c] file
+//	grammatic generate -g file.grammar -r Rule [-n count] [-seed n] [-depth n] [-verify] [-corpus dir]
+//
+// The input is read from the standard input when no file is given.
+// Errors are printed as file:line:col: message, and the exit code is 0 on success,
+// 1 when a grammar or an input is invalid and 2 when the command is used wrongly
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/jsanchesleao/grammatic"
+	"github.com/jsanchesleao/grammatic/lexer"
+	"github.com/jsanchesleao/grammatic/model"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	exitSuccess = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+const usage = `usage:
+  grammatic parse -g file.grammar -r Rule [-json] [input]
+  grammatic tokens -g file.grammar [input]
+  grammatic check file.grammar...
+  grammatic fmt [-l] [-w] [file.grammar...]
+  grammatic export [-f ebnf|abnf|w3c] file.grammar
+  grammatic railroad [-o dir] file.grammar
+  grammatic import [-f antlr|w3c] file
+  grammatic generate -g file.grammar -r Rule [-n count] [-seed n] [-depth n] [-verify] [-corpus dir]
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "parse":
+		return runParse(args[1:], stdin, stdout, stderr)
+	case "tokens":
+		return runTokens(args[1:], stdin, stdout, stderr)
+	case "check":
+		return runCheck(args[1:], stdout, stderr)
+	case "fmt":
+		return runFmt(args[1:], stdin, stdout, stderr)
+	case "export":
+		return runExport(args[1:], stdout, stderr)
+	case "railroad":
+		return runRailroad(args[1:], stdout, stderr)
+	case "import":
+		return runImport(args[1:], stdout, stderr)
+	case "generate":
+		return runGenerate(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return exitSuccess
+	}
+
+	fmt.Fprintf(stderr, "grammatic: unknown command %q\n%s", args[0], usage)
+	return exitUsage
+}
+
+func runParse(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("parse", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	grammarFile := flags.String("g", "", "grammar file")
+	rule := flags.String("r", "", "rule that must match the whole input")
+	asJSON := flags.Bool("json", false, "print the tree as JSON")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *grammarFile == "" || *rule == "" || flags.NArg() > 1 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	grammar, ok := loadGrammar(*grammarFile, stderr)
+	if !ok {
+		return exitFailure
+	}
+	inputFile, input, err := readInput(flags.Args(), stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "grammatic: %v\n", err)
+		return exitFailure
+	}
+
+	tree, err := grammar.Parse(*rule, input)
+	if err != nil {
+		reportError(stderr, inputFile, input, err)
+		return exitFailure
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(toJSON(tree))
+	} else {
+		fmt.Fprint(stdout, tree.PrettyPrint())
+	}
+	return exitSuccess
+}
+
+func runTokens(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("tokens", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	grammarFile := flags.String("g", "", "grammar file")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *grammarFile == "" || flags.NArg() > 1 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	grammar, ok := loadGrammar(*grammarFile, stderr)
+	if !ok {
+		return exitFailure
+	}
+	inputFile, input, err := readInput(flags.Args(), stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "grammatic: %v\n", err)
+		return exitFailure
+	}
+
+	tokens, err := grammar.Tokenize(input)
+	if err != nil {
+		reportError(stderr, inputFile, input, err)
+		return exitFailure
+	}
+
+	ignored := map[string]bool{}
+	for _, tokenType := range grammar.IgnoredTokenTypes {
+		ignored[tokenType] = true
+	}
+	for _, token := range tokens {
+		fmt.Fprintf(stdout, "%d:%d\t%s\t%q", token.Line, token.Col, token.Type, token.Value)
+		if ignored[token.Type] {
+			fmt.Fprint(stdout, "\t(ignored)")
+		}
+		fmt.Fprintln(stdout)
+	}
+	return exitSuccess
+}
+
+func runCheck(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	code := exitSuccess
+	for _, grammarFile := range args {
+		grammar, ok := loadGrammar(grammarFile, stderr)
+		if !ok {
+			code = exitFailure
+			continue
+		}
+		if err := grammar.Validate(); err != nil {
+			reportError(stderr, grammarFile, "", err)
+			code = exitFailure
+		}
+	}
+	return code
+}
+
+func runFmt(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	list := flags.Bool("l", false, "list the files whose formatting differs")
+	write := flags.Bool("w", false, "write the result to the files instead of printing it")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if flags.NArg() == 0 {
+		if *list || *write {
+			fmt.Fprint(stderr, usage)
+			return exitUsage
+		}
+		text, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "grammatic: %v\n", err)
+			return exitFailure
+		}
+		formatted, err := grammatic.FormatGrammar(string(text))
+		if err != nil {
+			reportError(stderr, "<stdin>", string(text), err)
+			return exitFailure
+		}
+		fmt.Fprint(stdout, formatted)
+		return exitSuccess
+	}
+
+	code := exitSuccess
+	for _, grammarFile := range flags.Args() {
+		text, err := os.ReadFile(grammarFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "grammatic: %v\n", err)
+			code = exitFailure
+			continue
+		}
+		formatted, err := grammatic.FormatGrammar(string(text))
+		if err != nil {
+			reportError(stderr, grammarFile, string(text), err)
+			code = exitFailure
+			continue
+		}
+
+		changed := formatted != string(text)
+		if *list && changed {
+			fmt.Fprintln(stdout, grammarFile)
+		}
+		if *write && changed {
+			if err := os.WriteFile(grammarFile, []byte(formatted), 0644); err != nil {
+				fmt.Fprintf(stderr, "grammatic: %v\n", err)
+				code = exitFailure
+			}
+		}
+		if !*list && !*write {
+			fmt.Fprint(stdout, formatted)
+		}
+	}
+	return code
+}
+
+func runExport(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("f", "ebnf", "notation: ebnf (ISO 14977), abnf (RFC 5234) or w3c (XML specification)")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	notation, err := grammatic.ParseNotation(*format)
+	if err != nil || flags.NArg() != 1 {
+		if err != nil {
+			fmt.Fprintf(stderr, "grammatic: %v\n", err)
+		}
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	grammar, ok := loadGrammar(flags.Arg(0), stderr)
+	if !ok {
+		return exitFailure
+	}
+	if err := grammar.Export(stdout, notation); err != nil {
+		fmt.Fprintf(stderr, "grammatic: %v\n", err)
+		return exitFailure
+	}
+	return exitSuccess
+}
+
+func runRailroad(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("railroad", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	dir := flags.String("o", "", "directory to write one SVG file per rule to, instead of printing an HTML page")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	grammarFile := flags.Arg(0)
+	grammar, ok := loadGrammar(grammarFile, stderr)
+	if !ok {
+		return exitFailure
+	}
+	if *dir == "" {
+		if err := grammar.WriteRailroadHTML(stdout, filepath.Base(grammarFile)); err != nil {
+			fmt.Fprintf(stderr, "grammatic: %v\n", err)
+			return exitFailure
+		}
+		return exitSuccess
+	}
+
+	if err := os.MkdirAll(*dir, 0755); err != nil {
+		fmt.Fprintf(stderr, "grammatic: %v\n", err)
+		return exitFailure
+	}
+	for _, rule := range grammar.RuleGraph() {
+		// anonymous groups and literals are drawn inside the rules that use them
+		if rule.Kind == model.KindLiteral || strings.HasPrefix(rule.Name, "(") {
+			continue
+		}
+		svg := &bytes.Buffer{}
+		if err := grammar.WriteRailroadSVG(svg, rule.Name); err != nil {
+			fmt.Fprintf(stderr, "grammatic: %v\n", err)
+			return exitFailure
+		}
+		if err := os.WriteFile(filepath.Join(*dir, grammatic.RailroadFileName(rule.Name)), svg.Bytes(), 0644); err != nil {
+			fmt.Fprintf(stderr, "grammatic: %v\n", err)
+			return exitFailure
+		}
+	}
+	return exitSuccess
+}
+
+func runImport(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("f", "", "notation of the file: antlr (.g4) or w3c (EBNF of the XML specification), by default from the file extension")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	file := flags.Arg(0)
+	if *format == "" {
+		switch filepath.Ext(file) {
+		case ".g4":
+			*format = "antlr"
+		case ".ebnf":
+			*format = "w3c"
+		}
+	}
+	importGrammar := map[string]func(string) (grammatic.Grammar, error){
+		"antlr": grammatic.ImportANTLR,
+		"w3c":   grammatic.ImportW3CEBNF,
+	}[*format]
+	if importGrammar == nil {
+		fmt.Fprintf(stderr, "grammatic: unknown notation %q, use -f antlr or -f w3c\n%s", *format, usage)
+		return exitUsage
+	}
+
+	text, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintf(stderr, "grammatic: %v\n", err)
+		return exitFailure
+	}
+	grammar, err := importGrammar(string(text))
+	if err != nil {
+		reportError(stderr, file, string(text), err)
+		return exitFailure
+	}
+	fmt.Fprint(stdout, grammar.String())
+	return exitSuccess
+}
+
+func runGenerate(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	grammarFile := flags.String("g", "", "grammar file")
+	rule := flags.String("r", "", "rule of the sentences")
+	count := flags.Int("n", 10, "number of sentences")
+	seed := flags.Int64("seed", 0, "seed of the random choices, by default the current time")
+	verify := flags.Bool("verify", false, "parse each sentence, generating another one when it doesn't match")
+	maxDepth := flags.Int("depth", 0, "depth after which rules take their shortest way out")
+	corpus := flags.String("corpus", "", "directory to write the sentences to as a fuzz seed corpus, instead of printing them")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *grammarFile == "" || *rule == "" || flags.NArg() != 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	grammar, ok := loadGrammar(*grammarFile, stderr)
+	if !ok {
+		return exitFailure
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(*seed))
+
+	sentences := []string{}
+	for i := 0; i < *count; i++ {
+		sentence, err := grammar.Generate(*rule, rng, grammatic.GenerateOptions{MaxDepth: *maxDepth, Verify: *verify})
+		if err != nil {
+			fmt.Fprintf(stderr, "grammatic: %v\n", err)
+			return exitFailure
+		}
+		sentences = append(sentences, sentence)
+	}
+
+	if *corpus != "" {
+		if err := grammatic.WriteFuzzCorpus(*corpus, sentences); err != nil {
+			fmt.Fprintf(stderr, "grammatic: %v\n", err)
+			return exitFailure
+		}
+		return exitSuccess
+	}
+	for _, sentence := range sentences {
+		fmt.Fprintln(stdout, sentence)
+	}
+	return exitSuccess
+}
+
+func loadGrammar(grammarFile string, stderr io.Writer) (*grammatic.Grammar, bool) {
+	text, err := os.ReadFile(grammarFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "grammatic: %v\n", err)
+		return nil, false
+	}
+
+	grammar := grammatic.NewGrammar()
+	if err := grammar.Load(string(text)); err != nil {
+		reportError(stderr, grammarFile, string(text), err)
+		return nil, false
+	}
+	return &grammar, true
+}
+
+// Returns the name and the contents of the input file, or of the standard input when no file is given
+func readInput(args []string, stdin io.Reader) (string, string, error) {
+	if len(args) == 0 || args[0] == "-" {
+		input, err := io.ReadAll(stdin)
+		return "<stdin>", string(input), err
+	}
+	input, err := os.ReadFile(args[0])
+	return args[0], string(input), err
+}
+
+// Prints the error prefixed by the file and, when the error has one, the position where it happened.
+// The text is the contents of the file, used to find where it ends
+func reportError(stderr io.Writer, file, text string, err error) {
+	var syntaxError *model.SyntaxError
+	var characterError *lexer.IllegalCharacterError
+	var importError *grammatic.ImportError
+
+	switch {
+	case errors.As(err, &importError):
+		for _, issue := range importError.Issues {
+			fmt.Fprintf(stderr, "%s:%d:%d: %s\n", file, issue.Line, issue.Col, issue.Message)
+		}
+	case errors.As(err, &syntaxError) && syntaxError.Token.Type == lexer.TYPE_EOF:
+		line, col := endPosition(text)
+		fmt.Fprintf(stderr, "%s:%d:%d: unexpected end of input\n", file, line, col)
+	case errors.As(err, &syntaxError):
+		fmt.Fprintf(stderr, "%s:%d:%d: unexpected token %q\n", file, syntaxError.Token.Line, syntaxError.Token.Col, syntaxError.Token.Value)
+	case errors.As(err, &characterError):
+		fmt.Fprintf(stderr, "%s:%d:%d: illegal character %q\n", file, characterError.Line, characterError.Col, characterError.Character)
+	default:
+		fmt.Fprintf(stderr, "%s: %v\n", file, err)
+	}
+}
+
+// Returns the line and column right after the last character of the text, counting columns in bytes like the lexer
+func endPosition(text string) (int, int) {
+	lastLine := text[strings.LastIndexByte(text, '\n')+1:]
+	return strings.Count(text, "\n") + 1, len(lastLine) + 1
+}
+
+type jsonToken struct {
+	Type   string `json:"type"`
+	Value  string `json:"value"`
+	Line   int    `json:"line"`
+	Col    int    `json:"col"`
+	Offset int    `json:"offset"`
+}
+
+type jsonNode struct {
+	Type  string     `json:"type"`
+	Token *jsonToken `json:"token,omitempty"`
+	Rules []jsonNode `json:"rules,omitempty"`
+}
+
+func toJSON(node *model.Node) jsonNode {
+	result := jsonNode{Type: node.Type}
+	if node.Token != nil {
+		result.Token = &jsonToken{
+			Type:   node.Token.Type,
+			Value:  node.Token.Value,
+			Line:   node.Token.Line,
+			Col:    node.Token.Col,
+			Offset: node.Token.Offset,
+		}
+	}
+	for i := range node.Rules {
+		result.Rules = append(result.Rules, toJSON(&node.Rules[i]))
+	}
+	return result
+}
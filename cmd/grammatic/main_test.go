@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sumGrammar = `Sum := Number[Plus]+
+Plus := /\+/
+Number := /\d+/
+Space := $EmptySpaceFormat (ignore)
+`
+
+func writeFile(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func runCommand(stdin string, args ...string) (int, string, string) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	code := run(args, strings.NewReader(stdin), stdout, stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestParseCommand(t *testing.T) {
+	grammarFile := writeFile(t, "sum.grammar", sumGrammar)
+
+	code, stdout, stderr := runCommand("1 + 2", "parse", "-g", grammarFile, "-r", "Sum")
+	if code != exitSuccess || !strings.Contains(stdout, "├─Sum\n  │ ├─Number • 1\n") {
+		t.Fatalf("Expected a tree, got code %d, output %q and errors %q", code, stdout, stderr)
+	}
+
+	code, stdout, _ = runCommand("1", "parse", "-g", grammarFile, "-r", "Sum", "-json")
+	if code != exitSuccess || !strings.Contains(stdout, `"type": "Number",`) || !strings.Contains(stdout, `"value": "1",`) {
+		t.Fatalf("Expected a JSON tree, got code %d and output %q", code, stdout)
+	}
+
+	inputFile := writeFile(t, "input.txt", "1 + 2\n3")
+	code, _, stderr = runCommand("", "parse", "-g", grammarFile, "-r", "Sum", inputFile)
+	if code != exitFailure || stderr != inputFile+":2:1: unexpected token \"3\"\n" {
+		t.Fatalf("Expected a positioned syntax error, got code %d and errors %q", code, stderr)
+	}
+
+	code, _, stderr = runCommand("1 ? 2", "parse", "-g", grammarFile, "-r", "Sum")
+	if code != exitFailure || stderr != "<stdin>:1:3: illegal character \"?\"\n" {
+		t.Fatalf("Expected a positioned lexer error, got code %d and errors %q", code, stderr)
+	}
+
+	code, _, _ = runCommand("1", "parse", "-g", grammarFile)
+	if code != exitUsage {
+		t.Fatalf("Expected a usage error without a rule, got code %d", code)
+	}
+}
+
+func TestEndOfInputError(t *testing.T) {
+	grammarFile := writeFile(t, "pair.grammar", "Pair := Number Number\nNumber := /\\d+/\nSpace := $EmptySpaceFormat (ignore)\n")
+
+	code, _, stderr := runCommand("1 ", "parse", "-g", grammarFile, "-r", "Pair")
+	if code != exitFailure || stderr != "<stdin>:1:3: unexpected end of input\n" {
+		t.Fatalf("Expected the error at the end of the single line, got code %d and errors %q", code, stderr)
+	}
+
+	code, _, stderr = runCommand("1\n  ", "parse", "-g", grammarFile, "-r", "Pair")
+	if code != exitFailure || stderr != "<stdin>:2:3: unexpected end of input\n" {
+		t.Fatalf("Expected the error at the end of the second line, got code %d and errors %q", code, stderr)
+	}
+
+	emptyGrammar := writeFile(t, "empty.grammar", "")
+	code, _, stderr = runCommand("1", "parse", "-g", emptyGrammar, "-r", "Pair")
+	if code != exitFailure || stderr != emptyGrammar+":1:1: unexpected end of input\n" {
+		t.Fatalf("Expected the error at the start of the empty grammar, got code %d and errors %q", code, stderr)
+	}
+}
+
+func TestTokensCommand(t *testing.T) {
+	grammarFile := writeFile(t, "sum.grammar", sumGrammar)
+
+	code, stdout, _ := runCommand("1 +", "tokens", "-g", grammarFile)
+	expected := "1:1\tNumber\t\"1\"\n1:2\tSpace\t\" \"\t(ignored)\n1:3\tPlus\t\"+\"\n2:0\tTOKEN_EOF\t\"\"\n"
+	if code != exitSuccess || stdout != expected {
+		t.Fatalf("Unexpected tokens, got code %d and output %q", code, stdout)
+	}
+}
+
+func TestCheckCommand(t *testing.T) {
+	valid := writeFile(t, "valid.grammar", sumGrammar)
+	invalid := writeFile(t, "invalid.grammar", "Sum := Number\nNumber := := /\\d+/\n")
+	undefined := writeFile(t, "undefined.grammar", "Sum := Number Plus\nNumber := /\\d+/\n")
+
+	code, _, stderr := runCommand("", "check", valid)
+	if code != exitSuccess || stderr != "" {
+		t.Fatalf("Expected a valid grammar, got code %d and errors %q", code, stderr)
+	}
+
+	code, _, stderr = runCommand("", "check", valid, invalid, undefined)
+	expected := invalid + ":2:8: unexpected token \":=\"\n" + undefined + ": these rules are used but never defined: Plus\n"
+	if code != exitFailure || stderr != expected {
+		t.Fatalf("Expected errors for the invalid grammars, got code %d and errors %q", code, stderr)
+	}
+
+	code, _, _ = runCommand("", "unknown")
+	if code != exitUsage {
+		t.Fatalf("Expected a usage error for an unknown command, got code %d", code)
+	}
+}
+
+func TestFmtCommand(t *testing.T) {
+	messy := "Sum:=Number[ Plus ]+\nNumber := /\\d+/ # digits\nPlus := /\\+/\n"
+	formatted := "Sum := Number[Plus]+\n\nNumber := /\\d+/ # digits\nPlus   := /\\+/\n"
+
+	code, stdout, stderr := runCommand(messy, "fmt")
+	if code != exitSuccess || stdout != formatted {
+		t.Fatalf("Expected the formatted grammar, got code %d, output %q and errors %q", code, stdout, stderr)
+	}
+
+	grammarFile := writeFile(t, "sum.grammar", messy)
+	code, stdout, _ = runCommand("", "fmt", "-l", grammarFile)
+	if code != exitSuccess || stdout != grammarFile+"\n" {
+		t.Fatalf("Expected the file to be listed, got code %d and output %q", code, stdout)
+	}
+
+	code, stdout, _ = runCommand("", "fmt", "-w", grammarFile)
+	written, _ := os.ReadFile(grammarFile)
+	if code != exitSuccess || stdout != "" || string(written) != formatted {
+		t.Fatalf("Expected the file to be rewritten, got code %d, output %q and file %q", code, stdout, written)
+	}
+
+	code, stdout, _ = runCommand("", "fmt", "-l", grammarFile)
+	if code != exitSuccess || stdout != "" {
+		t.Fatalf("Expected no files to be listed, got code %d and output %q", code, stdout)
+	}
+
+	code, _, stderr = runCommand("Sum := := Number\n", "fmt")
+	if code != exitFailure || stderr != "<stdin>:1:8: unexpected token \":=\"\n" {
+		t.Fatalf("Expected a positioned syntax error, got code %d and errors %q", code, stderr)
+	}
+
+	code, _, _ = runCommand("", "fmt", "-w")
+	if code != exitUsage {
+		t.Fatalf("Expected a usage error when writing the standard input, got code %d", code)
+	}
+}
+
+func TestExportCommand(t *testing.T) {
+	grammarFile := writeFile(t, "sum.grammar", sumGrammar)
+
+	expected := map[string]string{
+		"ebnf": "Sum = Number, { Plus, Number } ;\n",
+		"abnf": "Sum = Number *(Plus Number)\n",
+		"w3c":  "Sum ::= Number (Plus Number)*\n",
+	}
+	for format, want := range expected {
+		code, stdout, stderr := runCommand("", "export", "-f", format, grammarFile)
+		if code != exitSuccess || !strings.HasPrefix(stdout, want) {
+			t.Fatalf("Expected the grammar in %s, got code %d, output %q and errors %q", format, code, stdout, stderr)
+		}
+	}
+
+	code, _, stderr := runCommand("", "export", "-f", "bnf", grammarFile)
+	if code != exitUsage || !strings.Contains(stderr, `unknown notation "bnf"`) {
+		t.Fatalf("Expected a usage error for an unknown notation, got code %d and errors %q", code, stderr)
+	}
+}
+
+func TestRailroadCommand(t *testing.T) {
+	grammarFile := writeFile(t, "sum.grammar", sumGrammar)
+
+	code, stdout, stderr := runCommand("", "railroad", grammarFile)
+	if code != exitSuccess || !strings.Contains(stdout, "<title>sum.grammar</title>") || !strings.Contains(stdout, `<section id="rule-Sum">`) {
+		t.Fatalf("Expected an HTML page, got code %d, output %q and errors %q", code, stdout, stderr)
+	}
+
+	dir := filepath.Join(t.TempDir(), "diagrams")
+	code, _, stderr = runCommand("", "railroad", "-o", dir, grammarFile)
+	if code != exitSuccess {
+		t.Fatalf("Expected the diagrams to be written, got code %d and errors %q", code, stderr)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []string{}
+	for _, entry := range entries {
+		files = append(files, entry.Name())
+	}
+	if strings.Join(files, " ") != "Number.svg Plus.svg Space.svg Sum.svg" {
+		t.Fatalf("Expected one SVG file per rule, got %v", files)
+	}
+
+	code, _, _ = runCommand("", "railroad")
+	if code != exitUsage {
+		t.Fatalf("Expected a usage error without a grammar file, got code %d", code)
+	}
+}
+
+func TestImportCommand(t *testing.T) {
+	antlrFile := writeFile(t, "Sum.g4", "grammar Sum;\nsum : NUMBER ('+' NUMBER)* ;\nNUMBER : [0-9]+ ;\nWS : ' '+ -> skip ;\n")
+	code, stdout, stderr := runCommand("", "import", antlrFile)
+	expected := "sum := NUMBER ('+' NUMBER)*\n\nNUMBER := /[0-9]+/\nWS     := / +/ (ignore)\n"
+	if code != exitSuccess || stdout != expected {
+		t.Fatalf("Expected the grammar %q, got code %d, output %q and errors %q", expected, code, stdout, stderr)
+	}
+
+	w3cFile := writeFile(t, "sum.txt", "Sum ::= Number ('+' Number)*\nNumber ::= [0-9]+ - '0'\n")
+	code, _, stderr = runCommand("", "import", "-f", "w3c", w3cFile)
+	if code != exitFailure || stderr != w3cFile+":2:19: exceptions like A - B are not supported\n" {
+		t.Fatalf("Expected the unsupported constructs to be reported, got code %d and errors %q", code, stderr)
+	}
+
+	code, _, stderr = runCommand("", "import", w3cFile)
+	if code != exitUsage || !strings.Contains(stderr, `unknown notation ""`) {
+		t.Fatalf("Expected a usage error without a notation, got code %d and errors %q", code, stderr)
+	}
+}
+
+func TestGenerateCommand(t *testing.T) {
+	grammarFile := writeFile(t, "sum.grammar", sumGrammar)
+
+	code, stdout, stderr := runCommand("", "generate", "-g", grammarFile, "-r", "Sum", "-n", "5", "-seed", "1", "-verify")
+	sentences := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	if code != exitSuccess || len(sentences) != 5 {
+		t.Fatalf("Expected 5 sentences, got code %d, output %q and errors %q", code, stdout, stderr)
+	}
+	for _, sentence := range sentences {
+		code, _, stderr := runCommand(sentence, "parse", "-g", grammarFile, "-r", "Sum")
+		if code != exitSuccess {
+			t.Fatalf("Expected %q to match the grammar, got %q", sentence, stderr)
+		}
+	}
+
+	_, again, _ := runCommand("", "generate", "-g", grammarFile, "-r", "Sum", "-n", "5", "-seed", "1", "-verify")
+	if again != stdout {
+		t.Fatalf("Expected the same sentences for the same seed, got %q and %q", stdout, again)
+	}
+
+	dir := filepath.Join(t.TempDir(), "FuzzSum")
+	code, _, stderr = runCommand("", "generate", "-g", grammarFile, "-r", "Sum", "-n", "3", "-corpus", dir)
+	entries, err := os.ReadDir(dir)
+	if code != exitSuccess || err != nil || len(entries) == 0 {
+		t.Fatalf("Expected a seed corpus, got code %d, errors %q and %v", code, stderr, err)
+	}
+
+	code, _, _ = runCommand("", "generate", "-g", grammarFile)
+	if code != exitUsage {
+		t.Fatalf("Expected a usage error without a rule, got code %d", code)
+	}
+}
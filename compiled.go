@@ -0,0 +1,77 @@
+package grammatic
+
+import (
+	"context"
+	"github.com/jsanchesleao/grammatic/model"
+)
+
+// A read-only snapshot of a Grammar, created by Freeze.
+// Its parse methods never change the grammar, so it can be shared by many goroutines
+type Compiled struct {
+	grammar Grammar
+}
+
+// Stops the grammar from being changed, and returns a snapshot of it that is safe for concurrent use.
+// After freezing, defining rules, tokens or reducers in the grammar returns ErrFrozenGrammar
+func (g *Grammar) Freeze() *Compiled {
+	g.frozen = true
+
+	rules := make(map[string]*model.Rule, len(g.Rules))
+	for name, rule := range g.Rules {
+		rules[name] = rule
+	}
+
+	return &Compiled{
+		grammar: Grammar{
+			Rules:             rules,
+			TokenDefs:         append([]model.TokenDef{}, g.TokenDefs...),
+			IgnoredTokenTypes: append([]string{}, g.IgnoredTokenTypes...),
+			TokenReducers:     append([]TokenReducer{}, g.TokenReducers...),
+			literalDefs:       append([]model.TokenDef{}, g.literalDefs...),
+			inlineRules:       copyNames(g.inlineRules),
+			droppedRules:      copyNames(g.droppedRules),
+			collapsedRules:    copyNames(g.collapsedRules),
+			groupCount:        g.groupCount,
+			definitions:       copyDefinitions(g.definitions),
+			ruleOrder:         append([]string{}, g.ruleOrder...),
+			actions:           copyActions(g.actions),
+			templates:         g.templates,
+			frozen:            true,
+		},
+	}
+}
+
+// Checks if Freeze was called on the grammar
+func (g *Grammar) IsFrozen() bool {
+	return g.frozen
+}
+
+// Same as Grammar.Parse
+func (c *Compiled) Parse(ruleType, input string) (*model.Node, error) {
+	return c.grammar.Parse(ruleType, input)
+}
+
+// Same as Grammar.ParseContext
+func (c *Compiled) ParseContext(ctx context.Context, ruleType, input string, opts ParseOptions) (*model.Node, error) {
+	return c.grammar.ParseContext(ctx, ruleType, input, opts)
+}
+
+// Same as Grammar.ParsePrefix
+func (c *Compiled) ParsePrefix(ruleType, input string) (*model.Node, int, error) {
+	return c.grammar.ParsePrefix(ruleType, input)
+}
+
+// Same as Grammar.ParseValue
+func (c *Compiled) ParseValue(ruleType, input string) (any, error) {
+	return c.grammar.ParseValue(ruleType, input)
+}
+
+// Same as Grammar.ParseAll
+func (c *Compiled) ParseAll(ruleType, input string, limit int) ([]*model.Node, error) {
+	return c.grammar.ParseAll(ruleType, input, limit)
+}
+
+// Same as Grammar.IsAmbiguous
+func (c *Compiled) IsAmbiguous(ruleType, input string) (bool, *Ambiguity, error) {
+	return c.grammar.IsAmbiguous(ruleType, input)
+}
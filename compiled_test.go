@@ -0,0 +1,42 @@
+package grammatic
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFreeze(t *testing.T) {
+	grammar := Compile(JSONGrammar)
+	compiled := grammar.Freeze()
+
+	if !grammar.IsFrozen() {
+		t.Fatalf("Expected grammar to be frozen, but it was not")
+	}
+
+	if err := grammar.DefineRule("Null", grammar.Token("^null")); !errors.Is(err, ErrFrozenGrammar) {
+		t.Fatalf("Expected defining a rule to fail with ErrFrozenGrammar, but the error was %v", err)
+	}
+	if err := grammar.DefineToken("Null", "^null"); !errors.Is(err, ErrFrozenGrammar) {
+		t.Fatalf("Expected defining a token to fail with ErrFrozenGrammar, but the error was %v", err)
+	}
+	if _, ok := grammar.Rules["Null"]; ok {
+		t.Fatalf("Expected frozen grammar to not declare new rules")
+	}
+
+	results := make(chan error)
+	for i := 0; i < 10; i++ {
+		go func() {
+			_, err := compiled.Parse("Value", `{"name": "grammatic", "awesome": [true, 1]}`)
+			results <- err
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		if err := <-results; err != nil {
+			t.Error(err)
+		}
+	}
+
+	if _, err := compiled.Parse("Missing", "{}"); err == nil {
+		t.Fatalf("Expected parsing an undefined rule to fail, but it did not")
+	}
+}
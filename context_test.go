@@ -0,0 +1,99 @@
+package grammatic
+
+import (
+	"context"
+	"errors"
+	"github.com/jsanchesleao/grammatic/parser"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Splitting the input in Pairs and Singles is exponential, and always fails at the end
+const backtrackingGrammar = `
+Input := Chunk* as Chunks Stop
+
+Chunk := Single | Pair
+
+Pair := X X
+
+Single := X
+
+X := /x/
+Stop := /!/
+Space := $EmptySpaceFormat (ignore)`
+
+func TestParseContextDeadline(t *testing.T) {
+	grammar := Compile(backtrackingGrammar)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := grammar.ParseContext(ctx, "Input", strings.Repeat("x ", 60)+"x", ParseOptions{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected parsing to exceed its deadline, but the error was %v", err)
+	}
+}
+
+func TestParseContextLimits(t *testing.T) {
+	grammar := Compile(backtrackingGrammar)
+	input := strings.Repeat("x ", 60) + "x"
+
+	cases := []struct {
+		opts  ParseOptions
+		limit string
+	}{
+		{ParseOptions{MaxInvocations: 1000}, parser.LimitInvocations},
+		{ParseOptions{MaxNodes: 1000}, parser.LimitNodes},
+	}
+
+	for _, c := range cases {
+		_, err := grammar.ParseContext(context.Background(), "Input", input, c.opts)
+
+		var limitError *parser.LimitError
+		if !errors.As(err, &limitError) {
+			t.Fatalf("Expected a limit error for %s, but the error was %v", c.limit, err)
+		}
+		if limitError.Limit != c.limit {
+			t.Fatalf("Expected the %s limit to be exceeded, but it was %s", c.limit, limitError.Limit)
+		}
+	}
+
+	tree, err := grammar.ParseContext(context.Background(), "Input", "x x x !", ParseOptions{MaxDepth: 100, MaxInvocations: 1000, MaxNodes: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree.GetNodeWithType("Input").GetNodeWithType("Chunks").GetAllNodes()) != 3 {
+		t.Fatalf("Unexpected tree:\n%s", tree.PrettyPrint())
+	}
+}
+
+func TestParseContextDepthLimit(t *testing.T) {
+	grammar := Compile(`
+List := '[' Value[',']* as Items ']'
+
+Value := Number | List
+
+Number := /\d+/
+Space := $EmptySpaceFormat (ignore)`)
+
+	flat := "[" + strings.TrimSuffix(strings.Repeat("1, ", 200), ", ") + "]"
+	tree, err := grammar.ParseContext(context.Background(), "List", flat, ParseOptions{MaxDepth: 10})
+	if err != nil {
+		t.Fatalf("Expected a flat list to be within the depth limit, but the error was %v", err)
+	}
+	if len(tree.GetNodeWithType("List").GetNodeWithType("Items").GetNodesWithType("Value")) != 200 {
+		t.Fatalf("Unexpected tree:\n%s", tree.PrettyPrint())
+	}
+
+	nested := strings.Repeat("[", 20) + "1" + strings.Repeat("]", 20)
+	_, err = grammar.ParseContext(context.Background(), "List", nested, ParseOptions{MaxDepth: 10})
+	var limitError *parser.LimitError
+	if !errors.As(err, &limitError) || limitError.Limit != parser.LimitDepth {
+		t.Fatalf("Expected the depth limit to be exceeded by nested lists, but the error was %v", err)
+	}
+
+	if _, err := grammar.ParseContext(context.Background(), "List", nested, ParseOptions{MaxDepth: 200}); err != nil {
+		t.Fatalf("Expected nested lists to be within a larger depth limit, but the error was %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package grammatic
+
+import (
+	"fmt"
+	"github.com/jsanchesleao/grammatic/model"
+	"strings"
+)
+
+// The kinds of rules that the grammar combinators create
+const (
+	kindToken                  = model.KindToken
+	kindVirtualToken           = model.KindVirtualToken
+	kindLiteral                = model.KindLiteral
+	kindOr                     = model.KindOr
+	kindSeq                    = model.KindSeq
+	kindRename                 = model.KindRename
+	kindOneOrNone              = model.KindOneOrNone
+	kindMany                   = model.KindMany
+	kindOneOrMany              = model.KindOneOrMany
+	kindManyWithSeparator      = model.KindManyWithSeparator
+	kindOneOrManyWithSeparator = model.KindOneOrManyWithSeparator
+	kindRepeat                 = model.KindRepeat
+	kindRepeatWithSeparator    = model.KindRepeatWithSeparator
+)
+
+// Describes how a rule was built by the grammar combinators, so it can be built again in another grammar.
+// Rules created by custom combinators have an empty kind
+type ruleDefinition struct {
+	kind       string
+	rules      []string
+	separator  string
+	min        int
+	max        int
+	trailing   bool
+	memo       bool
+	pattern    string
+	ignored    bool
+	value      string
+	ignoreCase bool
+}
+
+func (g *Grammar) recordDefinition(name string, definition ruleDefinition) {
+	if g.definitions == nil {
+		g.definitions = map[string]ruleDefinition{}
+	}
+	if _, ok := g.definitions[name]; !ok {
+		g.ruleOrder = append(g.ruleOrder, name)
+	}
+	g.definitions[name] = definition
+	if rule := g.Rules[name]; rule != nil {
+		rule.Description = definition.describe(name)
+	}
+}
+
+// Returns the public description of the definition, with its own copy of the rule names
+func (definition ruleDefinition) describe(name string) *model.RuleDescription {
+	if definition.kind == "" {
+		return &model.RuleDescription{Name: name, Kind: model.KindCustom}
+	}
+	var rules []string
+	if definition.rules != nil {
+		rules = append([]string{}, definition.rules...)
+	}
+	return &model.RuleDescription{
+		Name:       name,
+		Kind:       definition.kind,
+		Rules:      rules,
+		Separator:  definition.separator,
+		Min:        definition.min,
+		Max:        definition.max,
+		Trailing:   definition.trailing,
+		Memo:       definition.memo,
+		Pattern:    definition.pattern,
+		Ignored:    definition.ignored,
+		Value:      definition.value,
+		IgnoreCase: definition.ignoreCase,
+	}
+}
+
+// Returns the descriptions of the defined rules, in the order they were first defined.
+// The rule names in each description link it to the others, forming the graph of the grammar.
+// Literals and the anonymous groups created by the grammar language are included, like any other rule
+func (g *Grammar) RuleGraph() []model.RuleDescription {
+	result := make([]model.RuleDescription, 0, len(g.ruleOrder))
+	for _, name := range g.ruleOrder {
+		result = append(result, *g.definitions[name].describe(name))
+	}
+	return result
+}
+
+// Same as Grammar.RuleGraph
+func (c *Compiled) RuleGraph() []model.RuleDescription {
+	return c.grammar.RuleGraph()
+}
+
+func copyDefinitions(definitions map[string]ruleDefinition) map[string]ruleDefinition {
+	result := make(map[string]ruleDefinition, len(definitions))
+	for name, definition := range definitions {
+		result[name] = definition
+	}
+	return result
+}
+
+func isLiteralName(name string) bool {
+	return strings.HasPrefix(name, "'")
+}
+
+// Returns a combinator that builds the defined rule in this grammar, with its references changed by rename.
+// Token rules are not handled here, since they are not defined with a combinator
+func (g *Grammar) combinatorFor(definition ruleDefinition, rename func(string) string) (GrammarCombinator, error) {
+	rules := []string{}
+	for _, name := range definition.rules {
+		if name == CutMarker || isLiteralName(name) {
+			rules = append(rules, name)
+		} else {
+			rules = append(rules, rename(name))
+		}
+	}
+	separator := definition.separator
+	if separator != "" && !isLiteralName(separator) {
+		separator = rename(separator)
+	}
+
+	var combinator GrammarCombinator
+	switch definition.kind {
+	case kindOr:
+		combinator = g.Or(rules...)
+	case kindSeq:
+		combinator = g.Seq(rules...)
+	case kindRename:
+		combinator = g.Rename(rules[0])
+	case kindOneOrNone:
+		combinator = g.OneOrNone(rules[0])
+	case kindMany:
+		combinator = g.Many(rules[0])
+	case kindOneOrMany:
+		combinator = g.OneOrMany(rules[0])
+	case kindManyWithSeparator:
+		if definition.trailing {
+			combinator = g.ManyWithSeparatorTrailing(rules[0], separator)
+		} else {
+			combinator = g.ManyWithSeparator(rules[0], separator)
+		}
+	case kindOneOrManyWithSeparator:
+		if definition.trailing {
+			combinator = g.OneOrManyWithSeparatorTrailing(rules[0], separator)
+		} else {
+			combinator = g.OneOrManyWithSeparator(rules[0], separator)
+		}
+	case kindRepeat:
+		combinator = g.Repeat(rules[0], definition.min, definition.max)
+	case kindRepeatWithSeparator:
+		if definition.trailing {
+			combinator = g.RepeatWithSeparatorTrailing(rules[0], separator, definition.min, definition.max)
+		} else {
+			combinator = g.RepeatWithSeparator(rules[0], separator, definition.min, definition.max)
+		}
+	default:
+		return GrammarCombinator{}, fmt.Errorf("rule kind %q cannot be rebuilt", definition.kind)
+	}
+
+	if definition.memo {
+		combinator = g.Memo(combinator)
+	}
+	return combinator, nil
+}
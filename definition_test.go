@@ -0,0 +1,84 @@
+package grammatic
+
+import (
+	"github.com/jsanchesleao/grammatic/model"
+	"github.com/jsanchesleao/grammatic/parser"
+	"reflect"
+	"testing"
+)
+
+func TestRuleGraph(t *testing.T) {
+	grammar := Compile(`
+List := '[' Item[Comma,]* ']'
+Item := ('let'i ^ Name) | Number{1,3}
+Number := /\d+/
+Name := /\w+/
+Comma := /,/
+Space := $EmptySpaceFormat (ignore)
+:virtual: Indent`)
+
+	descriptions := map[string]model.RuleDescription{}
+	names := []string{}
+	for _, description := range grammar.RuleGraph() {
+		descriptions[description.Name] = description
+		names = append(names, description.Name)
+	}
+
+	list := descriptions["List"]
+	items := descriptions[list.Rules[1]]
+	item := descriptions["Item"]
+	keyword := descriptions[item.Rules[0]]
+	numbers := descriptions[item.Rules[1]]
+
+	expected := []model.RuleDescription{
+		{Name: "List", Kind: model.KindSeq, Rules: []string{"'['", items.Name, "']'"}},
+		{Name: items.Name, Kind: model.KindManyWithSeparator, Rules: []string{"Item"}, Separator: "Comma", Trailing: true},
+		{Name: keyword.Name, Kind: model.KindSeq, Rules: []string{"'let'i", CutMarker, "Name"}},
+		{Name: numbers.Name, Kind: model.KindRepeat, Rules: []string{"Number"}, Min: 1, Max: 3},
+		{Name: "'let'i", Kind: model.KindLiteral, Value: "let", IgnoreCase: true},
+		{Name: "Number", Kind: model.KindToken, Pattern: `^\d+`},
+		{Name: "Space", Kind: model.KindToken, Pattern: `^\s+`, Ignored: true},
+		{Name: "Indent", Kind: model.KindVirtualToken},
+	}
+	for _, want := range expected {
+		if got := descriptions[want.Name]; !reflect.DeepEqual(got, want) {
+			t.Fatalf("Unexpected description of %s: %+v", want.Name, got)
+		}
+	}
+	if item.Kind != model.KindOr || len(item.Rules) != 2 {
+		t.Fatalf("Unexpected description of Item: %+v", item)
+	}
+	if len(names) != len(grammar.definitions) || names[0] != "'['" {
+		t.Fatalf("Expected every rule in definition order, got %v", names)
+	}
+
+	if rule := grammar.Rules["Item"]; rule.Description == nil || rule.Description.Kind != model.KindOr {
+		t.Fatalf("Expected the rule to carry its description, got %+v", rule.Description)
+	}
+	if frozen := grammar.Freeze().RuleGraph(); !reflect.DeepEqual(frozen, grammar.RuleGraph()) {
+		t.Fatal("Expected the frozen grammar to have the same graph")
+	}
+}
+
+func TestRuleGraphCustomAndUndefinedRules(t *testing.T) {
+	grammar := NewGrammar()
+	grammar.DefineRule("Custom", GrammarCombinator{
+		Create: func(ruleType string) *model.Rule {
+			return parser.RuleTokenType(ruleType, "Custom")
+		},
+	})
+	grammar.DefineRule("Start", grammar.Many("Missing"))
+
+	graph := grammar.RuleGraph()
+	if len(graph) != 2 || graph[0].Kind != model.KindCustom || graph[1].Rules[0] != "Missing" {
+		t.Fatalf("Unexpected graph %+v", graph)
+	}
+	if grammar.Rules["Missing"].Description != nil {
+		t.Fatal("Expected no description for an undefined rule")
+	}
+
+	graph[1].Rules[0] = "Changed"
+	if grammar.RuleGraph()[1].Rules[0] != "Missing" {
+		t.Fatal("Expected the graph to be a copy")
+	}
+}
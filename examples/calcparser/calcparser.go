@@ -0,0 +1,4 @@
+// Package calcparser shows a parser generated by grammatic-gen, which parses without building the grammar at runtime
+package calcparser
+
+//go:generate go run ../../cmd/grammatic-gen -mode parser -grammar calc.grammar -output parser.go
@@ -0,0 +1,85 @@
+package calcparser
+
+import (
+	"bytes"
+	"github.com/jsanchesleao/grammatic"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func loadGrammar(t *testing.T) grammatic.Grammar {
+	t.Helper()
+	text, err := os.ReadFile("calc.grammar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	grammar := grammatic.NewGrammar()
+	if err := grammar.Load(string(text)); err != nil {
+		t.Fatal(err)
+	}
+	return grammar
+}
+
+var differentialCases = []struct {
+	rule  string
+	input string
+}{
+	{"Program", ""},
+	{"Program", "1"},
+	{"Program", "1 + 2 * 3"},
+	{"Program", "let x = 1; print x, -x * (2 + y);"},
+	{"Program", "LET total = sum(1, 2, f(3)) / 4 # comment\n; PRINT total"},
+	{"Program", "let x 1"},
+	{"Program", "print"},
+	{"Program", "let = 2"},
+	{"Program", "1 +"},
+	{"Program", "(1 + 2"},
+	{"Program", "f(1, 2,)"},
+	{"Program", "1;;2"},
+	{"Program", "1 ? 2"},
+	{"Expression", "a - -b"},
+	{"Expression", "let"},
+	{"Call", "f()"},
+	{"Point", "<1 2>"},
+	{"Point", "<1 2 3>"},
+	{"Point", "<1>"},
+	{"Point", "<1 2 3 4>"},
+	{"Tuple", "[1]"},
+	{"Tuple", "[1, 2, 3]"},
+	{"Tuple", "[]"},
+	{"Tuple", "[1, 2, 3, 4]"},
+	{"Tuple", "[1,]"},
+	{"Missing", "1"},
+}
+
+func TestGeneratedParserMatchesGrammar(t *testing.T) {
+	grammar := loadGrammar(t)
+
+	for _, c := range differentialCases {
+		expected, expectedErr := grammar.Parse(c.rule, c.input)
+		actual, actualErr := Parse(c.rule, c.input)
+
+		if (expectedErr == nil) != (actualErr == nil) || (expectedErr != nil && expectedErr.Error() != actualErr.Error()) {
+			t.Fatalf("Parsing %q with %s: expected error %v but got %v", c.input, c.rule, expectedErr, actualErr)
+		}
+		if !reflect.DeepEqual(expected, actual) {
+			t.Fatalf("Parsing %q with %s: expected tree\n%s\nbut got\n%s", c.input, c.rule, expected.PrettyPrint(), actual.PrettyPrint())
+		}
+	}
+}
+
+func TestGeneratedParserIsUpToDate(t *testing.T) {
+	grammar := loadGrammar(t)
+	expected, err := grammar.GenerateParser("calcparser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := os.ReadFile("parser.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(expected, actual) {
+		t.Fatal("parser.go is out of date, run go generate")
+	}
+}
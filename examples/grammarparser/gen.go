@@ -0,0 +1,21 @@
+//go:build ignore
+
+// Writes parser.go with the parser of the grammar language, which is built with Go code instead of a grammar file
+package main
+
+import (
+	"github.com/jsanchesleao/grammatic"
+	"log"
+	"os"
+)
+
+func main() {
+	grammar := grammatic.GrammarParsingGrammar()
+	source, err := grammar.GenerateParser("grammarparser")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("parser.go", source, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,5 @@
+// Package grammarparser holds a generated parser for the grammar language, used to check that generated parsers
+// produce the same trees as the grammars they come from
+package grammarparser
+
+//go:generate go run gen.go
@@ -0,0 +1,75 @@
+package grammarparser
+
+import (
+	"bytes"
+	"github.com/jsanchesleao/grammatic"
+	"os"
+	"reflect"
+	"testing"
+)
+
+var grammarTexts = []string{
+	`Value := Number | Word
+Number := /\d+/
+Word := /\w+/`,
+	`List := '[' Item[Comma,]* as Items ']'
+Item := Name Value? as MaybeValue (inline)
+Pair<Key, Value> := Key '=' Value
+Entry := Pair<Name, Number>
+Tuple := '(' Number{2,3} as Coordinates ')'
+Names := Name[',']{1,}
+If := 'if'i ^ '(' Name ')'
+_Hidden := (Name | Number)* (collapse)
+Comma := /,/ (drop)
+Name := $KeywordFormat
+Number := $NumberFormat
+Space := $EmptySpaceFormat (ignore)
+:virtual: Indent Dedent`,
+	`Base |= Extra
+Extra := /x/`,
+	`Broken := `,
+	`Value := Number |`,
+	`Value := Number as`,
+	`Template<A := A`,
+	`Value Number`,
+	``,
+}
+
+func TestGeneratedParserMatchesGrammar(t *testing.T) {
+	texts := grammarTexts
+	for _, file := range []string{"../jsonast/json.grammar", "../calcparser/calc.grammar"} {
+		text, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		texts = append(texts, string(text))
+	}
+
+	grammar := grammatic.GrammarParsingGrammar()
+	for _, text := range texts {
+		expected, expectedErr := grammar.Parse("Grammar", text)
+		actual, actualErr := Parse("Grammar", text)
+
+		if (expectedErr == nil) != (actualErr == nil) || (expectedErr != nil && expectedErr.Error() != actualErr.Error()) {
+			t.Fatalf("Parsing %q: expected error %v but got %v", text, expectedErr, actualErr)
+		}
+		if !reflect.DeepEqual(expected, actual) {
+			t.Fatalf("Parsing %q: expected tree\n%s\nbut got\n%s", text, expected.PrettyPrint(), actual.PrettyPrint())
+		}
+	}
+}
+
+func TestGeneratedParserIsUpToDate(t *testing.T) {
+	grammar := grammatic.GrammarParsingGrammar()
+	expected, err := grammar.GenerateParser("grammarparser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := os.ReadFile("parser.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(expected, actual) {
+		t.Fatal("parser.go is out of date, run go generate")
+	}
+}
@@ -0,0 +1,1118 @@
+// Code generated by grammatic. DO NOT EDIT.
+
+package grammarparser
+
+import (
+	"fmt"
+	"github.com/jsanchesleao/grammatic/lexer"
+	"github.com/jsanchesleao/grammatic/model"
+	"regexp"
+	"strings"
+)
+
+var rules = map[string]parseFunc{
+	"Grammar":                                parseGrammar,
+	"GrammarRules":                           parseGrammarRules,
+	"VirtualTokens":                          parseVirtualTokens,
+	"VirtualTokenStatement":                  parseVirtualTokenStatement,
+	"VirtualTokenNames":                      parseVirtualTokenNames,
+	"GrammarRule":                            parseGrammarRule,
+	"RuleOperator":                           parseRuleOperator,
+	"TemplateParameters":                     parseTemplateParameters,
+	"TemplateParameterList":                  parseTemplateParameterList,
+	"TemplateParameterNames":                 parseTemplateParameterNames,
+	"TemplateInstance":                       parseTemplateInstance,
+	"TemplateArguments":                      parseTemplateArguments,
+	"TemplateArgument":                       parseTemplateArgument,
+	"RuleFlags":                              parseRuleFlags,
+	"RuleFlag":                               parseRuleFlag,
+	"RuleExpression":                         parseRuleExpression,
+	"InlineRenameExpression":                 parseInlineRenameExpression,
+	"InlineRenameExpressionItem":             parseInlineRenameExpressionItem,
+	"ManyExpression":                         parseManyExpression,
+	"ManyExpressionItem":                     parseManyExpressionItem,
+	"ManyWithSeparatorExpression":            parseManyWithSeparatorExpression,
+	"TrailingSeparator":                      parseTrailingSeparator,
+	"OneOrManyExpression":                    parseOneOrManyExpression,
+	"OneOrManyExpressionItem":                parseOneOrManyExpressionItem,
+	"OneOrManyWithSeparatorExpression":       parseOneOrManyWithSeparatorExpression,
+	"OneOrNoneExpression":                    parseOneOrNoneExpression,
+	"OneOrNoneExpressionItem":                parseOneOrNoneExpressionItem,
+	"RepeatExpression":                       parseRepeatExpression,
+	"RepeatWithSeparatorExpression":          parseRepeatWithSeparatorExpression,
+	"RepeatCount":                            parseRepeatCount,
+	"RepeatCountRange":                       parseRepeatCountRange,
+	"RepeatCountMax":                         parseRepeatCountMax,
+	"RepeatCountMaxValue":                    parseRepeatCountMaxValue,
+	"OrExpression":                           parseOrExpression,
+	"OrExpressionItem":                       parseOrExpressionItem,
+	"OrExpressionTail":                       parseOrExpressionTail,
+	"SeqExpression":                          parseSeqExpression,
+	"SeqExpressionItem":                      parseSeqExpressionItem,
+	"SeqExpressionTail":                      parseSeqExpressionTail,
+	"InlineRuleExpression":                   parseInlineRuleExpression,
+	"GroupExpression":                        parseGroupExpression,
+	"InlineSeqExpression":                    parseInlineSeqExpression,
+	"InlineSeqExpressionTail":                parseInlineSeqExpressionTail,
+	"InlineSeqExpressionItem":                parseInlineSeqExpressionItem,
+	"InlineManyExpression":                   parseInlineManyExpression,
+	"InlineManyWithSeparatorExpression":      parseInlineManyWithSeparatorExpression,
+	"InlineOneOrManyWithSeparatorExpression": parseInlineOneOrManyWithSeparatorExpression,
+	"InlineOneOrManyExpression":              parseInlineOneOrManyExpression,
+	"InlineOneOrNoneExpression":              parseInlineOneOrNoneExpression,
+	"InlineRepeatExpression":                 parseInlineRepeatExpression,
+	"InlineRepeatWithSeparatorExpression":    parseInlineRepeatWithSeparatorExpression,
+	"TokenExpression":                        parseTokenExpression,
+	"TokenExpressionBody":                    parseTokenExpressionBody,
+	"TokenExpressionFlag":                    parseTokenExpressionFlag,
+	"TokenExpressionFlagValue":               parseTokenExpressionFlagValue,
+	"TokenExpressionFlagName":                parseTokenExpressionFlagName,
+	"Token":                                  parseToken,
+	"ConvenienceToken":                       parseConvenienceToken,
+	"Literal":                                parseLiteral,
+	"As":                                     parseAs,
+	"Ignore":                                 parseIgnore,
+	"InlineFlag":                             parseInlineFlag,
+	"CollapseFlag":                           parseCollapseFlag,
+	"RuleName":                               parseRuleName,
+	"AlternativesAssignment":                 parseAlternativesAssignment,
+	"Pipe":                                   parsePipe,
+	"Cut":                                    parseCut,
+	"Star":                                   parseStar,
+	"Plus":                                   parsePlus,
+	"QuestionMark":                           parseQuestionMark,
+	"LeftBracket":                            parseLeftBracket,
+	"RightBracket":                           parseRightBracket,
+	"LeftParens":                             parseLeftParens,
+	"RightParens":                            parseRightParens,
+	"LeftCurly":                              parseLeftCurly,
+	"RightCurly":                             parseRightCurly,
+	"Count":                                  parseCount,
+	"Comma":                                  parseComma,
+	"LeftAngle":                              parseLeftAngle,
+	"RightAngle":                             parseRightAngle,
+	"Assignment":                             parseAssignment,
+	"Virtual":                                parseVirtual,
+	"Comment":                                parseComment,
+	"Space":                                  parseSpace,
+}
+
+var inlineRules = map[string]bool{}
+
+var droppedRules = map[string]bool{}
+
+var collapsedRules = map[string]bool{}
+
+var tokenDefs = []model.TokenDef{
+	{Type: "Token", Pattern: regexp.MustCompile("^\\/(\\\\/|[^/])+?\\/")},
+	{Type: "ConvenienceToken", Pattern: regexp.MustCompile("^\\$\\w+")},
+	{Type: "Literal", Pattern: regexp.MustCompile("^'(\\\\.|[^'\\\\])+'i?")},
+	{Type: "As", Pattern: regexp.MustCompile("^as")},
+	{Type: "Ignore", Pattern: regexp.MustCompile("^ignore")},
+	{Type: "InlineFlag", Pattern: regexp.MustCompile("^\\(\\s*inline\\s*\\)")},
+	{Type: "CollapseFlag", Pattern: regexp.MustCompile("^\\(\\s*collapse\\s*\\)")},
+	{Type: "RuleName", Pattern: regexp.MustCompile("^(?i)_*[a-z][-_\\w]*(\\._*[a-z][-_\\w]*)*")},
+	{Type: "AlternativesAssignment", Pattern: regexp.MustCompile("^\\|=")},
+	{Type: "Pipe", Pattern: regexp.MustCompile("^\\|")},
+	{Type: "Cut", Pattern: regexp.MustCompile("^\\^")},
+	{Type: "Star", Pattern: regexp.MustCompile("^\\*")},
+	{Type: "Plus", Pattern: regexp.MustCompile("^\\+")},
+	{Type: "QuestionMark", Pattern: regexp.MustCompile("^\\?")},
+	{Type: "LeftBracket", Pattern: regexp.MustCompile("^\\[")},
+	{Type: "RightBracket", Pattern: regexp.MustCompile("^\\]")},
+	{Type: "LeftParens", Pattern: regexp.MustCompile("^\\(")},
+	{Type: "RightParens", Pattern: regexp.MustCompile("^\\)")},
+	{Type: "LeftCurly", Pattern: regexp.MustCompile("^\\{")},
+	{Type: "RightCurly", Pattern: regexp.MustCompile("^\\}")},
+	{Type: "Count", Pattern: regexp.MustCompile("^\\d+")},
+	{Type: "Comma", Pattern: regexp.MustCompile("^,")},
+	{Type: "LeftAngle", Pattern: regexp.MustCompile("^<")},
+	{Type: "RightAngle", Pattern: regexp.MustCompile("^>")},
+	{Type: "Assignment", Pattern: regexp.MustCompile("^:=")},
+	{Type: "Virtual", Pattern: regexp.MustCompile("^:virtual:")},
+	{Type: "Comment", Pattern: regexp.MustCompile("^#.*?\\n")},
+	{Type: "Space", Pattern: regexp.MustCompile("^\\s+")},
+}
+
+var ignoredTokenTypes = map[string]bool{
+	"Comment": true,
+	"Space":   true,
+}
+
+// Checks the rule Grammar
+func parseGrammar(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("Grammar", pos, []parseFunc{parseGrammarRules, parseVirtualTokens}, k)
+}
+
+// Checks the rule GrammarRules
+func parseGrammarRules(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.oneOrMany("GrammarRules", pos, parseGrammarRule, k)
+}
+
+// Checks the rule VirtualTokens
+func parseVirtualTokens(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.oneOrNone("VirtualTokens", pos, parseVirtualTokenStatement, k)
+}
+
+// Checks the rule VirtualTokenStatement
+func parseVirtualTokenStatement(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("VirtualTokenStatement", pos, []parseFunc{parseVirtual, parseVirtualTokenNames}, k)
+}
+
+// Checks the rule VirtualTokenNames
+func parseVirtualTokenNames(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.oneOrMany("VirtualTokenNames", pos, parseRuleName, k)
+}
+
+// Checks the rule GrammarRule
+func parseGrammarRule(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("GrammarRule", pos, []parseFunc{parseRuleName, parseTemplateParameters, parseRuleOperator, parseRuleExpression, parseRuleFlags}, k)
+}
+
+// Checks the rule RuleOperator
+func parseRuleOperator(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.or("RuleOperator", pos, []parseFunc{parseAssignment, parseAlternativesAssignment}, k)
+}
+
+// Checks the rule TemplateParameters
+func parseTemplateParameters(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.oneOrNone("TemplateParameters", pos, parseTemplateParameterList, k)
+}
+
+// Checks the rule TemplateParameterList
+func parseTemplateParameterList(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("TemplateParameterList", pos, []parseFunc{parseLeftAngle, parseTemplateParameterNames, parseRightAngle}, k)
+}
+
+// Checks the rule TemplateParameterNames
+func parseTemplateParameterNames(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.oneOrManyWithSeparator("TemplateParameterNames", pos, parseRuleName, parseComma, false, k)
+}
+
+// Checks the rule TemplateInstance
+func parseTemplateInstance(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.memo(0, pos, checkTemplateInstance, k)
+}
+
+func checkTemplateInstance(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("TemplateInstance", pos, []parseFunc{parseRuleName, parseLeftAngle, parseTemplateArguments, parseRightAngle}, k)
+}
+
+// Checks the rule TemplateArguments
+func parseTemplateArguments(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.oneOrManyWithSeparator("TemplateArguments", pos, parseTemplateArgument, parseComma, false, k)
+}
+
+// Checks the rule TemplateArgument
+func parseTemplateArgument(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.or("TemplateArgument", pos, []parseFunc{parseTemplateInstance, parseRuleName, parseLiteral}, k)
+}
+
+// Checks the rule RuleFlags
+func parseRuleFlags(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.many("RuleFlags", pos, parseRuleFlag, k)
+}
+
+// Checks the rule RuleFlag
+func parseRuleFlag(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.or("RuleFlag", pos, []parseFunc{parseInlineFlag, parseCollapseFlag}, k)
+}
+
+// Checks the rule RuleExpression
+func parseRuleExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.memo(1, pos, checkRuleExpression, k)
+}
+
+func checkRuleExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.or("RuleExpression", pos, []parseFunc{parseTemplateInstance, parseRuleName, parseLiteral, parseTokenExpression, parseSeqExpression, parseOrExpression, parseManyExpression, parseOneOrManyExpression, parseOneOrNoneExpression, parseManyWithSeparatorExpression, parseOneOrManyWithSeparatorExpression, parseRepeatExpression, parseRepeatWithSeparatorExpression}, k)
+}
+
+// Checks the rule InlineRenameExpression
+func parseInlineRenameExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("InlineRenameExpression", pos, []parseFunc{parseInlineRenameExpressionItem, parseAs, parseRuleName}, k)
+}
+
+// Checks the rule InlineRenameExpressionItem
+func parseInlineRenameExpressionItem(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.or("InlineRenameExpressionItem", pos, []parseFunc{parseTemplateInstance, parseRuleName, parseLiteral}, k)
+}
+
+// Checks the rule ManyExpression
+func parseManyExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.memo(2, pos, checkManyExpression, k)
+}
+
+func checkManyExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("ManyExpression", pos, []parseFunc{parseManyExpressionItem, parseStar}, k)
+}
+
+// Checks the rule ManyExpressionItem
+func parseManyExpressionItem(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.memo(3, pos, checkManyExpressionItem, k)
+}
+
+func checkManyExpressionItem(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.or("ManyExpressionItem", pos, []parseFunc{parseTemplateInstance, parseRuleName, parseLiteral, parseInlineRuleExpression, parseGroupExpression}, k)
+}
+
+// Checks the rule ManyWithSeparatorExpression
+func parseManyWithSeparatorExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.memo(4, pos, checkManyWithSeparatorExpression, k)
+}
+
+func checkManyWithSeparatorExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("ManyWithSeparatorExpression", pos, []parseFunc{parseManyExpressionItem, parseLeftBracket, parseManyExpressionItem, parseTrailingSeparator, parseRightBracket, parseStar}, k)
+}
+
+// Checks the rule TrailingSeparator
+func parseTrailingSeparator(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.oneOrNone("TrailingSeparator", pos, parseComma, k)
+}
+
+// Checks the rule OneOrManyExpression
+func parseOneOrManyExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.memo(5, pos, checkOneOrManyExpression, k)
+}
+
+func checkOneOrManyExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("OneOrManyExpression", pos, []parseFunc{parseOneOrManyExpressionItem, parsePlus}, k)
+}
+
+// Checks the rule OneOrManyExpressionItem
+func parseOneOrManyExpressionItem(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.memo(6, pos, checkOneOrManyExpressionItem, k)
+}
+
+func checkOneOrManyExpressionItem(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.or("OneOrManyExpressionItem", pos, []parseFunc{parseTemplateInstance, parseRuleName, parseLiteral, parseInlineRuleExpression, parseGroupExpression}, k)
+}
+
+// Checks the rule OneOrManyWithSeparatorExpression
+func parseOneOrManyWithSeparatorExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.memo(7, pos, checkOneOrManyWithSeparatorExpression, k)
+}
+
+func checkOneOrManyWithSeparatorExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("OneOrManyWithSeparatorExpression", pos, []parseFunc{parseOneOrManyExpressionItem, parseLeftBracket, parseOneOrManyExpressionItem, parseTrailingSeparator, parseRightBracket, parsePlus}, k)
+}
+
+// Checks the rule OneOrNoneExpression
+func parseOneOrNoneExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.memo(8, pos, checkOneOrNoneExpression, k)
+}
+
+func checkOneOrNoneExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("OneOrNoneExpression", pos, []parseFunc{parseOneOrNoneExpressionItem, parseQuestionMark}, k)
+}
+
+// Checks the rule OneOrNoneExpressionItem
+func parseOneOrNoneExpressionItem(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.memo(9, pos, checkOneOrNoneExpressionItem, k)
+}
+
+func checkOneOrNoneExpressionItem(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.or("OneOrNoneExpressionItem", pos, []parseFunc{parseTemplateInstance, parseRuleName, parseLiteral, parseInlineRuleExpression, parseGroupExpression}, k)
+}
+
+// Checks the rule RepeatExpression
+func parseRepeatExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.memo(10, pos, checkRepeatExpression, k)
+}
+
+func checkRepeatExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("RepeatExpression", pos, []parseFunc{parseManyExpressionItem, parseRepeatCount}, k)
+}
+
+// Checks the rule RepeatWithSeparatorExpression
+func parseRepeatWithSeparatorExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.memo(11, pos, checkRepeatWithSeparatorExpression, k)
+}
+
+func checkRepeatWithSeparatorExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("RepeatWithSeparatorExpression", pos, []parseFunc{parseManyExpressionItem, parseLeftBracket, parseManyExpressionItem, parseTrailingSeparator, parseRightBracket, parseRepeatCount}, k)
+}
+
+// Checks the rule RepeatCount
+func parseRepeatCount(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("RepeatCount", pos, []parseFunc{parseLeftCurly, parseCount, parseRepeatCountRange, parseRightCurly}, k)
+}
+
+// Checks the rule RepeatCountRange
+func parseRepeatCountRange(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.oneOrNone("RepeatCountRange", pos, parseRepeatCountMax, k)
+}
+
+// Checks the rule RepeatCountMax
+func parseRepeatCountMax(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("RepeatCountMax", pos, []parseFunc{parseComma, parseRepeatCountMaxValue}, k)
+}
+
+// Checks the rule RepeatCountMaxValue
+func parseRepeatCountMaxValue(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.oneOrNone("RepeatCountMaxValue", pos, parseCount, k)
+}
+
+// Checks the rule OrExpression
+func parseOrExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("OrExpression", pos, []parseFunc{parseOrExpressionItem, parsePipe, parseOrExpressionTail}, k)
+}
+
+// Checks the rule OrExpressionItem
+func parseOrExpressionItem(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.memo(12, pos, checkOrExpressionItem, k)
+}
+
+func checkOrExpressionItem(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.or("OrExpressionItem", pos, []parseFunc{parseInlineSeqExpression, parseInlineRuleExpression, parseInlineManyExpression, parseInlineManyWithSeparatorExpression, parseInlineOneOrManyExpression, parseInlineOneOrManyWithSeparatorExpression, parseInlineOneOrNoneExpression, parseInlineRepeatExpression, parseInlineRepeatWithSeparatorExpression, parseTemplateInstance, parseRuleName, parseLiteral, parseManyExpression, parseManyWithSeparatorExpression, parseOneOrManyExpression, parseOneOrManyWithSeparatorExpression, parseOneOrNoneExpression, parseRepeatExpression, parseRepeatWithSeparatorExpression, parseGroupExpression}, k)
+}
+
+// Checks the rule OrExpressionTail
+func parseOrExpressionTail(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.oneOrManyWithSeparator("OrExpressionTail", pos, parseOrExpressionItem, parsePipe, false, k)
+}
+
+// Checks the rule SeqExpression
+func parseSeqExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("SeqExpression", pos, []parseFunc{parseSeqExpressionItem, parseSeqExpressionTail}, k)
+}
+
+// Checks the rule SeqExpressionItem
+func parseSeqExpressionItem(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.memo(13, pos, checkSeqExpressionItem, k)
+}
+
+func checkSeqExpressionItem(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.or("SeqExpressionItem", pos, []parseFunc{parseInlineRuleExpression, parseInlineManyExpression, parseInlineManyWithSeparatorExpression, parseInlineOneOrManyExpression, parseInlineOneOrManyWithSeparatorExpression, parseInlineOneOrNoneExpression, parseInlineRepeatExpression, parseInlineRepeatWithSeparatorExpression, parseInlineRenameExpression, parseTemplateInstance, parseRuleName, parseLiteral, parseManyExpression, parseManyWithSeparatorExpression, parseOneOrManyExpression, parseOneOrManyWithSeparatorExpression, parseOneOrNoneExpression, parseRepeatExpression, parseRepeatWithSeparatorExpression, parseGroupExpression, parseCut}, k)
+}
+
+// Checks the rule SeqExpressionTail
+func parseSeqExpressionTail(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.oneOrMany("SeqExpressionTail", pos, parseSeqExpressionItem, k)
+}
+
+// Checks the rule InlineRuleExpression
+func parseInlineRuleExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.memo(14, pos, checkInlineRuleExpression, k)
+}
+
+func checkInlineRuleExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("InlineRuleExpression", pos, []parseFunc{parseLeftParens, parseRuleExpression, parseAs, parseRuleName, parseRightParens}, k)
+}
+
+// Checks the rule GroupExpression
+func parseGroupExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.memo(15, pos, checkGroupExpression, k)
+}
+
+func checkGroupExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("GroupExpression", pos, []parseFunc{parseLeftParens, parseRuleExpression, parseRightParens}, k)
+}
+
+// Checks the rule InlineSeqExpression
+func parseInlineSeqExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("InlineSeqExpression", pos, []parseFunc{parseInlineSeqExpressionItem, parseInlineSeqExpressionTail, parseAs, parseRuleName}, k)
+}
+
+// Checks the rule InlineSeqExpressionTail
+func parseInlineSeqExpressionTail(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.oneOrMany("InlineSeqExpressionTail", pos, parseInlineSeqExpressionItem, k)
+}
+
+// Checks the rule InlineSeqExpressionItem
+func parseInlineSeqExpressionItem(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.memo(16, pos, checkInlineSeqExpressionItem, k)
+}
+
+func checkInlineSeqExpressionItem(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.or("InlineSeqExpressionItem", pos, []parseFunc{parseTemplateInstance, parseRuleName, parseLiteral, parseGroupExpression, parseCut}, k)
+}
+
+// Checks the rule InlineManyExpression
+func parseInlineManyExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("InlineManyExpression", pos, []parseFunc{parseManyExpression, parseAs, parseRuleName}, k)
+}
+
+// Checks the rule InlineManyWithSeparatorExpression
+func parseInlineManyWithSeparatorExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("InlineManyWithSeparatorExpression", pos, []parseFunc{parseManyWithSeparatorExpression, parseAs, parseRuleName}, k)
+}
+
+// Checks the rule InlineOneOrManyWithSeparatorExpression
+func parseInlineOneOrManyWithSeparatorExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("InlineOneOrManyWithSeparatorExpression", pos, []parseFunc{parseOneOrManyWithSeparatorExpression, parseAs, parseRuleName}, k)
+}
+
+// Checks the rule InlineOneOrManyExpression
+func parseInlineOneOrManyExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("InlineOneOrManyExpression", pos, []parseFunc{parseOneOrManyExpression, parseAs, parseRuleName}, k)
+}
+
+// Checks the rule InlineOneOrNoneExpression
+func parseInlineOneOrNoneExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("InlineOneOrNoneExpression", pos, []parseFunc{parseOneOrNoneExpression, parseAs, parseRuleName}, k)
+}
+
+// Checks the rule InlineRepeatExpression
+func parseInlineRepeatExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("InlineRepeatExpression", pos, []parseFunc{parseRepeatExpression, parseAs, parseRuleName}, k)
+}
+
+// Checks the rule InlineRepeatWithSeparatorExpression
+func parseInlineRepeatWithSeparatorExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("InlineRepeatWithSeparatorExpression", pos, []parseFunc{parseRepeatWithSeparatorExpression, parseAs, parseRuleName}, k)
+}
+
+// Checks the rule TokenExpression
+func parseTokenExpression(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("TokenExpression", pos, []parseFunc{parseTokenExpressionBody, parseTokenExpressionFlag}, k)
+}
+
+// Checks the rule TokenExpressionBody
+func parseTokenExpressionBody(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.or("TokenExpressionBody", pos, []parseFunc{parseToken, parseConvenienceToken}, k)
+}
+
+// Checks the rule TokenExpressionFlag
+func parseTokenExpressionFlag(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.oneOrNone("TokenExpressionFlag", pos, parseTokenExpressionFlagValue, k)
+}
+
+// Checks the rule TokenExpressionFlagValue
+func parseTokenExpressionFlagValue(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.seq("TokenExpressionFlagValue", pos, []parseFunc{parseLeftParens, parseTokenExpressionFlagName, parseRightParens}, k)
+}
+
+// Checks the rule TokenExpressionFlagName
+func parseTokenExpressionFlagName(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.or("TokenExpressionFlagName", pos, []parseFunc{parseIgnore, parseRuleName}, k)
+}
+
+// Checks the rule Token
+func parseToken(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "Token", "Token", k)
+}
+
+// Checks the rule ConvenienceToken
+func parseConvenienceToken(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "ConvenienceToken", "ConvenienceToken", k)
+}
+
+// Checks the rule Literal
+func parseLiteral(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "Literal", "Literal", k)
+}
+
+// Checks the rule As
+func parseAs(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "As", "As", k)
+}
+
+// Checks the rule Ignore
+func parseIgnore(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "Ignore", "Ignore", k)
+}
+
+// Checks the rule InlineFlag
+func parseInlineFlag(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "InlineFlag", "InlineFlag", k)
+}
+
+// Checks the rule CollapseFlag
+func parseCollapseFlag(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "CollapseFlag", "CollapseFlag", k)
+}
+
+// Checks the rule RuleName
+func parseRuleName(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "RuleName", "RuleName", k)
+}
+
+// Checks the rule AlternativesAssignment
+func parseAlternativesAssignment(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "AlternativesAssignment", "AlternativesAssignment", k)
+}
+
+// Checks the rule Pipe
+func parsePipe(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "Pipe", "Pipe", k)
+}
+
+// Checks the rule Cut
+func parseCut(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "Cut", "Cut", k)
+}
+
+// Checks the rule Star
+func parseStar(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "Star", "Star", k)
+}
+
+// Checks the rule Plus
+func parsePlus(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "Plus", "Plus", k)
+}
+
+// Checks the rule QuestionMark
+func parseQuestionMark(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "QuestionMark", "QuestionMark", k)
+}
+
+// Checks the rule LeftBracket
+func parseLeftBracket(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "LeftBracket", "LeftBracket", k)
+}
+
+// Checks the rule RightBracket
+func parseRightBracket(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "RightBracket", "RightBracket", k)
+}
+
+// Checks the rule LeftParens
+func parseLeftParens(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "LeftParens", "LeftParens", k)
+}
+
+// Checks the rule RightParens
+func parseRightParens(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "RightParens", "RightParens", k)
+}
+
+// Checks the rule LeftCurly
+func parseLeftCurly(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "LeftCurly", "LeftCurly", k)
+}
+
+// Checks the rule RightCurly
+func parseRightCurly(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "RightCurly", "RightCurly", k)
+}
+
+// Checks the rule Count
+func parseCount(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "Count", "Count", k)
+}
+
+// Checks the rule Comma
+func parseComma(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "Comma", "Comma", k)
+}
+
+// Checks the rule LeftAngle
+func parseLeftAngle(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "LeftAngle", "LeftAngle", k)
+}
+
+// Checks the rule RightAngle
+func parseRightAngle(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "RightAngle", "RightAngle", k)
+}
+
+// Checks the rule Assignment
+func parseAssignment(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "Assignment", "Assignment", k)
+}
+
+// Checks the rule Virtual
+func parseVirtual(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "Virtual", "Virtual", k)
+}
+
+// Checks the rule Comment
+func parseComment(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "Comment", "Comment", k)
+}
+
+// Checks the rule Space
+func parseSpace(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "Space", "Space", k)
+}
+
+// Receives a result of a rule and the position of the next token, and returns true to stop the parse
+type continuation func(node model.Node, next int) bool
+
+// Calls k for each result of a rule at pos, and returns true when the parse was stopped,
+// or the error that the rule produces after its results
+type parseFunc func(p *parser, pos int, k continuation) (bool, *model.RuleError)
+
+type memoResult struct {
+	node model.Node
+	next int
+}
+
+type memoEntry struct {
+	results []memoResult
+	err     *model.RuleError
+	fatal   *model.RuleError
+}
+
+type memoKey struct {
+	rule int
+	pos  int
+}
+
+type parser struct {
+	tokens []model.Token
+	fatal  *model.RuleError
+	memos  map[memoKey]*memoEntry
+}
+
+// Splits the input into tokens, with the token definitions of the grammar
+func Tokenize(text string) ([]model.Token, error) {
+	tokens := []model.Token{}
+	line := 1
+	col := 0
+	index := 0
+	var err error
+
+	skips := 0
+
+	for {
+		if index >= len(text) {
+			tokens = append(tokens, model.Token{Type: "TOKEN_EOF", Value: "", Line: line + 1, Col: 0, Offset: len(text)})
+			break
+		}
+
+		nextToken := model.Token{Offset: index}
+		if text[index] == '\n' {
+			nextToken.Col = col + 1
+			nextToken.Line = line
+			col = 0
+			line++
+		} else {
+			col++
+			nextToken.Col = col
+			nextToken.Line = line
+		}
+
+		if skips > 0 {
+			skips--
+			index++
+			continue
+		}
+
+		remainingText := text[index:]
+		hasToken := false
+		for _, def := range tokenDefs {
+			if match := def.Pattern.FindString(remainingText); match != "" {
+				nextToken.Type = def.Type
+				nextToken.Value = match
+				hasToken = true
+				skips = len(match) - 1
+				break
+			}
+		}
+		if !hasToken {
+			err = &lexer.IllegalCharacterError{Character: string(text[index]), Line: line, Col: col}
+			break
+		} else {
+			tokens = append(tokens, nextToken)
+		}
+		index++
+	}
+
+	return tokens, err
+}
+
+// Returns the tree for the rule ruleType, which must match the whole input
+func Parse(ruleType, input string) (*model.Node, error) {
+	tokens, err := Tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTokens(ruleType, tokens)
+}
+
+// Works like Parse, but receives the tokens returned by Tokenize, after any changes made by token reducers
+func ParseTokens(ruleType string, tokens []model.Token) (*model.Node, error) {
+	rule, ok := rules[ruleType]
+	if !ok {
+		return nil, fmt.Errorf("Undefined rule %q", ruleType)
+	}
+
+	validTokens := []model.Token{}
+	for _, token := range tokens {
+		if !ignoredTokenTypes[token.Type] {
+			validTokens = append(validTokens, token)
+		}
+	}
+
+	p := &parser{tokens: validTokens, memos: map[memoKey]*memoEntry{}}
+	var match *model.Node
+	_, err := p.seq("Root", 0, []parseFunc{rule, parseEOF}, func(node model.Node, next int) bool {
+		match = &node
+		return true
+	})
+
+	if p.fatal != nil {
+		return nil, p.fatal.GetError()
+	}
+	if match != nil {
+		match.Rules = shapeChildren(match.Rules)
+		return match, nil
+	}
+	if err == nil {
+		return nil, fmt.Errorf("found an unexpected error during parsing")
+	}
+	return nil, err.GetError()
+}
+
+func parseEOF(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "EOF", "TOKEN_EOF", k)
+}
+
+func isInline(ruleType string) bool {
+	name := ruleType[strings.LastIndex(ruleType, ".")+1:]
+	return inlineRules[ruleType] || strings.HasPrefix(name, "_")
+}
+
+func shapeChildren(nodes []model.Node) []model.Node {
+	if nodes == nil {
+		return nil
+	}
+	result := []model.Node{}
+	for _, child := range nodes {
+		child.Rules = shapeChildren(child.Rules)
+		if droppedRules[child.Type] {
+			continue
+		}
+		if child.Token == nil && isInline(child.Type) {
+			result = append(result, child.Rules...)
+			continue
+		}
+		if collapsedRules[child.Type] && len(child.Rules) == 1 {
+			child = child.Rules[0]
+		}
+		result = append(result, child)
+	}
+	return result
+}
+
+func furthest(current, candidate *model.RuleError) *model.RuleError {
+	if current == nil || candidate.Token.IsAfter(current.Token) {
+		return candidate
+	}
+	return current
+}
+
+func (p *parser) repeatError(ruleType string, pos int, err *model.RuleError) *model.RuleError {
+	if err != nil {
+		return err
+	}
+	err = &model.RuleError{RuleType: ruleType}
+	if pos < len(p.tokens) {
+		err.Token = p.tokens[pos]
+	}
+	return err
+}
+
+func (p *parser) token(pos int, ruleType string, matches func(model.Token) bool, k continuation) (bool, *model.RuleError) {
+	if pos >= len(p.tokens) {
+		return false, &model.RuleError{Token: model.Token{Type: "NULL", Value: "STREAM_END"}, RuleType: ruleType}
+	}
+	token := p.tokens[pos]
+	if !matches(token) {
+		return false, &model.RuleError{Token: token, RuleType: ruleType}
+	}
+	return k(model.Node{Type: ruleType, Token: &token}, pos+1), nil
+}
+
+func (p *parser) tokenType(pos int, ruleType, tokenType string, k continuation) (bool, *model.RuleError) {
+	return p.token(pos, ruleType, func(token model.Token) bool {
+		return token.Type == tokenType
+	}, k)
+}
+
+func (p *parser) tokenValue(pos int, ruleType, value string, ignoreCase bool, k continuation) (bool, *model.RuleError) {
+	return p.token(pos, ruleType, func(token model.Token) bool {
+		if ignoreCase {
+			return strings.EqualFold(token.Value, value)
+		}
+		return token.Value == value
+	}, k)
+}
+
+// Calls k with the nodes of every combination of results of the rules. A nil rule is a cut
+func (p *parser) sequence(ruleType string, pos int, rules []parseFunc, k func(nodes []model.Node, next int) bool) (bool, *model.RuleError) {
+	if len(rules) == 0 {
+		return k(nil, pos), nil
+	}
+	if rules[0] == nil {
+		return p.committed(ruleType, pos, rules[1:], k)
+	}
+
+	var err *model.RuleError
+	stop, headErr := rules[0](p, pos, func(head model.Node, next int) bool {
+		stop, tailErr := p.sequence(ruleType+":Seq", next, rules[1:], func(tail []model.Node, last int) bool {
+			return k(append([]model.Node{head}, tail...), last)
+		})
+		if !stop && tailErr != nil {
+			err = furthest(err, tailErr)
+		}
+		return stop
+	})
+	if stop {
+		return true, nil
+	}
+	if headErr != nil {
+		err = furthest(err, headErr)
+	}
+	return false, err
+}
+
+// Checks the rules after a cut, stopping the parse with a fatal error when they have no result
+func (p *parser) committed(ruleType string, pos int, rules []parseFunc, k func(nodes []model.Node, next int) bool) (bool, *model.RuleError) {
+	success := false
+	stop, err := p.sequence(ruleType, pos, rules, func(nodes []model.Node, next int) bool {
+		success = true
+		return k(nodes, next)
+	})
+	if stop || success {
+		return stop, nil
+	}
+
+	fatal := model.RuleError{RuleType: ruleType, Fatal: true}
+	if err != nil {
+		fatal = *err
+		fatal.Fatal = true
+	} else if pos < len(p.tokens) {
+		fatal.Token = p.tokens[pos]
+	}
+	p.fatal = &fatal
+	return true, nil
+}
+
+func (p *parser) seq(ruleType string, pos int, rules []parseFunc, k continuation) (bool, *model.RuleError) {
+	return p.sequence(ruleType, pos, rules, func(nodes []model.Node, next int) bool {
+		return k(model.Node{Type: ruleType, Rules: nodes}, next)
+	})
+}
+
+func (p *parser) or(ruleType string, pos int, rules []parseFunc, k continuation) (bool, *model.RuleError) {
+	success := false
+	var err *model.RuleError
+	for _, rule := range rules {
+		stop, ruleErr := rule(p, pos, func(node model.Node, next int) bool {
+			success = true
+			return k(model.Node{Type: ruleType, Rules: []model.Node{node}}, next)
+		})
+		if stop {
+			return true, nil
+		}
+		if ruleErr != nil {
+			err = furthest(err, ruleErr)
+		}
+	}
+	if success {
+		return false, nil
+	}
+	return false, err
+}
+
+func (p *parser) rename(ruleType string, pos int, rule parseFunc, k continuation) (bool, *model.RuleError) {
+	stop, err := rule(p, pos, func(node model.Node, next int) bool {
+		node.Type = ruleType
+		return k(node, next)
+	})
+	if err != nil {
+		renamed := *err
+		renamed.RuleType = ruleType
+		err = &renamed
+	}
+	return stop, err
+}
+
+func (p *parser) oneOrNone(ruleType string, pos int, rule parseFunc, k continuation) (bool, *model.RuleError) {
+	stop, _ := rule(p, pos, func(node model.Node, next int) bool {
+		return k(model.Node{Type: ruleType, Rules: []model.Node{node}}, next)
+	})
+	if stop {
+		return true, nil
+	}
+	return k(model.Node{Type: ruleType, Rules: []model.Node{}}, pos), nil
+}
+
+func decreaseCount(count int) int {
+	if count <= 0 {
+		return count
+	}
+	return count - 1
+}
+
+// Calls k with the nodes of the rule matched at least min and at most max times, longer matches first
+func (p *parser) repeatNodes(ruleType string, pos int, rule parseFunc, min, max int, k func(nodes []model.Node, next int) bool) (bool, *model.RuleError) {
+	if max != 0 {
+		var err *model.RuleError
+		success := false
+		stop, ruleErr := rule(p, pos, func(node model.Node, next int) bool {
+			stop, nextErr := p.repeatNodes(ruleType, next, rule, decreaseCount(min), decreaseCount(max), func(nodes []model.Node, last int) bool {
+				success = true
+				return k(append([]model.Node{node}, nodes...), last)
+			})
+			if !stop && nextErr != nil {
+				err = furthest(err, nextErr)
+			}
+			return stop
+		})
+		if stop {
+			return true, nil
+		}
+		if ruleErr != nil {
+			err = furthest(err, ruleErr)
+		}
+		if min > 0 {
+			if success {
+				return false, nil
+			}
+			return false, p.repeatError(ruleType, pos, err)
+		}
+	}
+	return k([]model.Node{}, pos), nil
+}
+
+// Calls k with the nodes of the rule matched at least min and at most max times, with the separator between them
+func (p *parser) separatedNodes(ruleType string, pos int, rule, separator parseFunc, min, max int, k func(nodes []model.Node, next int) bool) (bool, *model.RuleError) {
+	tailItem := func(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+		return p.seq(ruleType+":TailItem", pos, []parseFunc{separator, rule}, k)
+	}
+	if max != 0 {
+		var err *model.RuleError
+		success := false
+		stop, ruleErr := rule(p, pos, func(node model.Node, next int) bool {
+			stop, tailErr := p.repeatNodes(ruleType+":Tail", next, tailItem, decreaseCount(min), decreaseCount(max), func(items []model.Node, last int) bool {
+				nodes := []model.Node{node}
+				for _, item := range items {
+					nodes = append(nodes, item.Rules...)
+				}
+				success = true
+				return k(nodes, last)
+			})
+			if !stop && tailErr != nil {
+				err = furthest(err, tailErr)
+			}
+			return stop
+		})
+		if stop {
+			return true, nil
+		}
+		if ruleErr != nil {
+			err = furthest(err, ruleErr)
+		}
+		if min > 0 {
+			if success {
+				return false, nil
+			}
+			return false, p.repeatError(ruleType, pos, err)
+		}
+	}
+	return k([]model.Node{}, pos), nil
+}
+
+// Calls k with the nodes of a separated list followed by a separator, before the nodes of the list alone
+func (p *parser) withTrailingSeparator(ruleType string, pos int, separator parseFunc, list func(k func(nodes []model.Node, next int) bool) (bool, *model.RuleError), k continuation) (bool, *model.RuleError) {
+	success := false
+	stop, err := list(func(nodes []model.Node, next int) bool {
+		success = true
+		if len(nodes) > 0 {
+			stop, _ := separator(p, next, func(node model.Node, last int) bool {
+				return k(model.Node{Type: ruleType, Rules: append(append([]model.Node{}, nodes...), node)}, last)
+			})
+			if stop {
+				return true
+			}
+		}
+		return k(model.Node{Type: ruleType, Rules: nodes}, next)
+	})
+	if stop {
+		return true, nil
+	}
+	if success {
+		return false, nil
+	}
+	return false, p.repeatError(ruleType, pos, err)
+}
+
+func (p *parser) many(ruleType string, pos int, rule parseFunc, k continuation) (bool, *model.RuleError) {
+	return p.repeat(ruleType, pos, rule, 0, -1, k)
+}
+
+func (p *parser) oneOrMany(ruleType string, pos int, rule parseFunc, k continuation) (bool, *model.RuleError) {
+	err := &model.RuleError{RuleType: ruleType}
+	if pos < len(p.tokens) {
+		err.Token = p.tokens[pos]
+	}
+	success := false
+	stop, ruleErr := rule(p, pos, func(node model.Node, next int) bool {
+		success = true
+		stop, _ := p.repeatNodes(ruleType, next, rule, 0, -1, func(nodes []model.Node, last int) bool {
+			return k(model.Node{Type: ruleType, Rules: append([]model.Node{node}, nodes...)}, last)
+		})
+		return stop
+	})
+	if stop {
+		return true, nil
+	}
+	if success {
+		return false, nil
+	}
+	if ruleErr != nil && ruleErr.Token.IsAfter(err.Token) {
+		err = ruleErr
+	}
+	return false, err
+}
+
+func (p *parser) repeat(ruleType string, pos int, rule parseFunc, min, max int, k continuation) (bool, *model.RuleError) {
+	return p.repeatNodes(ruleType, pos, rule, min, max, func(nodes []model.Node, next int) bool {
+		return k(model.Node{Type: ruleType, Rules: nodes}, next)
+	})
+}
+
+func (p *parser) repeatWithSeparator(ruleType string, pos int, rule, separator parseFunc, min, max int, trailing bool, k continuation) (bool, *model.RuleError) {
+	list := func(k func(nodes []model.Node, next int) bool) (bool, *model.RuleError) {
+		return p.separatedNodes(ruleType, pos, rule, separator, min, max, k)
+	}
+	if trailing {
+		return p.withTrailingSeparator(ruleType, pos, separator, list, k)
+	}
+	return list(func(nodes []model.Node, next int) bool {
+		return k(model.Node{Type: ruleType, Rules: nodes}, next)
+	})
+}
+
+func (p *parser) manyWithSeparator(ruleType string, pos int, rule, separator parseFunc, trailing bool, k continuation) (bool, *model.RuleError) {
+	return p.repeatWithSeparator(ruleType, pos, rule, separator, 0, -1, trailing, k)
+}
+
+func (p *parser) oneOrManyWithSeparator(ruleType string, pos int, rule, separator parseFunc, trailing bool, k continuation) (bool, *model.RuleError) {
+	stop, err := p.repeatWithSeparator(ruleType, pos, rule, separator, 1, -1, trailing, k)
+	if err != nil && !err.Token.IsAfter(model.Token{}) {
+		err = &model.RuleError{}
+	}
+	return stop, err
+}
+
+// Collects every result of the rule the first time it is checked at pos, and replays them afterwards
+func (p *parser) memo(rule int, pos int, check parseFunc, k continuation) (bool, *model.RuleError) {
+	key := memoKey{rule: rule, pos: pos}
+	entry, ok := p.memos[key]
+	if !ok {
+		entry = &memoEntry{}
+		_, entry.err = check(p, pos, func(node model.Node, next int) bool {
+			entry.results = append(entry.results, memoResult{node: node, next: next})
+			return false
+		})
+		entry.fatal = p.fatal
+		p.fatal = nil
+		p.memos[key] = entry
+	}
+
+	for _, result := range entry.results {
+		if k(result.node, result.next) {
+			return true, nil
+		}
+	}
+	if entry.fatal != nil {
+		p.fatal = entry.fatal
+		return true, nil
+	}
+	return false, entry.err
+}
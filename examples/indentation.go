@@ -60,10 +60,11 @@ func ParseIndents(input string) IndentList {
 			result := tokens
 			for i := 0; i < indents; i++ {
 				result = append(result, model.Token{
-					Type:  indentType,
-					Value: fmt.Sprintf("%s", indentType),
-					Line:  next.Line,
-					Col:   next.Col,
+					Type:   indentType,
+					Value:  fmt.Sprintf("%s", indentType),
+					Line:   next.Line,
+					Col:    next.Col,
+					Offset: next.Offset,
 				})
 			}
 
@@ -0,0 +1,270 @@
+// Code generated by grammatic. DO NOT EDIT.
+
+package jsonast
+
+import (
+	"fmt"
+	"github.com/jsanchesleao/grammatic/model"
+)
+
+// Converts the tree returned by Grammar.Parse for the rule Value, or a node of that rule, to its type
+func Convert(node *model.Node) (Value, error) {
+	if node.Type == "Root" && len(node.Rules) > 0 {
+		node = &node.Rules[0]
+	}
+	switch node.Type {
+	case "Value", "Object", "Array", "Number", "String", "Bool":
+		return convertValue(node)
+	}
+	return nil, fmt.Errorf("expected a node of the rule %q, got %q", "Value", node.Type)
+}
+
+// One of the alternatives of the rule Value: Object, Array, Number, String, Bool
+type Value interface {
+	isValue()
+}
+
+func convertValue(node *model.Node) (Value, error) {
+	if node.Type == "Value" {
+		if len(node.Rules) == 0 {
+			return nil, nil
+		}
+		node = &node.Rules[0]
+	}
+	switch node.Type {
+	case "Object":
+		value, err := convertObject(node)
+		if err != nil {
+			return nil, err
+		}
+		return value, nil
+	case "Array":
+		value, err := convertArray(node)
+		if err != nil {
+			return nil, err
+		}
+		return value, nil
+	case "Number":
+		value, err := convertNumber(node)
+		if err != nil {
+			return nil, err
+		}
+		return value, nil
+	case "String":
+		value, err := convertString(node)
+		if err != nil {
+			return nil, err
+		}
+		return value, nil
+	case "Bool":
+		value, err := convertBool(node)
+		if err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+	return nil, fmt.Errorf("unexpected node %q in the rule %q", node.Type, "Value")
+}
+
+// Node of the rule Object
+type Object struct {
+	Node    *model.Node
+	Entries Entries
+}
+
+func (*Object) isValue() {}
+
+func convertObject(node *model.Node) (*Object, error) {
+	result := &Object{Node: node}
+	for i := range node.Rules {
+		child := &node.Rules[i]
+		switch child.Type {
+		case "Entries":
+			if result.Entries == nil {
+				value, err := convertEntries(child)
+				if err != nil {
+					return nil, err
+				}
+				result.Entries = value
+			}
+		}
+	}
+	return result, nil
+}
+
+// Node of the rule Array
+type Array struct {
+	Node  *model.Node
+	Items Items
+}
+
+func (*Array) isValue() {}
+
+func convertArray(node *model.Node) (*Array, error) {
+	result := &Array{Node: node}
+	for i := range node.Rules {
+		child := &node.Rules[i]
+		switch child.Type {
+		case "Items":
+			if result.Items == nil {
+				value, err := convertItems(child)
+				if err != nil {
+					return nil, err
+				}
+				result.Items = value
+			}
+		}
+	}
+	return result, nil
+}
+
+// Token of the rule Number
+type Number struct {
+	model.Token
+}
+
+func (*Number) isValue() {}
+
+func convertNumber(node *model.Node) (*Number, error) {
+	if node.Token == nil {
+		return nil, fmt.Errorf("expected a token for the rule %q, got node %q", "Number", node.Type)
+	}
+	return &Number{Token: *node.Token}, nil
+}
+
+// Token of the rule String
+type String struct {
+	model.Token
+}
+
+func (*String) isValue() {}
+
+func convertString(node *model.Node) (*String, error) {
+	if node.Token == nil {
+		return nil, fmt.Errorf("expected a token for the rule %q, got node %q", "String", node.Type)
+	}
+	return &String{Token: *node.Token}, nil
+}
+
+// Token of the rule Bool
+type Bool struct {
+	model.Token
+}
+
+func (*Bool) isValue() {}
+
+func convertBool(node *model.Node) (*Bool, error) {
+	if node.Token == nil {
+		return nil, fmt.Errorf("expected a token for the rule %q, got node %q", "Bool", node.Type)
+	}
+	return &Bool{Token: *node.Token}, nil
+}
+
+// Nodes repeated by the rule Entries
+type Entries []*ObjectEntry
+
+func convertEntries(node *model.Node) (Entries, error) {
+	result := Entries{}
+	for i := range node.Rules {
+		child := &node.Rules[i]
+		switch child.Type {
+		case "ObjectEntry":
+			value, err := convertObjectEntry(child)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, value)
+		}
+	}
+	return result, nil
+}
+
+// Nodes repeated by the rule Items
+type Items []Value
+
+func convertItems(node *model.Node) (Items, error) {
+	result := Items{}
+	for i := range node.Rules {
+		child := &node.Rules[i]
+		switch child.Type {
+		case "Value", "Object", "Array", "Number", "String", "Bool":
+			value, err := convertValue(child)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, value)
+		}
+	}
+	return result, nil
+}
+
+// Node of the rule ObjectEntry
+type ObjectEntry struct {
+	Node   *model.Node
+	String *String
+	Value  Value
+}
+
+func convertObjectEntry(node *model.Node) (*ObjectEntry, error) {
+	result := &ObjectEntry{Node: node}
+	for i := range node.Rules {
+		child := &node.Rules[i]
+		switch child.Type {
+		case "String":
+			if result.String == nil {
+				value, err := convertString(child)
+				if err != nil {
+					return nil, err
+				}
+				result.String = value
+			} else if result.Value == nil {
+				value, err := convertValue(child)
+				if err != nil {
+					return nil, err
+				}
+				result.Value = value
+			}
+		case "Value":
+			if result.Value == nil {
+				value, err := convertValue(child)
+				if err != nil {
+					return nil, err
+				}
+				result.Value = value
+			}
+		case "Object":
+			if result.Value == nil {
+				value, err := convertValue(child)
+				if err != nil {
+					return nil, err
+				}
+				result.Value = value
+			}
+		case "Array":
+			if result.Value == nil {
+				value, err := convertValue(child)
+				if err != nil {
+					return nil, err
+				}
+				result.Value = value
+			}
+		case "Number":
+			if result.Value == nil {
+				value, err := convertValue(child)
+				if err != nil {
+					return nil, err
+				}
+				result.Value = value
+			}
+		case "Bool":
+			if result.Value == nil {
+				value, err := convertValue(child)
+				if err != nil {
+					return nil, err
+				}
+				result.Value = value
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,23 @@
+// Package jsonast shows the typed trees made by grammatic-gen for a JSON grammar
+package jsonast
+
+import (
+	_ "embed"
+	"github.com/jsanchesleao/grammatic"
+)
+
+//go:generate go run ../../cmd/grammatic-gen -grammar json.grammar -rule Value -output ast.go
+
+//go:embed json.grammar
+var grammarText string
+
+var grammar = grammatic.Compile(grammarText)
+
+// Parses a JSON document into its typed tree
+func Parse(input string) (Value, error) {
+	tree, err := grammar.Parse("Value", input)
+	if err != nil {
+		return nil, err
+	}
+	return Convert(tree)
+}
@@ -0,0 +1,68 @@
+package jsonast
+
+import (
+	"bytes"
+	"github.com/jsanchesleao/grammatic"
+	"os"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	value, err := Parse(`{"name": "grammatic", "tags": [true, 42]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	object, ok := value.(*Object)
+	if !ok {
+		t.Fatalf("Expected an object but got %T", value)
+	}
+	if len(object.Entries) != 2 {
+		t.Fatalf("Expected 2 entries but got %d", len(object.Entries))
+	}
+	if object.Entries[0].String.Value != `"name"` {
+		t.Fatalf("Expected first key to be \"name\" but it was %s", object.Entries[0].String.Value)
+	}
+	if name, ok := object.Entries[0].Value.(*String); !ok || name.Value != `"grammatic"` {
+		t.Fatalf("Expected first value to be the string \"grammatic\" but it was %#v", object.Entries[0].Value)
+	}
+
+	tags, ok := object.Entries[1].Value.(*Array)
+	if !ok {
+		t.Fatalf("Expected second value to be an array but got %T", object.Entries[1].Value)
+	}
+	if len(tags.Items) != 2 {
+		t.Fatalf("Expected 2 items but got %d", len(tags.Items))
+	}
+	if flag, ok := tags.Items[0].(*Bool); !ok || flag.Value != "true" {
+		t.Fatalf("Expected first item to be true but it was %#v", tags.Items[0])
+	}
+	if number, ok := tags.Items[1].(*Number); !ok || number.Value != "42" || number.Col != 38 {
+		t.Fatalf("Expected second item to be 42 at column 38 but it was %#v", tags.Items[1])
+	}
+}
+
+func TestGeneratedTypesAreUpToDate(t *testing.T) {
+	expected, err := grammar.GenerateTypes("jsonast", "Value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := os.ReadFile("ast.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(expected, actual) {
+		t.Fatal("ast.go is out of date, run go generate")
+	}
+}
+
+func TestConvertRejectsOtherRules(t *testing.T) {
+	other := grammatic.Compile(grammarText)
+	tree, err := other.Parse("ObjectEntry", `"key": 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Convert(tree); err == nil {
+		t.Fatal("Expected an error when converting a tree of another rule")
+	}
+}
@@ -34,7 +34,12 @@ LParen := /\(/
 RParen := /\)/
 Space := $EmptySpaceFormat (ignore)`
 
-var mathGrammar = grammatic.Compile(mathGrammarDef)
+var mathGrammar = compileMathGrammar()
+
+func compileMathGrammar() *grammatic.Compiled {
+	grammar := grammatic.Compile(mathGrammarDef)
+	return grammar.Freeze()
+}
 
 func EvalExpression(expression string) float64 {
 
@@ -1,6 +1,9 @@
 package examples
 
-import "testing"
+import (
+	"fmt"
+	"testing"
+)
 
 func assertExpressionValue(t *testing.T, expression string, expectedValue float64) {
 
@@ -22,3 +25,29 @@ func TestMathExpression(t *testing.T) {
 	assertExpressionValue(t, "2 * 5 - 12 / (3 - 1)", 4)
 
 }
+
+func TestConcurrentMathExpression(t *testing.T) {
+	expressions := map[string]float64{
+		"1 + 1":                2,
+		"2 * 5":                10,
+		"2 + 3 * 5":            17,
+		"2 * 5 - 12 / (3 - 1)": 4,
+	}
+
+	results := make(chan error)
+	for expression, expectedValue := range expressions {
+		go func(expression string, expectedValue float64) {
+			if actualValue := EvalExpression(expression); actualValue != expectedValue {
+				results <- fmt.Errorf("Expected expression %q to evaluate to %.2f, but it was %.2f", expression, expectedValue, actualValue)
+				return
+			}
+			results <- nil
+		}(expression, expectedValue)
+	}
+
+	for range expressions {
+		if err := <-results; err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -1,9 +1,6 @@
 package examples
 
-import (
-	"github.com/jsanchesleao/grammatic"
-	"github.com/jsanchesleao/grammatic/model"
-)
+import "github.com/jsanchesleao/grammatic"
 
 const urlGrammar = `
 Url := Protocol
@@ -48,22 +45,22 @@ Colon := /:/
 `
 
 type ParsedUrl struct {
-	Protocol string
-	Auth     *Auth
-	Domain   string
-	Path     string
-	Query    []QueryParam
-	Anchor   string
+	Protocol string       `grammatic:"Protocol"`
+	Auth     *Auth        `grammatic:"MaybeCredentials/Credentials"`
+	Domain   string       `grammatic:"Domain"`
+	Path     string       `grammatic:"Path"`
+	Query    []QueryParam `grammatic:"MaybeQueryString/QueryString/QueryStringItems/QueryParam"`
+	Anchor   string       `grammatic:"MaybeHashAnchor/HashAnchor/Word"`
 }
 
 type Auth struct {
-	Username string
-	Password string
+	Username string `grammatic:"AuthUser"`
+	Password string `grammatic:"AuthPassword"`
 }
 
 type QueryParam struct {
-	Key   string
-	Value string
+	Key   string `grammatic:"ParamKey"`
+	Value string `grammatic:"ParamValue"`
 }
 
 func UrlParse(input string) ParsedUrl {
@@ -74,51 +71,13 @@ func UrlParse(input string) ParsedUrl {
 		panic(err)
 	}
 
-	return reduceUrlTree(tree)
-}
-
-func reduceUrlTree(node *model.Node) ParsedUrl {
 	url := ParsedUrl{}
-	node = node.GetNodeWithType("Url")
-
-	url.Protocol = node.GetNodeWithType("Protocol").Token.Value
-	url.Domain = reduceChildNodesToString(node.GetNodeWithType("Domain"))
-	url.Path = reduceChildNodesToString(node.GetNodeWithType("Path"))
-	url.Query = []QueryParam{}
-
-	anchor := node.GetNodeWithType("MaybeHashAnchor").GetNodeWithType("HashAnchor")
-	if anchor != nil {
-		url.Anchor = anchor.GetNodeWithType("Word").Token.Value
-	}
-
-	queryString := node.GetNodeWithType("MaybeQueryString").GetNodeWithType("QueryString")
-	if queryString != nil {
-		params := queryString.GetNodeWithType("QueryStringItems").GetNodesWithType("QueryParam")
-		for _, queryParam := range params {
-			queryStringItem := QueryParam{
-				Key:   queryParam.GetNodeWithType("ParamKey").Token.Value,
-				Value: queryParam.GetNodeWithType("ParamValue").Token.Value,
-			}
-			url.Query = append(url.Query, queryStringItem)
-		}
+	if err := grammatic.Unmarshal(tree.GetNodeWithType("Url"), &url); err != nil {
+		panic(err)
 	}
-
-	auth := node.GetNodeWithType("MaybeCredentials").GetNodeWithType("Credentials")
-	if auth != nil {
-		authValue := Auth{
-			Username: auth.GetNodeWithType("AuthUser").Token.Value,
-			Password: auth.GetNodeWithType("AuthPassword").Token.Value,
-		}
-		url.Auth = &authValue
+	if url.Query == nil {
+		url.Query = []QueryParam{}
 	}
 
 	return url
 }
-
-func reduceChildNodesToString(node *model.Node) string {
-	domain := ""
-	for _, n := range node.GetAllNodes() {
-		domain = domain + n.Token.Value
-	}
-	return domain
-}
@@ -23,6 +23,9 @@ func assertUrlEquals(t *testing.T, expected, actual ParsedUrl) {
 
 func assertUrlQueryString(t *testing.T, expected, actual []QueryParam) {
 	t.Helper()
+	if (expected == nil) != (actual == nil) {
+		t.Fatalf("Expected query string to be %#v but it was %#v", expected, actual)
+	}
 	if len(expected) != len(actual) {
 		t.Fatalf("Expected query string to contain %d items, but it had %d", len(expected), len(actual))
 	}
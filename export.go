@@ -0,0 +1,683 @@
+package grammatic
+
+import (
+	"fmt"
+	"io"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+)
+
+// A standard notation that grammars can be exported to
+type Notation int
+
+const (
+	// The EBNF of ISO/IEC 14977
+	NotationISOEBNF Notation = iota
+	// The ABNF of RFC 5234
+	NotationABNF
+	// The EBNF used by the W3C XML specification
+	NotationW3CEBNF
+)
+
+// Returns the notation with the given name, which is one of ebnf, abnf or w3c
+func ParseNotation(name string) (Notation, error) {
+	switch strings.ToLower(name) {
+	case "ebnf", "iso":
+		return NotationISOEBNF, nil
+	case "abnf":
+		return NotationABNF, nil
+	case "w3c":
+		return NotationW3CEBNF, nil
+	}
+	return 0, fmt.Errorf("unknown notation %q, expected ebnf, abnf or w3c", name)
+}
+
+// Character classes with more characters than this are written as prose in ISO EBNF, which has no ranges
+const isoMaxClassSize = 16
+
+// The operations of the expressions that are written in the notations
+const (
+	exportReference = iota
+	exportTerminal
+	exportClass
+	exportProse
+	exportSequence
+	exportAlternatives
+	exportRepeat
+)
+
+// An expression independent of the notation, built from a rule definition or from a token pattern
+type exportExpression struct {
+	op         int
+	name       string
+	text       string
+	ignoreCase bool
+	ranges     []rune
+	items      []exportExpression
+	min        int
+	max        int
+}
+
+func sequenceOf(items ...exportExpression) exportExpression {
+	if len(items) == 1 {
+		return items[0]
+	}
+	return exportExpression{op: exportSequence, items: items}
+}
+
+func repeatOf(item exportExpression, min, max int) exportExpression {
+	return exportExpression{op: exportRepeat, items: []exportExpression{item}, min: min, max: max}
+}
+
+// Writes the grammar in a standard notation, for specifications and documentation.
+// Every rule except anonymous groups and literals gets a production, with literals written as terminals.
+// Token patterns are translated when the notation can express them, and are otherwise written as prose,
+// like virtual tokens and rules created by custom combinators. Cuts are left out, since they don't change the language
+func (g *Grammar) Export(w io.Writer, notation Notation) error {
+	exporter := &grammarExporter{grammar: g, notation: notation, names: map[string]string{}}
+	exporter.nameRules()
+
+	lines := []string{}
+	for _, name := range g.ruleOrder {
+		if isLiteralName(name) || isGroupName(name) {
+			continue
+		}
+		definition := g.definitions[name]
+		expression := exporter.ruleExpression(name, definition)
+		line := exporter.production(exporter.names[name], exporter.render(expression, levelAlternatives))
+		if definition.kind == kindToken && definition.ignored {
+			line += " " + exporter.comment("ignored, may appear between any two tokens")
+		}
+		lines = append(lines, line)
+	}
+
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+// Same as Grammar.Export
+func (c *Compiled) Export(w io.Writer, notation Notation) error {
+	return c.grammar.Export(w, notation)
+}
+
+type grammarExporter struct {
+	grammar  *Grammar
+	notation Notation
+	names    map[string]string
+}
+
+// Gives every exported rule a name that is valid in the notation, adding a number when two rules get the same name
+func (e *grammarExporter) nameRules() {
+	used := map[string]bool{}
+	for _, name := range e.grammar.ruleOrder {
+		if isLiteralName(name) || isGroupName(name) {
+			continue
+		}
+		base := e.validName(name)
+		exported := base
+		for count := 2; used[strings.ToLower(exported)]; count++ {
+			exported = fmt.Sprintf("%s%s%d", base, e.joiner(), count)
+		}
+		// ABNF rule names are case-insensitive, so names are compared ignoring case in every notation
+		used[strings.ToLower(exported)] = true
+		e.names[name] = exported
+	}
+}
+
+func (e *grammarExporter) joiner() string {
+	if e.notation == NotationABNF {
+		return "-"
+	}
+	return "_"
+}
+
+// Replaces the characters that the notation doesn't allow in names, like the ones of C.Number or List<Item>
+func (e *grammarExporter) validName(name string) string {
+	builder := strings.Builder{}
+	pendingJoiner := false
+	for _, char := range name {
+		allowed := char < unicode.MaxASCII && (unicode.IsLetter(char) || unicode.IsDigit(char))
+		if e.notation == NotationW3CEBNF && char < unicode.MaxASCII && (char == '.' || char == '-') {
+			allowed = true
+		}
+		if !allowed {
+			pendingJoiner = builder.Len() > 0
+			continue
+		}
+		if builder.Len() == 0 && !unicode.IsLetter(char) {
+			builder.WriteString("Rule")
+		}
+		if pendingJoiner {
+			builder.WriteString(e.joiner())
+			pendingJoiner = false
+		}
+		builder.WriteRune(char)
+	}
+	if builder.Len() == 0 {
+		return "Rule"
+	}
+	return builder.String()
+}
+
+// Returns the expression of a rule, or prose that describes it when it can't be expressed
+func (e *grammarExporter) ruleExpression(name string, definition ruleDefinition) exportExpression {
+	switch definition.kind {
+	case "":
+		return exportExpression{op: exportProse, text: "defined by a custom combinator"}
+	case kindVirtualToken:
+		return exportExpression{op: exportProse, text: "virtual token, produced by a token reducer"}
+	case kindToken:
+		if expression, ok := e.patternExpression(definition.pattern); ok {
+			return expression
+		}
+		return exportExpression{op: exportProse, text: "matches the regular expression /" + strings.TrimPrefix(definition.pattern, "^") + "/"}
+	}
+	return e.definitionExpression(definition)
+}
+
+func (e *grammarExporter) definitionExpression(definition ruleDefinition) exportExpression {
+	items := []exportExpression{}
+	for _, rule := range definition.rules {
+		if rule != CutMarker {
+			items = append(items, e.reference(rule))
+		}
+	}
+	separator := exportExpression{}
+	if definition.separator != "" {
+		separator = e.reference(definition.separator)
+	}
+
+	switch definition.kind {
+	case kindLiteral:
+		return exportExpression{op: exportTerminal, text: definition.value, ignoreCase: definition.ignoreCase}
+	case kindSeq:
+		return sequenceOf(items...)
+	case kindOr:
+		return exportExpression{op: exportAlternatives, items: items}
+	case kindRename:
+		return items[0]
+	case kindOneOrNone:
+		return repeatOf(items[0], 0, 1)
+	case kindMany:
+		return repeatOf(items[0], 0, -1)
+	case kindOneOrMany:
+		return repeatOf(items[0], 1, -1)
+	case kindRepeat:
+		return repeatOf(items[0], definition.min, definition.max)
+	case kindManyWithSeparator:
+		return separated(items[0], separator, 0, -1, definition.trailing)
+	case kindOneOrManyWithSeparator:
+		return separated(items[0], separator, 1, -1, definition.trailing)
+	case kindRepeatWithSeparator:
+		return separated(items[0], separator, definition.min, definition.max, definition.trailing)
+	}
+	return exportExpression{op: exportProse, text: "rule of kind " + definition.kind}
+}
+
+// Returns the expression that refers to a rule. Literals become terminals and anonymous groups are written in place
+func (e *grammarExporter) reference(name string) exportExpression {
+	definition, ok := e.grammar.definitions[name]
+	if ok && (isLiteralName(name) || isGroupName(name)) {
+		return e.definitionExpression(definition)
+	}
+	exported, ok := e.names[name]
+	if !ok {
+		exported = e.validName(name)
+	}
+	return exportExpression{op: exportReference, name: exported}
+}
+
+// Returns the expression for min to max items with separators between them, and an optional trailing separator
+func separated(item, separator exportExpression, min, max int, trailing bool) exportExpression {
+	restMin, restMax := 0, -1
+	if min > 1 {
+		restMin = min - 1
+	}
+	if max >= 0 {
+		restMax = max - 1
+	}
+	items := []exportExpression{item}
+	if restMax != 0 {
+		items = append(items, repeatOf(sequenceOf(separator, item), restMin, restMax))
+	}
+	if trailing {
+		items = append(items, repeatOf(separator, 0, 1))
+	}
+	body := sequenceOf(items...)
+	if min == 0 {
+		return repeatOf(body, 0, 1)
+	}
+	return body
+}
+
+// Translates a token pattern, returning false when it uses something the notations can't express, like \b
+func (e *grammarExporter) patternExpression(pattern string) (exportExpression, bool) {
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return exportExpression{}, false
+	}
+	if parsed.Op == syntax.OpConcat && len(parsed.Sub) > 0 && parsed.Sub[0].Op == syntax.OpBeginText {
+		parsed.Sub = parsed.Sub[1:]
+	} else if parsed.Op == syntax.OpBeginText {
+		parsed = &syntax.Regexp{Op: syntax.OpEmptyMatch}
+	}
+	return e.regexpExpression(parsed)
+}
+
+func (e *grammarExporter) regexpExpression(re *syntax.Regexp) (exportExpression, bool) {
+	subs := func() ([]exportExpression, bool) {
+		items := []exportExpression{}
+		for _, sub := range re.Sub {
+			item, ok := e.regexpExpression(sub)
+			if !ok {
+				return nil, false
+			}
+			items = append(items, item)
+		}
+		return items, true
+	}
+
+	switch re.Op {
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase != 0 {
+			return exportExpression{op: exportTerminal, text: strings.ToLower(string(re.Rune)), ignoreCase: true}, true
+		}
+		return exportExpression{op: exportTerminal, text: string(re.Rune)}, true
+	case syntax.OpCharClass:
+		return exportExpression{op: exportClass, ranges: re.Rune}, true
+	case syntax.OpAnyCharNotNL:
+		return exportExpression{op: exportClass, ranges: []rune{0, '\n' - 1, '\n' + 1, unicode.MaxRune}}, true
+	case syntax.OpAnyChar:
+		return exportExpression{op: exportClass, ranges: []rune{0, unicode.MaxRune}}, true
+	case syntax.OpCapture:
+		return e.regexpExpression(re.Sub[0])
+	case syntax.OpEmptyMatch:
+		return exportExpression{op: exportSequence}, true
+	case syntax.OpConcat:
+		items, ok := subs()
+		return sequenceOf(items...), ok
+	case syntax.OpAlternate:
+		items, ok := subs()
+		return exportExpression{op: exportAlternatives, items: items}, ok
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		item, ok := e.regexpExpression(re.Sub[0])
+		min, max := re.Min, re.Max
+		switch re.Op {
+		case syntax.OpStar:
+			min, max = 0, -1
+		case syntax.OpPlus:
+			min, max = 1, -1
+		case syntax.OpQuest:
+			min, max = 0, 1
+		}
+		return repeatOf(item, min, max), ok
+	}
+	return exportExpression{}, false
+}
+
+// How tightly the parts of an expression are bound, to know when it must be written in parentheses
+const (
+	levelAlternatives = iota
+	levelSequence
+	levelPrimary
+)
+
+func (e *grammarExporter) production(name, expression string) string {
+	switch e.notation {
+	case NotationABNF:
+		return name + " = " + expression
+	case NotationW3CEBNF:
+		return name + " ::= " + expression
+	}
+	return name + " = " + expression + " ;"
+}
+
+func (e *grammarExporter) comment(text string) string {
+	switch e.notation {
+	case NotationABNF:
+		return "; " + text
+	case NotationW3CEBNF:
+		return "/* " + strings.ReplaceAll(text, "*/", "*\\/") + " */"
+	}
+	return "(* " + strings.ReplaceAll(text, "*)", "*\\)") + " *)"
+}
+
+func (e *grammarExporter) prose(text string) string {
+	switch e.notation {
+	case NotationABNF:
+		return "<" + escapeProse(text, ">") + ">"
+	case NotationW3CEBNF:
+		return e.comment(text)
+	}
+	return "? " + escapeProse(text, "?") + " ?"
+}
+
+// Replaces the characters that would end a prose value, or that are not printable ASCII, by regular expression escapes
+func escapeProse(text, end string) string {
+	builder := strings.Builder{}
+	for _, char := range text {
+		if strings.ContainsRune(end, char) || char < ' ' || char > '~' {
+			builder.WriteString(fmt.Sprintf("\\x{%X}", char))
+		} else {
+			builder.WriteRune(char)
+		}
+	}
+	return builder.String()
+}
+
+// Returns the expression written in the notation, in parentheses if it binds less tightly than the level requires
+func (e *grammarExporter) render(expression exportExpression, level int) string {
+	text, own := e.renderOwn(expression)
+	if own < level {
+		return "(" + text + ")"
+	}
+	return text
+}
+
+// Returns the expression written in the notation, and how tightly it is bound
+func (e *grammarExporter) renderOwn(expression exportExpression) (string, int) {
+	switch expression.op {
+	case exportReference:
+		return expression.name, levelPrimary
+	case exportProse:
+		return e.prose(expression.text), levelPrimary
+	case exportTerminal:
+		return e.terminal(expression.text, expression.ignoreCase)
+	case exportClass:
+		return e.class(expression.ranges)
+	case exportSequence:
+		if len(expression.items) == 0 {
+			return e.empty(), levelPrimary
+		}
+		separator := " "
+		if e.notation == NotationISOEBNF {
+			separator = ", "
+		}
+		return e.join(expression.items, separator, levelSequence), levelSequence
+	case exportAlternatives:
+		separator := " | "
+		if e.notation == NotationABNF {
+			separator = " / "
+		}
+		return e.join(expression.items, separator, levelSequence), levelAlternatives
+	case exportRepeat:
+		return e.repeat(expression.items[0], expression.min, expression.max)
+	}
+	return "", levelPrimary
+}
+
+func (e *grammarExporter) join(items []exportExpression, separator string, level int) string {
+	texts := []string{}
+	for _, item := range items {
+		texts = append(texts, e.render(item, level))
+	}
+	return strings.Join(texts, separator)
+}
+
+func (e *grammarExporter) empty() string {
+	switch e.notation {
+	case NotationABNF:
+		return `""`
+	case NotationW3CEBNF:
+		return "()"
+	}
+	return "[ ]"
+}
+
+func (e *grammarExporter) repeat(item exportExpression, min, max int) (string, int) {
+	if max == 0 {
+		return e.empty(), levelPrimary
+	}
+	switch e.notation {
+	case NotationABNF:
+		if min == 0 && max == 1 {
+			return "[" + e.render(item, levelAlternatives) + "]", levelPrimary
+		}
+		prefix := ""
+		switch {
+		case min == max:
+			prefix = fmt.Sprint(min)
+		case max < 0 && min == 0:
+			prefix = "*"
+		case max < 0:
+			prefix = fmt.Sprintf("%d*", min)
+		default:
+			prefix = fmt.Sprintf("%d*%d", min, max)
+		}
+		return prefix + e.render(item, levelPrimary), levelPrimary
+
+	case NotationW3CEBNF:
+		text := e.render(item, levelPrimary)
+		switch {
+		case min == 0 && max == 1:
+			return text + "?", levelPrimary
+		case min == 0 && max < 0:
+			return text + "*", levelPrimary
+		case min == 1 && max < 0:
+			return text + "+", levelPrimary
+		}
+		// counted repetitions are written out, since the notation has no counts
+		parts := []string{}
+		for i := 0; i < min; i++ {
+			parts = append(parts, text)
+		}
+		if max < 0 {
+			parts[len(parts)-1] = text + "+"
+		}
+		for i := min; i < max; i++ {
+			parts = append(parts, text+"?")
+		}
+		return strings.Join(parts, " "), levelSequence
+	}
+
+	switch {
+	case min == 0 && max == 1:
+		return "[ " + e.render(item, levelAlternatives) + " ]", levelPrimary
+	case min == 0 && max < 0:
+		return "{ " + e.render(item, levelAlternatives) + " }", levelPrimary
+	}
+	parts := []string{}
+	if min == 1 {
+		parts = append(parts, e.render(item, levelSequence))
+	} else if min > 1 {
+		parts = append(parts, fmt.Sprintf("%d * %s", min, e.render(item, levelPrimary)))
+	}
+	if max < 0 {
+		parts = append(parts, "{ "+e.render(item, levelAlternatives)+" }")
+	} else if max > min {
+		optional := "[ " + e.render(item, levelAlternatives) + " ]"
+		if max-min > 1 {
+			optional = fmt.Sprintf("%d * %s", max-min, optional)
+		}
+		parts = append(parts, optional)
+	}
+	if len(parts) == 1 && min <= 1 {
+		return parts[0], levelSequence
+	}
+	return strings.Join(parts, ", "), levelSequence
+}
+
+// Returns a terminal string. Strings matched ignoring case are written as a choice of cases for every letter,
+// except in ABNF, where quoted strings already ignore case
+func (e *grammarExporter) terminal(text string, ignoreCase bool) (string, int) {
+	parts := []string{}
+	pending := strings.Builder{}
+	flush := func() {
+		if pending.Len() > 0 {
+			parts = append(parts, e.quote(pending.String(), ignoreCase))
+			pending.Reset()
+		}
+	}
+
+	for _, char := range text {
+		switch {
+		case !isPrintable(char) || (e.notation == NotationABNF && (char == '"' || char > '~' || (!ignoreCase && unicode.IsLetter(char)))):
+			flush()
+			parts = append(parts, e.character(char))
+		case ignoreCase && unicode.ToUpper(char) != unicode.ToLower(char) && e.notation != NotationABNF:
+			flush()
+			parts = append(parts, e.caseChoice(char))
+		default:
+			pending.WriteRune(char)
+		}
+	}
+	flush()
+
+	if e.notation == NotationABNF {
+		parts = joinNumericValues(parts)
+	}
+	if len(parts) == 1 {
+		return parts[0], levelPrimary
+	}
+	if len(parts) == 0 {
+		return e.empty(), levelPrimary
+	}
+	separator := " "
+	if e.notation == NotationISOEBNF {
+		separator = ", "
+	}
+	return strings.Join(parts, separator), levelSequence
+}
+
+func isPrintable(char rune) bool {
+	return char >= ' ' && char != unicode.MaxASCII && unicode.IsPrint(char)
+}
+
+// Joins neighbouring ABNF numeric values, like %x61 %x62, into a single %x61.62
+func joinNumericValues(parts []string) []string {
+	result := []string{}
+	for _, part := range parts {
+		last := len(result) - 1
+		if last >= 0 && strings.HasPrefix(part, "%x") && strings.HasPrefix(result[last], "%x") && !strings.Contains(result[last]+part, "-") {
+			result[last] += "." + part[2:]
+			continue
+		}
+		result = append(result, part)
+	}
+	return result
+}
+
+// Returns text as a quoted terminal, using the quote that doesn't appear in it
+func (e *grammarExporter) quote(text string, ignoreCase bool) string {
+	if e.notation == NotationABNF {
+		return `"` + text + `"`
+	}
+	if strings.Contains(text, `"`) {
+		if strings.Contains(text, "'") {
+			// no quote can hold the text, so it is split where the double quotes are
+			parts := strings.Split(text, `"`)
+			quoted := []string{}
+			for i, part := range parts {
+				if i > 0 {
+					quoted = append(quoted, `'"'`)
+				}
+				if part != "" {
+					quoted = append(quoted, `"`+part+`"`)
+				}
+			}
+			separator := " "
+			if e.notation == NotationISOEBNF {
+				separator = ", "
+			}
+			return strings.Join(quoted, separator)
+		}
+		return "'" + text + "'"
+	}
+	return `"` + text + `"`
+}
+
+// Returns a single character that can't be written in a quoted terminal
+func (e *grammarExporter) character(char rune) string {
+	switch e.notation {
+	case NotationABNF:
+		return fmt.Sprintf("%%x%02X", char)
+	case NotationW3CEBNF:
+		return fmt.Sprintf("#x%X", char)
+	}
+	return fmt.Sprintf("? U+%04X ?", char)
+}
+
+func (e *grammarExporter) caseChoice(char rune) string {
+	lower, upper := string(unicode.ToLower(char)), string(unicode.ToUpper(char))
+	if e.notation == NotationW3CEBNF {
+		return "[" + lower + upper + "]"
+	}
+	return `("` + lower + `" | "` + upper + `")`
+}
+
+// Returns a character class, from its pairs of first and last characters
+func (e *grammarExporter) class(ranges []rune) (string, int) {
+	size := 0
+	for i := 0; i+1 < len(ranges); i += 2 {
+		size += int(ranges[i+1]-ranges[i]) + 1
+	}
+
+	switch e.notation {
+	case NotationABNF:
+		choices := []string{}
+		for i := 0; i+1 < len(ranges); i += 2 {
+			if ranges[i] == ranges[i+1] {
+				choices = append(choices, fmt.Sprintf("%%x%02X", ranges[i]))
+			} else {
+				choices = append(choices, fmt.Sprintf("%%x%02X-%02X", ranges[i], ranges[i+1]))
+			}
+		}
+		if len(choices) == 1 {
+			return choices[0], levelPrimary
+		}
+		return strings.Join(choices, " / "), levelAlternatives
+
+	case NotationW3CEBNF:
+		negated := len(ranges) >= 2 && ranges[0] == 0 && ranges[len(ranges)-1] == unicode.MaxRune
+		if negated {
+			complement := []rune{}
+			for i := 1; i+1 < len(ranges); i += 2 {
+				complement = append(complement, ranges[i]+1, ranges[i+1]-1)
+			}
+			if len(complement) == 0 {
+				return "[#x0-#x10FFFF]", levelPrimary
+			}
+			return "[^" + w3cRanges(complement) + "]", levelPrimary
+		}
+		return "[" + w3cRanges(ranges) + "]", levelPrimary
+	}
+
+	if size > isoMaxClassSize {
+		return "? " + escapeProse(classText(ranges), "?") + " ?", levelPrimary
+	}
+	choices := []string{}
+	for i := 0; i+1 < len(ranges); i += 2 {
+		for char := ranges[i]; char <= ranges[i+1]; char++ {
+			text, _ := e.terminal(string(char), false)
+			choices = append(choices, text)
+		}
+	}
+	if len(choices) == 1 {
+		return choices[0], levelPrimary
+	}
+	return strings.Join(choices, " | "), levelAlternatives
+}
+
+func w3cRanges(ranges []rune) string {
+	builder := strings.Builder{}
+	for i := 0; i+1 < len(ranges); i += 2 {
+		builder.WriteString(w3cClassChar(ranges[i]))
+		if ranges[i+1] != ranges[i] {
+			builder.WriteString("-" + w3cClassChar(ranges[i+1]))
+		}
+	}
+	return builder.String()
+}
+
+func w3cClassChar(char rune) string {
+	if char < unicode.MaxASCII && (unicode.IsLetter(char) || unicode.IsDigit(char)) {
+		return string(char)
+	}
+	return fmt.Sprintf("#x%X", char)
+}
+
+// Returns the class in the syntax of regular expressions, for the prose of ISO EBNF
+func classText(ranges []rune) string {
+	return (&syntax.Regexp{Op: syntax.OpCharClass, Rune: ranges}).String()
+}
@@ -0,0 +1,119 @@
+package grammatic
+
+import (
+	"strings"
+	"testing"
+)
+
+const exportGrammar = `
+List := '['i Item[',',]* ']'
+Item := Number | Name | _Pair | C.Call
+_Pair := '(' ^ Item Item ')'
+C.Call := Name{1,3} Name*
+Number := /\d{2,}(\.\d+)?/
+Name := /[a-z_]+/
+Word := /\w+\b/
+Quote := /"'/
+Space := /[ \t]+/ (ignore)
+:virtual: Indent`
+
+func exportText(t *testing.T, notation Notation) string {
+	t.Helper()
+	grammar := Compile(exportGrammar)
+	builder := &strings.Builder{}
+	if err := grammar.Export(builder, notation); err != nil {
+		t.Fatal(err)
+	}
+	return builder.String()
+}
+
+func TestExportISOEBNF(t *testing.T) {
+	expected := `List = "[", [ Item, { ",", Item }, [ "," ] ], "]" ;
+Item = Number | Name | Pair | C_Call ;
+Pair = "(", Item, Item, ")" ;
+C_Call = Name, 2 * [ Name ], { Name } ;
+Number = 2 * ("0" | "1" | "2" | "3" | "4" | "5" | "6" | "7" | "8" | "9"), { "0" | "1" | "2" | "3" | "4" | "5" | "6" | "7" | "8" | "9" }, [ ".", ("0" | "1" | "2" | "3" | "4" | "5" | "6" | "7" | "8" | "9"), { "0" | "1" | "2" | "3" | "4" | "5" | "6" | "7" | "8" | "9" } ] ;
+Name = ? [_a-z] ?, { ? [_a-z] ? } ;
+Word = ? matches the regular expression /\w+\b/ ? ;
+Quote = '"', "'" ;
+Space = (? U+0009 ? | " "), { ? U+0009 ? | " " } ; (* ignored, may appear between any two tokens *)
+Indent = ? virtual token, produced by a token reducer ? ;
+`
+	if text := exportText(t, NotationISOEBNF); text != expected {
+		t.Fatalf("Unexpected ISO EBNF\n%s", text)
+	}
+}
+
+func TestExportABNF(t *testing.T) {
+	expected := `List = "[" [Item *("," Item) [","]] "]"
+Item = Number / Name / Pair / C-Call
+Pair = "(" Item Item ")"
+C-Call = 1*3Name *Name
+Number = 2*%x30-39 ["." 1*%x30-39]
+Name = 1*(%x5F / %x61-7A)
+Word = <matches the regular expression /\w+\b/>
+Quote = %x22 "'"
+Space = 1*(%x09 / %x20) ; ignored, may appear between any two tokens
+Indent = <virtual token, produced by a token reducer>
+`
+	if text := exportText(t, NotationABNF); text != expected {
+		t.Fatalf("Unexpected ABNF\n%s", text)
+	}
+}
+
+func TestExportW3CEBNF(t *testing.T) {
+	expected := `List ::= "[" (Item ("," Item)* ","?)? "]"
+Item ::= Number | Name | Pair | C.Call
+Pair ::= "(" Item Item ")"
+C.Call ::= Name Name? Name? Name*
+Number ::= [0-9] [0-9]+ ("." [0-9]+)?
+Name ::= [#x5Fa-z]+
+Word ::= /* matches the regular expression /\w+\b/ */
+Quote ::= '"' "'"
+Space ::= [#x9#x20]+ /* ignored, may appear between any two tokens */
+Indent ::= /* virtual token, produced by a token reducer */
+`
+	if text := exportText(t, NotationW3CEBNF); text != expected {
+		t.Fatalf("Unexpected W3C EBNF\n%s", text)
+	}
+}
+
+func TestExportCaseAndNames(t *testing.T) {
+	grammar := NewGrammar()
+	grammar.DefineRule("Select", grammar.Seq(grammar.LiteralIgnoreCase("select"), grammar.Literal("from"), "Item-Name", "item_name"))
+	grammar.DefineToken("Item-Name", "^x")
+	grammar.DefineToken("item_name", "^(?i)y")
+
+	expected := map[Notation]string{
+		NotationISOEBNF: `Select = ("s" | "S"), ("e" | "E"), ("l" | "L"), ("e" | "E"), ("c" | "C"), ("t" | "T"), "from", Item_Name, item_name_2 ;
+Item_Name = "x" ;
+item_name_2 = ("y" | "Y") ;
+`,
+		NotationABNF: `Select = "select" %x66.72.6F.6D Item-Name item-name-2
+Item-Name = %x78
+item-name-2 = "y"
+`,
+		NotationW3CEBNF: `Select ::= [sS] [eE] [lL] [eE] [cC] [tT] "from" Item-Name item_name
+Item-Name ::= "x"
+item_name ::= [yY]
+`,
+	}
+	for notation, want := range expected {
+		builder := &strings.Builder{}
+		grammar.Export(builder, notation)
+		if builder.String() != want {
+			t.Fatalf("Unexpected export in notation %d\n%s", notation, builder.String())
+		}
+	}
+}
+
+func TestParseNotation(t *testing.T) {
+	for name, want := range map[string]Notation{"ebnf": NotationISOEBNF, "ABNF": NotationABNF, "w3c": NotationW3CEBNF} {
+		if notation, err := ParseNotation(name); err != nil || notation != want {
+			t.Fatalf("Unexpected notation %d for %q, with error %v", notation, name, err)
+		}
+	}
+	if _, err := ParseNotation("bnf"); err == nil {
+		t.Fatal("Expected an error for an unknown notation")
+	}
+}
@@ -0,0 +1,69 @@
+package grammatic
+
+import (
+	"fmt"
+)
+
+// Returns a copy of the grammar that can be changed without affecting it, to define a dialect of the grammar.
+// Rules can then be replaced with Override, or get new alternatives with AddAlternatives.
+// Fails if the grammar has rules created by custom combinators, since those cannot be copied
+func (g *Grammar) Extend() (Grammar, error) {
+	child := NewGrammar()
+	if err := child.Include(g, ""); err != nil {
+		return Grammar{}, err
+	}
+	child.TokenReducers = append(child.TokenReducers, g.TokenReducers...)
+
+	for name, template := range g.templates {
+		if child.templates == nil {
+			child.templates = map[string]*ruleTemplate{}
+		}
+		instances := map[string]bool{}
+		for instance := range template.instances {
+			instances[instance] = true
+		}
+		child.templates[name] = &ruleTemplate{
+			params:     template.params,
+			definition: template.definition,
+			instances:  instances,
+		}
+	}
+	return child, nil
+}
+
+// Same as Grammar.Extend
+func (c *Compiled) Extend() (Grammar, error) {
+	return c.grammar.Extend()
+}
+
+// Replaces the definition of an existing rule. Every rule that refers to it uses the new definition
+func (g *Grammar) Override(ruleName string, combinator GrammarCombinator) error {
+	if g.frozen {
+		return ErrFrozenGrammar
+	}
+	if !g.isDefined(ruleName) {
+		return fmt.Errorf("Undefined rule %q", ruleName)
+	}
+	return g.DefineRule(ruleName, combinator)
+}
+
+// Adds alternatives to the end of an existing Or rule
+func (g *Grammar) AddAlternatives(ruleName string, alternatives ...string) error {
+	if g.frozen {
+		return ErrFrozenGrammar
+	}
+	definition, ok := g.definitions[ruleName]
+	if !ok {
+		return fmt.Errorf("Undefined rule %q", ruleName)
+	}
+	if definition.kind != kindOr {
+		return fmt.Errorf("Cannot add alternatives to rule %q, because it is not an Or rule", ruleName)
+	}
+
+	rules := append(append([]string{}, definition.rules...), alternatives...)
+	combinator := g.Or(rules...)
+	if definition.memo {
+		combinator = g.Memo(combinator)
+	}
+	return g.DefineRule(ruleName, combinator)
+}
@@ -0,0 +1,72 @@
+package grammatic
+
+import (
+	"testing"
+)
+
+const sqlGrammar = `
+Statement := Select | Delete
+
+Select := 'select'i Name 'from'i Name
+Delete := 'delete'i 'from'i Name
+
+Name := /[a-z_]+/
+Space := $EmptySpaceFormat (ignore)`
+
+func TestExtend(t *testing.T) {
+	base := Compile(sqlGrammar)
+
+	dialect, err := base.Extend()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dialect.Load(`
+Statement |= Vacuum | Listen
+
+Vacuum := 'vacuum'i Name
+Listen := 'listen'i Name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, input := range []string{"select a from t", "delete from t", "vacuum t", "LISTEN channel"} {
+		if _, err := dialect.Parse("Statement", input); err != nil {
+			t.Fatalf("Expected %q to be parsed by the dialect, but it failed with %v", input, err)
+		}
+	}
+
+	if _, err := base.Parse("Statement", "vacuum t"); err == nil {
+		t.Fatal("Expected the base grammar to stay unchanged, but it parsed a statement of the dialect")
+	}
+	if _, err := base.Parse("Statement", "select a from t"); err != nil {
+		t.Fatalf("Expected the base grammar to keep working, but got %v", err)
+	}
+}
+
+func TestOverride(t *testing.T) {
+	base := Compile(sqlGrammar)
+
+	dialect, err := base.Extend()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := dialect.Override("Name", dialect.Token("^[a-z_]+(\\.[a-z_]+)?")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := dialect.Parse("Statement", "select a from public.t"); err != nil {
+		t.Fatalf("Expected the overridden rule to be used, but got %v", err)
+	}
+	if _, err := base.Parse("Statement", "select a from public.t"); err == nil {
+		t.Fatal("Expected the base grammar to stay unchanged")
+	}
+
+	if err := dialect.Override("Missing", dialect.Rename("Name")); err == nil {
+		t.Fatal("Expected overriding an undefined rule to fail")
+	}
+	if err := dialect.AddAlternatives("Select", "Delete"); err == nil {
+		t.Fatal("Expected adding alternatives to a Seq rule to fail")
+	}
+}
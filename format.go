@@ -0,0 +1,351 @@
+package grammatic
+
+import (
+	"github.com/jsanchesleao/grammatic/lexer"
+	"github.com/jsanchesleao/grammatic/model"
+	"strings"
+)
+
+// The kinds of statements of a grammar definition, in the order FormatGrammar writes them
+const (
+	statementProduction = iota
+	statementToken
+	statementVirtual
+)
+
+// A rule or :virtual: declaration of a grammar definition, with the comments that belong to it
+type formatStatement struct {
+	kind        int
+	index       int
+	leading     []model.Token
+	tokens      []model.Token
+	blankBefore bool
+	multiline   bool
+}
+
+// Rewrites a grammar definition in a canonical layout, returning an error if the definition is invalid.
+// Each rule starts at the first column, the := of neighbouring rules and the | of alternatives are aligned,
+// and token rules are moved after the production rules. Line breaks between the items of a rule
+// and # comments are kept, with the comments above a rule moving along with it
+func FormatGrammar(grammarText string) (string, error) {
+	parsingGrammar := GrammarParsingGrammar()
+
+	node, err := parsingGrammar.Parse("Grammar", grammarText)
+	if err != nil {
+		return "", err
+	}
+	tokens, err := parsingGrammar.Tokenize(grammarText)
+	if err != nil {
+		return "", err
+	}
+
+	starts := statementStarts(node.GetNodeWithType("Grammar"))
+
+	statements := []*formatStatement{}
+	header := []model.Token{}
+	pending := []model.Token{}
+	var current *formatStatement
+	previousLine := 0
+
+	for _, token := range tokens {
+		switch {
+		case token.Type == "Space" || token.Type == lexer.TYPE_EOF:
+			continue
+
+		case token.Type == "Comment":
+			if current != nil && len(pending) == 0 && token.Line == previousLine {
+				current.tokens = append(current.tokens, token)
+			} else {
+				pending = append(pending, token)
+			}
+
+		default:
+			kind, isStart := starts[token.Offset]
+			if !isStart {
+				current.tokens = append(current.tokens, pending...)
+				current.tokens = append(current.tokens, token)
+				pending = nil
+				break
+			}
+
+			if current == nil {
+				// comments separated from the first statement by a blank line describe the whole file
+				split := 0
+				for i := range pending {
+					next := token
+					if i+1 < len(pending) {
+						next = pending[i+1]
+					}
+					if next.Line > pending[i].Line+1 {
+						split = i + 1
+					}
+				}
+				header = pending[:split]
+				pending = pending[split:]
+				if len(header) > 0 {
+					previousLine = header[len(header)-1].Line
+				}
+			}
+
+			first := token
+			if len(pending) > 0 {
+				first = pending[0]
+			}
+			current = &formatStatement{
+				kind:        kind,
+				index:       len(statements),
+				leading:     pending,
+				tokens:      []model.Token{token},
+				blankBefore: previousLine > 0 && first.Line > previousLine+1,
+			}
+			statements = append(statements, current)
+			pending = nil
+		}
+		previousLine = token.Line
+	}
+
+	ordered := []*formatStatement{}
+	for _, kind := range []int{statementProduction, statementToken, statementVirtual} {
+		for _, statement := range statements {
+			if statement.kind == kind {
+				ordered = append(ordered, statement)
+			}
+		}
+	}
+
+	// statements that were next to each other and fit in one line form a paragraph, whose := are aligned
+	paragraphs := [][]*formatStatement{}
+	for i, statement := range ordered {
+		statement.multiline = len(layoutStatement(statement.tokens)) > 1
+		if i == 0 || statement.multiline || statement.blankBefore || statement.kind == statementVirtual ||
+			ordered[i-1].multiline || ordered[i-1].index != statement.index-1 || ordered[i-1].kind != statement.kind {
+			paragraphs = append(paragraphs, nil)
+		}
+		last := len(paragraphs) - 1
+		paragraphs[last] = append(paragraphs[last], statement)
+	}
+
+	output := []string{}
+	writeComments(&output, header)
+	if len(header) > 0 {
+		output = append(output, "")
+	}
+	for i, paragraph := range paragraphs {
+		if i > 0 {
+			output = append(output, "")
+		}
+		width := 0
+		for _, statement := range paragraph {
+			if headWidth := len(renderHead(statement.tokens)); headWidth > width {
+				width = headWidth
+			}
+		}
+		for _, statement := range paragraph {
+			writeComments(&output, statement.leading)
+			if len(statement.leading) > 0 && statement.tokens[0].Line > statement.leading[len(statement.leading)-1].Line+1 {
+				output = append(output, "")
+			}
+			output = append(output, renderStatement(statement.tokens, width)...)
+		}
+	}
+	if len(pending) > 0 {
+		if len(statements) > 0 && pending[0].Line > lastLine(statements[len(statements)-1])+1 {
+			output = append(output, "")
+		}
+		writeComments(&output, pending)
+	}
+
+	return strings.Join(output, "\n") + "\n", nil
+}
+
+// Returns the kinds of the statements of the grammar, by the offset of their first token
+func statementStarts(grammarNode *model.Node) map[int]int {
+	starts := map[int]int{}
+	for _, ruleNode := range grammarNode.GetNodeWithType("GrammarRules").GetNodesWithType("GrammarRule") {
+		kind := statementProduction
+		if ruleNode.GetNodeWithType("RuleExpression").Rules[0].Type == "TokenExpression" {
+			kind = statementToken
+		}
+		starts[ruleNode.GetNodeWithType("RuleName").Token.Offset] = kind
+	}
+	virtual := grammarNode.GetNodeWithType("VirtualTokens").GetNodeWithType("VirtualTokenStatement")
+	if virtual != nil {
+		starts[virtual.GetNodeWithType("Virtual").Token.Offset] = statementVirtual
+	}
+	return starts
+}
+
+func lastLine(statement *formatStatement) int {
+	return statement.tokens[len(statement.tokens)-1].Line
+}
+
+// Appends the comments to the output, keeping a single blank line where there were blank lines between them
+func writeComments(output *[]string, comments []model.Token) {
+	for i, comment := range comments {
+		if i > 0 && comment.Line > comments[i-1].Line+1 {
+			*output = append(*output, "")
+		}
+		*output = append(*output, commentText(comment))
+	}
+}
+
+func commentText(comment model.Token) string {
+	return strings.TrimRight(comment.Value, " \t\r\n")
+}
+
+func isOperator(token model.Token) bool {
+	return token.Type == "Assignment" || token.Type == "AlternativesAssignment" || token.Type == "Virtual"
+}
+
+// Splits the tokens of a statement in the lines they are written in.
+// Items keep the line breaks they had in the original text, unless they are inside brackets or parentheses,
+// and the alternatives of rules written in more than one line always start a new line
+func layoutStatement(tokens []model.Token) [][]model.Token {
+	firstLine, endLine := 0, 0
+	for _, token := range tokens {
+		if token.Type == "Comment" {
+			continue
+		}
+		if firstLine == 0 {
+			firstLine = token.Line
+		}
+		endLine = token.Line
+	}
+	multiline := firstLine != endLine
+
+	lines := [][]model.Token{{}}
+	depth := 0
+	seenOperator := false
+	breakNext := false
+	previous := model.Token{}
+
+	for _, token := range tokens {
+		last := len(lines) - 1
+		newLine := breakNext
+		if token.Type == "Comment" {
+			newLine = newLine || token.Line != previous.Line
+		} else if seenOperator && depth == 0 && !isOperator(previous) && previous.Type != "Pipe" {
+			newLine = newLine || (token.Type == "Pipe" && multiline) || token.Line != previous.Line
+		}
+		if newLine && len(lines[last]) > 0 {
+			lines = append(lines, []model.Token{})
+			last++
+		}
+		lines[last] = append(lines[last], token)
+
+		breakNext = token.Type == "Comment"
+		switch token.Type {
+		case "LeftBracket", "LeftParens", "LeftCurly", "LeftAngle":
+			depth++
+		case "RightBracket", "RightParens", "RightCurly", "RightAngle":
+			depth--
+		}
+		if isOperator(token) {
+			seenOperator = true
+		}
+		previous = token
+	}
+	return lines
+}
+
+// Joins the tokens of a line, with spaces only where they are needed for reading
+func joinTokens(tokens []model.Token) string {
+	builder := strings.Builder{}
+	enclosing := []string{}
+	for i, token := range tokens {
+		if i > 0 && needsSpace(tokens[i-1], token, enclosing) {
+			builder.WriteString(" ")
+		}
+		if token.Type == "Comment" {
+			builder.WriteString(commentText(token))
+		} else {
+			builder.WriteString(token.Value)
+		}
+		switch token.Type {
+		case "LeftBracket", "LeftParens", "LeftCurly", "LeftAngle":
+			enclosing = append(enclosing, token.Type)
+		case "RightBracket", "RightParens", "RightCurly", "RightAngle":
+			if len(enclosing) > 0 {
+				enclosing = enclosing[:len(enclosing)-1]
+			}
+		}
+	}
+	return builder.String()
+}
+
+func needsSpace(previous, token model.Token, enclosing []string) bool {
+	switch token.Type {
+	case "Star", "Plus", "QuestionMark", "Comma", "LeftBracket", "RightBracket",
+		"LeftCurly", "RightCurly", "LeftAngle", "RightAngle", "RightParens":
+		return false
+	}
+	switch previous.Type {
+	case "LeftBracket", "LeftParens", "LeftCurly", "LeftAngle":
+		return false
+	case "Comma":
+		// template arguments are separated by ", ", while separators and counts are written together
+		return len(enclosing) > 0 && enclosing[len(enclosing)-1] == "LeftAngle"
+	}
+	return true
+}
+
+// Returns the rule name and template parameters of a statement, as they are written before its operator
+func renderHead(tokens []model.Token) string {
+	for i, token := range tokens {
+		if isOperator(token) {
+			return joinTokens(tokens[:i])
+		}
+	}
+	return ""
+}
+
+// Returns the lines of a statement, with its operator placed at the given column.
+// Alternatives are indented so their | is under the last character of the operator,
+// and other continuation lines so they start where the first item does
+func renderStatement(tokens []model.Token, width int) []string {
+	lines := layoutStatement(tokens)
+	itemColumn := 0
+	result := []string{}
+
+	for i, line := range lines {
+		if i == 0 {
+			operator := -1
+			for j, token := range line {
+				if isOperator(token) {
+					operator = j
+					break
+				}
+			}
+			if operator < 0 {
+				result = append(result, joinTokens(line))
+				continue
+			}
+			head := ""
+			if operator > 0 {
+				head = joinTokens(line[:operator])
+				head += strings.Repeat(" ", width-len(head)) + " "
+			}
+			text := head + joinTokens(line[operator:])
+			itemColumn = len(head) + len(line[operator].Value) + 1
+			result = append(result, text)
+			continue
+		}
+
+		indentLine := line
+		if line[0].Type == "Comment" {
+			// comments on their own line are indented like the line that follows them
+			for _, next := range lines[i+1:] {
+				if next[0].Type != "Comment" {
+					indentLine = next
+					break
+				}
+			}
+		}
+		indent := itemColumn
+		if indentLine[0].Type == "Pipe" && indent >= 2 {
+			indent -= 2
+		}
+		result = append(result, strings.Repeat(" ", indent)+joinTokens(line))
+	}
+	return result
+}
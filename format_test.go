@@ -0,0 +1,119 @@
+package grammatic
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestFormatGrammar(t *testing.T) {
+	text := `# Sums of numbers
+
+# digits come first in the file
+Num   :=   /\d+/ # no signs
+  Sum:=Num[ Plus ]+ # at least one number
+# the operator
+Plus:=/\+/
+List < A,B > := '(' A [ ',' , ]* ')'
+
+
+Value := Sum |
+  List<Num, Sum> # lists
+  # or nothing
+  | 'nil'i (collapse)
+:virtual: Indent Dedent
+# end
+`
+
+	expected := `# Sums of numbers
+
+Sum := Num[Plus]+ # at least one number
+
+List<A, B> := '(' A[',',]* ')'
+
+Value := Sum
+       | List<Num, Sum> # lists
+       # or nothing
+       | 'nil'i (collapse)
+
+# digits come first in the file
+Num := /\d+/ # no signs
+
+# the operator
+Plus := /\+/
+
+:virtual: Indent Dedent
+# end
+`
+
+	formatted, err := FormatGrammar(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if formatted != expected {
+		t.Fatalf("Unexpected formatted grammar\n%s", formatted)
+	}
+}
+
+func TestFormatGrammarAlignsNeighbours(t *testing.T) {
+	text := `Operator := Plus | Minus
+LongerOperator := Times | Div
+Plus := /\+/
+Minus := /-/
+
+Times := /\*/
+Div := /\//
+`
+
+	expected := `Operator       := Plus | Minus
+LongerOperator := Times | Div
+
+Plus  := /\+/
+Minus := /-/
+
+Times := /\*/
+Div   := /\//
+`
+
+	formatted, err := FormatGrammar(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if formatted != expected {
+		t.Fatalf("Unexpected formatted grammar\n%s", formatted)
+	}
+}
+
+func TestFormatGrammarKeepsRules(t *testing.T) {
+	texts := map[string]string{"JSONGrammar": JSONGrammar}
+	for _, file := range []string{"examples/jsonast/json.grammar", "examples/calcparser/calc.grammar"} {
+		text, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		texts[file] = string(text)
+	}
+
+	for name, text := range texts {
+		formatted, err := FormatGrammar(text)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		again, err := FormatGrammar(formatted)
+		if err != nil || again != formatted {
+			t.Fatalf("%s: formatting is not stable, got error %v and\n%s", name, err, again)
+		}
+
+		original := Compile(text)
+		result := Compile(formatted)
+		if !reflect.DeepEqual(original.definitions, result.definitions) {
+			t.Fatalf("%s: formatting changed the rules of the grammar", name)
+		}
+	}
+}
+
+func TestFormatGrammarErrors(t *testing.T) {
+	if _, err := FormatGrammar("Sum := Number +\n:= Plus"); err == nil {
+		t.Fatal("Expected an error for an invalid grammar")
+	}
+}
@@ -0,0 +1,684 @@
+package grammatic
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+)
+
+type parserGenerator struct {
+	grammar   *Grammar
+	functions map[string]string
+	memos     int
+}
+
+// Returns the source of a Go file, in package packageName, with a lexer and a recursive descent parser for every rule of the grammar.
+// The generated Parse function returns the same trees and errors as Grammar.Parse, without building the grammar at runtime.
+// Token reducers cannot be generated, so they must be applied to the result of the generated Tokenize function,
+// before calling ParseTokens
+func (g *Grammar) GenerateParser(packageName string) ([]byte, error) {
+	generator := &parserGenerator{grammar: g, functions: map[string]string{}}
+
+	names := map[string]bool{"parseEOF": true}
+	for _, rule := range g.ruleOrder {
+		name := "parse" + goIdentifier(rule, g.definitions[rule])
+		unique := name
+		for i := 2; names[unique]; i++ {
+			unique = fmt.Sprintf("%s%d", name, i)
+		}
+		names[unique] = true
+		generator.functions[rule] = unique
+	}
+
+	output := &bytes.Buffer{}
+	generator.writeHeader(output, packageName)
+	generator.writeLexer(output)
+	for _, rule := range g.ruleOrder {
+		if err := generator.writeRule(output, rule); err != nil {
+			return nil, err
+		}
+	}
+	output.WriteString(parserRuntime)
+
+	source, err := format.Source(output.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("cannot format generated parser: %w", err)
+	}
+	return source, nil
+}
+
+func (gen *parserGenerator) writeHeader(output *bytes.Buffer, packageName string) {
+	g := gen.grammar
+	fmt.Fprintf(output, "// Code generated by grammatic. DO NOT EDIT.\n\n")
+	fmt.Fprintf(output, "package %s\n\n", packageName)
+	fmt.Fprintf(output, "import (\n\t\"fmt\"\n\t\"github.com/jsanchesleao/grammatic/lexer\"\n\t\"github.com/jsanchesleao/grammatic/model\"\n")
+	if len(g.tokenDefs()) > 0 {
+		fmt.Fprintf(output, "\t\"regexp\"\n")
+	}
+	fmt.Fprintf(output, "\t\"strings\"\n)\n\n")
+
+	fmt.Fprintf(output, "var rules = map[string]parseFunc{\n")
+	for _, rule := range g.ruleOrder {
+		fmt.Fprintf(output, "%q: %s,\n", rule, gen.functions[rule])
+	}
+	fmt.Fprintf(output, "}\n\n")
+
+	fmt.Fprintf(output, "var inlineRules = %s\n\n", setLiteral(g.inlineRules))
+	fmt.Fprintf(output, "var droppedRules = %s\n\n", setLiteral(g.droppedRules))
+	fmt.Fprintf(output, "var collapsedRules = %s\n\n", setLiteral(g.collapsedRules))
+}
+
+func (gen *parserGenerator) writeLexer(output *bytes.Buffer) {
+	fmt.Fprintf(output, "var tokenDefs = []model.TokenDef{\n")
+	for _, def := range gen.grammar.tokenDefs() {
+		fmt.Fprintf(output, "{Type: %q, Pattern: regexp.MustCompile(%q)},\n", def.Type, def.Pattern.String())
+	}
+	fmt.Fprintf(output, "}\n\n")
+
+	ignored := map[string]bool{}
+	for _, name := range gen.grammar.IgnoredTokenTypes {
+		ignored[name] = true
+	}
+	fmt.Fprintf(output, "var ignoredTokenTypes = %s\n\n", setLiteral(ignored))
+}
+
+func setLiteral(names map[string]bool) string {
+	sorted := []string{}
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	builder := strings.Builder{}
+	builder.WriteString("map[string]bool{")
+	for _, name := range sorted {
+		fmt.Fprintf(&builder, "\n%q: true,", name)
+	}
+	if len(sorted) > 0 {
+		builder.WriteString("\n")
+	}
+	builder.WriteString("}")
+	return builder.String()
+}
+
+func (gen *parserGenerator) reference(rule string) (string, error) {
+	if rule == CutMarker {
+		return "nil", nil
+	}
+	function, ok := gen.functions[rule]
+	if !ok {
+		return "", fmt.Errorf("Undefined rule %q", rule)
+	}
+	return function, nil
+}
+
+func (gen *parserGenerator) references(rules []string) (string, error) {
+	functions := []string{}
+	for _, rule := range rules {
+		function, err := gen.reference(rule)
+		if err != nil {
+			return "", err
+		}
+		functions = append(functions, function)
+	}
+	return strings.Join(functions, ", "), nil
+}
+
+func (gen *parserGenerator) writeRule(output *bytes.Buffer, rule string) error {
+	definition := gen.grammar.definitions[rule]
+	function := gen.functions[rule]
+
+	body, err := gen.ruleBody(rule, definition)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(output, "// Checks the rule %s\n", rule)
+	if definition.memo {
+		fmt.Fprintf(output, "func %s(p *parser, pos int, k continuation) (bool, *model.RuleError) {\n", function)
+		fmt.Fprintf(output, "return p.memo(%d, pos, check%s, k)\n}\n\n", gen.memos, strings.TrimPrefix(function, "parse"))
+		gen.memos++
+		function = "check" + strings.TrimPrefix(function, "parse")
+	}
+	fmt.Fprintf(output, "func %s(p *parser, pos int, k continuation) (bool, *model.RuleError) {\n", function)
+	fmt.Fprintf(output, "return %s\n}\n\n", body)
+	return nil
+}
+
+// Returns the expression that checks a rule with the given definition, using the functions of the parser runtime
+func (gen *parserGenerator) ruleBody(rule string, definition ruleDefinition) (string, error) {
+	switch definition.kind {
+	case kindToken, kindVirtualToken:
+		return fmt.Sprintf("p.tokenType(pos, %q, %q, k)", rule, rule), nil
+	case kindLiteral:
+		return fmt.Sprintf("p.tokenValue(pos, %q, %q, %t, k)", rule, definition.value, definition.ignoreCase), nil
+	case kindOr, kindSeq:
+		references, err := gen.references(definition.rules)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("p.%s(%q, pos, []parseFunc{%s}, k)", definition.kind, rule, references), nil
+	case "":
+		return "", fmt.Errorf("cannot generate a parser for rule %q, because it was created by a custom combinator", rule)
+	}
+
+	item, err := gen.reference(definition.rules[0])
+	if err != nil {
+		return "", err
+	}
+	separator := ""
+	if definition.separator != "" {
+		separator, err = gen.reference(definition.separator)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	switch definition.kind {
+	case kindRename, kindOneOrNone, kindMany, kindOneOrMany:
+		return fmt.Sprintf("p.%s(%q, pos, %s, k)", definition.kind, rule, item), nil
+	case kindManyWithSeparator, kindOneOrManyWithSeparator:
+		return fmt.Sprintf("p.%s(%q, pos, %s, %s, %t, k)", definition.kind, rule, item, separator, definition.trailing), nil
+	case kindRepeat:
+		return fmt.Sprintf("p.repeat(%q, pos, %s, %d, %d, k)", rule, item, definition.min, definition.max), nil
+	case kindRepeatWithSeparator:
+		return fmt.Sprintf("p.repeatWithSeparator(%q, pos, %s, %s, %d, %d, %t, k)", rule, item, separator, definition.min, definition.max, definition.trailing), nil
+	}
+	return "", fmt.Errorf("cannot generate a parser for rules of kind %q", definition.kind)
+}
+
+// The functions shared by every generated parser. Each one checks a kind of rule like the combinator of the same name
+// in the parser package, calling the continuation for every result in the same order
+const parserRuntime = `
+// Receives a result of a rule and the position of the next token, and returns true to stop the parse
+type continuation func(node model.Node, next int) bool
+
+// Calls k for each result of a rule at pos, and returns true when the parse was stopped,
+// or the error that the rule produces after its results
+type parseFunc func(p *parser, pos int, k continuation) (bool, *model.RuleError)
+
+type memoResult struct {
+	node model.Node
+	next int
+}
+
+type memoEntry struct {
+	results []memoResult
+	err     *model.RuleError
+	fatal   *model.RuleError
+}
+
+type memoKey struct {
+	rule int
+	pos  int
+}
+
+type parser struct {
+	tokens []model.Token
+	fatal  *model.RuleError
+	memos  map[memoKey]*memoEntry
+}
+
+// Splits the input into tokens, with the token definitions of the grammar
+func Tokenize(text string) ([]model.Token, error) {
+	tokens := []model.Token{}
+	line := 1
+	col := 0
+	index := 0
+	var err error
+
+	skips := 0
+
+	for {
+		if index >= len(text) {
+			tokens = append(tokens, model.Token{Type: "TOKEN_EOF", Value: "", Line: line + 1, Col: 0, Offset: len(text)})
+			break
+		}
+
+		nextToken := model.Token{Offset: index}
+		if text[index] == '\n' {
+			nextToken.Col = col + 1
+			nextToken.Line = line
+			col = 0
+			line++
+		} else {
+			col++
+			nextToken.Col = col
+			nextToken.Line = line
+		}
+
+		if skips > 0 {
+			skips--
+			index++
+			continue
+		}
+
+		remainingText := text[index:]
+		hasToken := false
+		for _, def := range tokenDefs {
+			if match := def.Pattern.FindString(remainingText); match != "" {
+				nextToken.Type = def.Type
+				nextToken.Value = match
+				hasToken = true
+				skips = len(match) - 1
+				break
+			}
+		}
+		if !hasToken {
+			err = &lexer.IllegalCharacterError{Character: string(text[index]), Line: line, Col: col}
+			break
+		} else {
+			tokens = append(tokens, nextToken)
+		}
+		index++
+	}
+
+	return tokens, err
+}
+
+// Returns the tree for the rule ruleType, which must match the whole input
+func Parse(ruleType, input string) (*model.Node, error) {
+	tokens, err := Tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTokens(ruleType, tokens)
+}
+
+// Works like Parse, but receives the tokens returned by Tokenize, after any changes made by token reducers
+func ParseTokens(ruleType string, tokens []model.Token) (*model.Node, error) {
+	rule, ok := rules[ruleType]
+	if !ok {
+		return nil, fmt.Errorf("Undefined rule %q", ruleType)
+	}
+
+	validTokens := []model.Token{}
+	for _, token := range tokens {
+		if !ignoredTokenTypes[token.Type] {
+			validTokens = append(validTokens, token)
+		}
+	}
+
+	p := &parser{tokens: validTokens, memos: map[memoKey]*memoEntry{}}
+	var match *model.Node
+	_, err := p.seq("Root", 0, []parseFunc{rule, parseEOF}, func(node model.Node, next int) bool {
+		match = &node
+		return true
+	})
+
+	if p.fatal != nil {
+		return nil, p.fatal.GetError()
+	}
+	if match != nil {
+		match.Rules = shapeChildren(match.Rules)
+		return match, nil
+	}
+	if err == nil {
+		return nil, fmt.Errorf("found an unexpected error during parsing")
+	}
+	return nil, err.GetError()
+}
+
+func parseEOF(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+	return p.tokenType(pos, "EOF", "TOKEN_EOF", k)
+}
+
+func isInline(ruleType string) bool {
+	name := ruleType[strings.LastIndex(ruleType, ".")+1:]
+	return inlineRules[ruleType] || strings.HasPrefix(name, "_")
+}
+
+func shapeChildren(nodes []model.Node) []model.Node {
+	if nodes == nil {
+		return nil
+	}
+	result := []model.Node{}
+	for _, child := range nodes {
+		child.Rules = shapeChildren(child.Rules)
+		if droppedRules[child.Type] {
+			continue
+		}
+		if child.Token == nil && isInline(child.Type) {
+			result = append(result, child.Rules...)
+			continue
+		}
+		if collapsedRules[child.Type] && len(child.Rules) == 1 {
+			child = child.Rules[0]
+		}
+		result = append(result, child)
+	}
+	return result
+}
+
+func furthest(current, candidate *model.RuleError) *model.RuleError {
+	if current == nil || candidate.Token.IsAfter(current.Token) {
+		return candidate
+	}
+	return current
+}
+
+func (p *parser) repeatError(ruleType string, pos int, err *model.RuleError) *model.RuleError {
+	if err != nil {
+		return err
+	}
+	err = &model.RuleError{RuleType: ruleType}
+	if pos < len(p.tokens) {
+		err.Token = p.tokens[pos]
+	}
+	return err
+}
+
+func (p *parser) token(pos int, ruleType string, matches func(model.Token) bool, k continuation) (bool, *model.RuleError) {
+	if pos >= len(p.tokens) {
+		return false, &model.RuleError{Token: model.Token{Type: "NULL", Value: "STREAM_END"}, RuleType: ruleType}
+	}
+	token := p.tokens[pos]
+	if !matches(token) {
+		return false, &model.RuleError{Token: token, RuleType: ruleType}
+	}
+	return k(model.Node{Type: ruleType, Token: &token}, pos+1), nil
+}
+
+func (p *parser) tokenType(pos int, ruleType, tokenType string, k continuation) (bool, *model.RuleError) {
+	return p.token(pos, ruleType, func(token model.Token) bool {
+		return token.Type == tokenType
+	}, k)
+}
+
+func (p *parser) tokenValue(pos int, ruleType, value string, ignoreCase bool, k continuation) (bool, *model.RuleError) {
+	return p.token(pos, ruleType, func(token model.Token) bool {
+		if ignoreCase {
+			return strings.EqualFold(token.Value, value)
+		}
+		return token.Value == value
+	}, k)
+}
+
+// Calls k with the nodes of every combination of results of the rules. A nil rule is a cut
+func (p *parser) sequence(ruleType string, pos int, rules []parseFunc, k func(nodes []model.Node, next int) bool) (bool, *model.RuleError) {
+	if len(rules) == 0 {
+		return k(nil, pos), nil
+	}
+	if rules[0] == nil {
+		return p.committed(ruleType, pos, rules[1:], k)
+	}
+
+	var err *model.RuleError
+	stop, headErr := rules[0](p, pos, func(head model.Node, next int) bool {
+		stop, tailErr := p.sequence(ruleType+":Seq", next, rules[1:], func(tail []model.Node, last int) bool {
+			return k(append([]model.Node{head}, tail...), last)
+		})
+		if !stop && tailErr != nil {
+			err = furthest(err, tailErr)
+		}
+		return stop
+	})
+	if stop {
+		return true, nil
+	}
+	if headErr != nil {
+		err = furthest(err, headErr)
+	}
+	return false, err
+}
+
+// Checks the rules after a cut, stopping the parse with a fatal error when they have no result
+func (p *parser) committed(ruleType string, pos int, rules []parseFunc, k func(nodes []model.Node, next int) bool) (bool, *model.RuleError) {
+	success := false
+	stop, err := p.sequence(ruleType, pos, rules, func(nodes []model.Node, next int) bool {
+		success = true
+		return k(nodes, next)
+	})
+	if stop || success {
+		return stop, nil
+	}
+
+	fatal := model.RuleError{RuleType: ruleType, Fatal: true}
+	if err != nil {
+		fatal = *err
+		fatal.Fatal = true
+	} else if pos < len(p.tokens) {
+		fatal.Token = p.tokens[pos]
+	}
+	p.fatal = &fatal
+	return true, nil
+}
+
+func (p *parser) seq(ruleType string, pos int, rules []parseFunc, k continuation) (bool, *model.RuleError) {
+	return p.sequence(ruleType, pos, rules, func(nodes []model.Node, next int) bool {
+		return k(model.Node{Type: ruleType, Rules: nodes}, next)
+	})
+}
+
+func (p *parser) or(ruleType string, pos int, rules []parseFunc, k continuation) (bool, *model.RuleError) {
+	success := false
+	var err *model.RuleError
+	for _, rule := range rules {
+		stop, ruleErr := rule(p, pos, func(node model.Node, next int) bool {
+			success = true
+			return k(model.Node{Type: ruleType, Rules: []model.Node{node}}, next)
+		})
+		if stop {
+			return true, nil
+		}
+		if ruleErr != nil {
+			err = furthest(err, ruleErr)
+		}
+	}
+	if success {
+		return false, nil
+	}
+	return false, err
+}
+
+func (p *parser) rename(ruleType string, pos int, rule parseFunc, k continuation) (bool, *model.RuleError) {
+	stop, err := rule(p, pos, func(node model.Node, next int) bool {
+		node.Type = ruleType
+		return k(node, next)
+	})
+	if err != nil {
+		renamed := *err
+		renamed.RuleType = ruleType
+		err = &renamed
+	}
+	return stop, err
+}
+
+func (p *parser) oneOrNone(ruleType string, pos int, rule parseFunc, k continuation) (bool, *model.RuleError) {
+	stop, _ := rule(p, pos, func(node model.Node, next int) bool {
+		return k(model.Node{Type: ruleType, Rules: []model.Node{node}}, next)
+	})
+	if stop {
+		return true, nil
+	}
+	return k(model.Node{Type: ruleType, Rules: []model.Node{}}, pos), nil
+}
+
+func decreaseCount(count int) int {
+	if count <= 0 {
+		return count
+	}
+	return count - 1
+}
+
+// Calls k with the nodes of the rule matched at least min and at most max times, longer matches first
+func (p *parser) repeatNodes(ruleType string, pos int, rule parseFunc, min, max int, k func(nodes []model.Node, next int) bool) (bool, *model.RuleError) {
+	if max != 0 {
+		var err *model.RuleError
+		success := false
+		stop, ruleErr := rule(p, pos, func(node model.Node, next int) bool {
+			stop, nextErr := p.repeatNodes(ruleType, next, rule, decreaseCount(min), decreaseCount(max), func(nodes []model.Node, last int) bool {
+				success = true
+				return k(append([]model.Node{node}, nodes...), last)
+			})
+			if !stop && nextErr != nil {
+				err = furthest(err, nextErr)
+			}
+			return stop
+		})
+		if stop {
+			return true, nil
+		}
+		if ruleErr != nil {
+			err = furthest(err, ruleErr)
+		}
+		if min > 0 {
+			if success {
+				return false, nil
+			}
+			return false, p.repeatError(ruleType, pos, err)
+		}
+	}
+	return k([]model.Node{}, pos), nil
+}
+
+// Calls k with the nodes of the rule matched at least min and at most max times, with the separator between them
+func (p *parser) separatedNodes(ruleType string, pos int, rule, separator parseFunc, min, max int, k func(nodes []model.Node, next int) bool) (bool, *model.RuleError) {
+	tailItem := func(p *parser, pos int, k continuation) (bool, *model.RuleError) {
+		return p.seq(ruleType+":TailItem", pos, []parseFunc{separator, rule}, k)
+	}
+	if max != 0 {
+		var err *model.RuleError
+		success := false
+		stop, ruleErr := rule(p, pos, func(node model.Node, next int) bool {
+			stop, tailErr := p.repeatNodes(ruleType+":Tail", next, tailItem, decreaseCount(min), decreaseCount(max), func(items []model.Node, last int) bool {
+				nodes := []model.Node{node}
+				for _, item := range items {
+					nodes = append(nodes, item.Rules...)
+				}
+				success = true
+				return k(nodes, last)
+			})
+			if !stop && tailErr != nil {
+				err = furthest(err, tailErr)
+			}
+			return stop
+		})
+		if stop {
+			return true, nil
+		}
+		if ruleErr != nil {
+			err = furthest(err, ruleErr)
+		}
+		if min > 0 {
+			if success {
+				return false, nil
+			}
+			return false, p.repeatError(ruleType, pos, err)
+		}
+	}
+	return k([]model.Node{}, pos), nil
+}
+
+// Calls k with the nodes of a separated list followed by a separator, before the nodes of the list alone
+func (p *parser) withTrailingSeparator(ruleType string, pos int, separator parseFunc, list func(k func(nodes []model.Node, next int) bool) (bool, *model.RuleError), k continuation) (bool, *model.RuleError) {
+	success := false
+	stop, err := list(func(nodes []model.Node, next int) bool {
+		success = true
+		if len(nodes) > 0 {
+			stop, _ := separator(p, next, func(node model.Node, last int) bool {
+				return k(model.Node{Type: ruleType, Rules: append(append([]model.Node{}, nodes...), node)}, last)
+			})
+			if stop {
+				return true
+			}
+		}
+		return k(model.Node{Type: ruleType, Rules: nodes}, next)
+	})
+	if stop {
+		return true, nil
+	}
+	if success {
+		return false, nil
+	}
+	return false, p.repeatError(ruleType, pos, err)
+}
+
+func (p *parser) many(ruleType string, pos int, rule parseFunc, k continuation) (bool, *model.RuleError) {
+	return p.repeat(ruleType, pos, rule, 0, -1, k)
+}
+
+func (p *parser) oneOrMany(ruleType string, pos int, rule parseFunc, k continuation) (bool, *model.RuleError) {
+	err := &model.RuleError{RuleType: ruleType}
+	if pos < len(p.tokens) {
+		err.Token = p.tokens[pos]
+	}
+	success := false
+	stop, ruleErr := rule(p, pos, func(node model.Node, next int) bool {
+		success = true
+		stop, _ := p.repeatNodes(ruleType, next, rule, 0, -1, func(nodes []model.Node, last int) bool {
+			return k(model.Node{Type: ruleType, Rules: append([]model.Node{node}, nodes...)}, last)
+		})
+		return stop
+	})
+	if stop {
+		return true, nil
+	}
+	if success {
+		return false, nil
+	}
+	if ruleErr != nil && ruleErr.Token.IsAfter(err.Token) {
+		err = ruleErr
+	}
+	return false, err
+}
+
+func (p *parser) repeat(ruleType string, pos int, rule parseFunc, min, max int, k continuation) (bool, *model.RuleError) {
+	return p.repeatNodes(ruleType, pos, rule, min, max, func(nodes []model.Node, next int) bool {
+		return k(model.Node{Type: ruleType, Rules: nodes}, next)
+	})
+}
+
+func (p *parser) repeatWithSeparator(ruleType string, pos int, rule, separator parseFunc, min, max int, trailing bool, k continuation) (bool, *model.RuleError) {
+	list := func(k func(nodes []model.Node, next int) bool) (bool, *model.RuleError) {
+		return p.separatedNodes(ruleType, pos, rule, separator, min, max, k)
+	}
+	if trailing {
+		return p.withTrailingSeparator(ruleType, pos, separator, list, k)
+	}
+	return list(func(nodes []model.Node, next int) bool {
+		return k(model.Node{Type: ruleType, Rules: nodes}, next)
+	})
+}
+
+func (p *parser) manyWithSeparator(ruleType string, pos int, rule, separator parseFunc, trailing bool, k continuation) (bool, *model.RuleError) {
+	return p.repeatWithSeparator(ruleType, pos, rule, separator, 0, -1, trailing, k)
+}
+
+func (p *parser) oneOrManyWithSeparator(ruleType string, pos int, rule, separator parseFunc, trailing bool, k continuation) (bool, *model.RuleError) {
+	stop, err := p.repeatWithSeparator(ruleType, pos, rule, separator, 1, -1, trailing, k)
+	if err != nil && !err.Token.IsAfter(model.Token{}) {
+		err = &model.RuleError{}
+	}
+	return stop, err
+}
+
+// Collects every result of the rule the first time it is checked at pos, and replays them afterwards
+func (p *parser) memo(rule int, pos int, check parseFunc, k continuation) (bool, *model.RuleError) {
+	key := memoKey{rule: rule, pos: pos}
+	entry, ok := p.memos[key]
+	if !ok {
+		entry = &memoEntry{}
+		_, entry.err = check(p, pos, func(node model.Node, next int) bool {
+			entry.results = append(entry.results, memoResult{node: node, next: next})
+			return false
+		})
+		entry.fatal = p.fatal
+		p.fatal = nil
+		p.memos[key] = entry
+	}
+
+	for _, result := range entry.results {
+		if k(result.node, result.next) {
+			return true, nil
+		}
+	}
+	if entry.fatal != nil {
+		p.fatal = entry.fatal
+		return true, nil
+	}
+	return false, entry.err
+}
+`
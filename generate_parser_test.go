@@ -0,0 +1,46 @@
+package grammatic
+
+import (
+	"github.com/jsanchesleao/grammatic/model"
+	"github.com/jsanchesleao/grammatic/parser"
+	"strings"
+	"testing"
+)
+
+func TestGenerateParser(t *testing.T) {
+	grammar := Compile(`
+List := '[' Item[',']* ']'
+Item := Name | Number
+Name := /[a-z]+/
+Number := /\d+/
+Space := $EmptySpaceFormat (ignore)`)
+
+	source, err := grammar.GenerateParser("lists")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertGenerated(t, source,
+		"// Code generated by grammatic. DO NOT EDIT.\n\npackage lists\n",
+		"func parseList(p *parser, pos int, k continuation) (bool, *model.RuleError) {\n\treturn p.seq(\"List\", pos, []parseFunc{parseLiteral5B, parseGroup1, parseLiteral5D}, k)\n}",
+		"func parseItem(p *parser, pos int, k continuation) (bool, *model.RuleError) {\n\treturn p.or(\"Item\", pos, []parseFunc{parseName, parseNumber}, k)\n}",
+		"{Type: \"Number\", Pattern: regexp.MustCompile(\"^\\\\d+\")},",
+		"var ignoredTokenTypes = map[string]bool{\n\t\"Space\": true,\n}",
+		"func Parse(ruleType, input string) (*model.Node, error) {",
+	)
+}
+
+func TestGenerateParserCustomCombinator(t *testing.T) {
+	grammar := NewGrammar()
+	grammar.DefineToken("Name", "^[a-z]+")
+	grammar.DefineRule("Custom", GrammarCombinator{
+		Create: func(ruleType string) *model.Rule {
+			return parser.RuleTokenType(ruleType, "Name")
+		},
+	})
+
+	_, err := grammar.GenerateParser("custom")
+	if err == nil || !strings.Contains(err.Error(), `rule "Custom", because it was created by a custom combinator`) {
+		t.Fatalf("Expected an error for the custom combinator, got %v", err)
+	}
+}
@@ -0,0 +1,519 @@
+package grammatic
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+	"unicode"
+)
+
+// The Go shapes that the rules take in the generated types
+const (
+	shapeToken  = "token"
+	shapeStruct = "struct"
+	shapeSum    = "sum"
+	shapeSlice  = "slice"
+)
+
+type typeField struct {
+	name     string
+	rule     string
+	repeated bool
+}
+
+type generatedType struct {
+	rule         string
+	name         string
+	shape        string
+	fields       []typeField
+	item         string
+	alternatives []string
+	sums         []string
+}
+
+type typesGenerator struct {
+	grammar *Grammar
+	types   map[string]*generatedType
+	order   []string
+	names   map[string]bool
+}
+
+// Returns the source of a Go file, in package packageName, with a type for each rule used by rootRule and a Convert
+// function that turns a parse tree into those types.
+// Seq rules become structs with a field for each child rule, Or rules become interfaces implemented by their alternatives,
+// repetitions become slices and tokens become structs that embed model.Token.
+// Inlined and dropped rules are left out, so the types follow the tree that Parse returns
+func (g *Grammar) GenerateTypes(packageName, rootRule string) ([]byte, error) {
+	generator := &typesGenerator{
+		grammar: g,
+		types:   map[string]*generatedType{},
+		names:   map[string]bool{"Convert": true},
+	}
+
+	definition, err := generator.resolve(rootRule)
+	if err != nil {
+		return nil, err
+	}
+	if generator.inlined(rootRule, definition) || g.droppedRules[rootRule] {
+		return nil, fmt.Errorf("cannot generate types for rule %q, because it is not kept in the tree", rootRule)
+	}
+
+	generator.register(rootRule)
+	for i := 0; i < len(generator.order); i++ {
+		if err := generator.build(generator.types[generator.order[i]]); err != nil {
+			return nil, err
+		}
+	}
+	for _, rule := range generator.order {
+		generated := generator.types[rule]
+		if generated.shape != shapeSum {
+			continue
+		}
+		for _, concrete := range generator.concreteTypes(rule, map[string]bool{}) {
+			generator.types[concrete].sums = append(generator.types[concrete].sums, generated.name)
+		}
+	}
+
+	output := &bytes.Buffer{}
+	generator.writeHeader(output, packageName)
+	generator.writeConvert(output, rootRule)
+	for _, rule := range generator.order {
+		generator.writeType(output, generator.types[rule])
+	}
+
+	source, err := format.Source(output.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("cannot format generated types: %w", err)
+	}
+	return source, nil
+}
+
+// Returns the definition of the rule, following renames to the rule they match
+func (gen *typesGenerator) resolve(rule string) (ruleDefinition, error) {
+	visited := map[string]bool{}
+	name := rule
+	for {
+		definition, ok := gen.grammar.definitions[name]
+		if !ok {
+			return ruleDefinition{}, fmt.Errorf("Undefined rule %q", name)
+		}
+		if definition.kind == "" {
+			return ruleDefinition{}, fmt.Errorf("cannot generate types for rule %q, because it was created by a custom combinator", name)
+		}
+		if definition.kind != kindRename {
+			return definition, nil
+		}
+		if visited[name] {
+			return ruleDefinition{}, fmt.Errorf("rule %q is renamed in a cycle", rule)
+		}
+		visited[name] = true
+		name = definition.rules[0]
+	}
+}
+
+func isTokenKind(kind string) bool {
+	return kind == kindToken || kind == kindVirtualToken || kind == kindLiteral
+}
+
+func isRepetitionKind(kind string) bool {
+	switch kind {
+	case kindMany, kindOneOrMany, kindManyWithSeparator, kindOneOrManyWithSeparator, kindRepeat, kindRepeatWithSeparator:
+		return true
+	}
+	return false
+}
+
+// Checks if the nodes of the rule are replaced by their children in the tree
+func (gen *typesGenerator) inlined(rule string, definition ruleDefinition) bool {
+	return !isTokenKind(definition.kind) && gen.grammar.isInline(rule)
+}
+
+func (gen *typesGenerator) register(rule string) {
+	if _, ok := gen.types[rule]; ok {
+		return
+	}
+	name := goIdentifier(rule, gen.grammar.definitions[rule])
+	unique := name
+	for i := 2; gen.names[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	gen.names[unique] = true
+	gen.types[rule] = &generatedType{rule: rule, name: unique}
+	gen.order = append(gen.order, rule)
+}
+
+func (gen *typesGenerator) build(generated *generatedType) error {
+	definition, err := gen.resolve(generated.rule)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case isTokenKind(definition.kind):
+		generated.shape = shapeToken
+		return nil
+	case definition.kind == kindOr:
+		generated.shape = shapeSum
+		generated.alternatives, err = gen.alternatives(generated.rule, definition)
+		if err != nil {
+			return err
+		}
+		for _, alternative := range generated.alternatives {
+			gen.register(alternative)
+		}
+		return nil
+	}
+
+	if gen.grammar.collapsedRules[generated.rule] {
+		return fmt.Errorf("cannot generate types for collapsed rule %q, because it is not an Or rule", generated.rule)
+	}
+
+	if isRepetitionKind(definition.kind) {
+		item := definition.rules[0]
+		itemDefinition, err := gen.resolve(item)
+		if err != nil {
+			return err
+		}
+		if !gen.inlined(item, itemDefinition) && !gen.grammar.droppedRules[item] {
+			generated.shape = shapeSlice
+			generated.item = item
+			gen.register(item)
+			return nil
+		}
+	}
+
+	generated.shape = shapeStruct
+	if err := gen.expandDefinition(definition, false, false, &generated.fields); err != nil {
+		return err
+	}
+	used := map[string]bool{"Node": true}
+	for i := range generated.fields {
+		name := generated.fields[i].name
+		for j := 2; used[generated.fields[i].name]; j++ {
+			generated.fields[i].name = fmt.Sprintf("%s%d", name, j)
+		}
+		used[generated.fields[i].name] = true
+		gen.register(generated.fields[i].rule)
+	}
+	return nil
+}
+
+// Returns the alternatives of an Or rule that can be found in its nodes, replacing inlined Or rules by their own alternatives
+func (gen *typesGenerator) alternatives(rule string, definition ruleDefinition) ([]string, error) {
+	result := []string{}
+	for _, alternative := range definition.rules {
+		if gen.grammar.droppedRules[alternative] {
+			continue
+		}
+		alternativeDefinition, err := gen.resolve(alternative)
+		if err != nil {
+			return nil, err
+		}
+		if !gen.inlined(alternative, alternativeDefinition) {
+			result = append(result, alternative)
+			continue
+		}
+		if alternativeDefinition.kind != kindOr {
+			return nil, fmt.Errorf("cannot generate types for rule %q, because its alternative %q is inlined", rule, alternative)
+		}
+		nested, err := gen.alternatives(alternative, alternativeDefinition)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, nested...)
+	}
+	return result, nil
+}
+
+// Adds the fields for the children of a node with the given definition.
+// Literals that are always present carry no information, so they only become fields when optional
+func (gen *typesGenerator) expandDefinition(definition ruleDefinition, repeated, optional bool, fields *[]typeField) error {
+	switch {
+	case definition.kind == kindSeq:
+		for _, rule := range definition.rules {
+			if err := gen.expand(rule, repeated, optional, fields); err != nil {
+				return err
+			}
+		}
+	case definition.kind == kindOr:
+		for _, rule := range definition.rules {
+			if err := gen.expand(rule, repeated, true, fields); err != nil {
+				return err
+			}
+		}
+	case definition.kind == kindOneOrNone:
+		return gen.expand(definition.rules[0], repeated, true, fields)
+	case isRepetitionKind(definition.kind):
+		return gen.expand(definition.rules[0], true, optional, fields)
+	default:
+		return fmt.Errorf("cannot generate fields for rules of kind %q", definition.kind)
+	}
+	return nil
+}
+
+func (gen *typesGenerator) expand(rule string, repeated, optional bool, fields *[]typeField) error {
+	if rule == CutMarker || gen.grammar.droppedRules[rule] {
+		return nil
+	}
+	definition, err := gen.resolve(rule)
+	if err != nil {
+		return err
+	}
+	if gen.inlined(rule, definition) {
+		return gen.expandDefinition(definition, repeated, optional, fields)
+	}
+	if isLiteralName(rule) && !optional {
+		return nil
+	}
+	*fields = append(*fields, typeField{
+		name:     goIdentifier(fieldRuleName(rule), gen.grammar.definitions[rule]),
+		rule:     rule,
+		repeated: repeated,
+	})
+	return nil
+}
+
+// Returns the name of a field for the rule, leaving out the namespace or template instance that qualifies it
+func fieldRuleName(rule string) string {
+	index := strings.LastIndex(rule, NamespaceSeparator)
+	if index < 0 || isLiteralName(rule) || strings.Contains(rule[index:], ">") {
+		return rule
+	}
+	return rule[index+1:]
+}
+
+// Returns the node types that can be found in the tree for a rule, which includes the alternatives of collapsed Or rules
+func (gen *typesGenerator) nodeTypes(rule string, visited map[string]bool) []string {
+	if visited[rule] {
+		return nil
+	}
+	visited[rule] = true
+	result := []string{rule}
+	generated := gen.types[rule]
+	if generated.shape == shapeSum && gen.grammar.collapsedRules[rule] {
+		for _, alternative := range generated.alternatives {
+			result = append(result, gen.nodeTypes(alternative, visited)...)
+		}
+	}
+	return result
+}
+
+// Returns the rules whose types implement the interface of a sum rule
+func (gen *typesGenerator) concreteTypes(rule string, visited map[string]bool) []string {
+	result := []string{}
+	for _, alternative := range gen.types[rule].alternatives {
+		if visited[alternative] {
+			continue
+		}
+		visited[alternative] = true
+		if gen.types[alternative].shape == shapeSum {
+			result = append(result, gen.concreteTypes(alternative, visited)...)
+		} else {
+			result = append(result, alternative)
+		}
+	}
+	return result
+}
+
+func (gen *typesGenerator) typeRef(rule string) string {
+	generated := gen.types[rule]
+	if generated.shape == shapeSum || generated.shape == shapeSlice {
+		return generated.name
+	}
+	return "*" + generated.name
+}
+
+func (gen *typesGenerator) writeHeader(output *bytes.Buffer, packageName string) {
+	fmt.Fprintf(output, "// Code generated by grammatic. DO NOT EDIT.\n\n")
+	fmt.Fprintf(output, "package %s\n\n", packageName)
+	fmt.Fprintf(output, "import (\n\t\"fmt\"\n\t\"github.com/jsanchesleao/grammatic/model\"\n)\n\n")
+}
+
+func (gen *typesGenerator) writeConvert(output *bytes.Buffer, rootRule string) {
+	root := gen.types[rootRule]
+	fmt.Fprintf(output, "// Converts the tree returned by Grammar.Parse for the rule %s, or a node of that rule, to its type\n", rootRule)
+	fmt.Fprintf(output, "func Convert(node *model.Node) (%s, error) {\n", gen.typeRef(rootRule))
+	if rootRule != "Root" {
+		fmt.Fprintf(output, "if node.Type == \"Root\" && len(node.Rules) > 0 {\nnode = &node.Rules[0]\n}\n")
+	}
+	fmt.Fprintf(output, "switch node.Type {\ncase %s:\nreturn convert%s(node)\n}\n", quotedList(gen.nodeTypes(rootRule, map[string]bool{})), root.name)
+	fmt.Fprintf(output, "return nil, fmt.Errorf(\"expected a node of the rule %%q, got %%q\", %q, node.Type)\n}\n\n", rootRule)
+}
+
+func (gen *typesGenerator) writeType(output *bytes.Buffer, generated *generatedType) {
+	switch generated.shape {
+	case shapeToken:
+		fmt.Fprintf(output, "// Token of the rule %s\n", generated.rule)
+		fmt.Fprintf(output, "type %s struct {\nmodel.Token\n}\n\n", generated.name)
+		gen.writeMarkers(output, generated, "*"+generated.name)
+		fmt.Fprintf(output, "func convert%s(node *model.Node) (*%s, error) {\n", generated.name, generated.name)
+		fmt.Fprintf(output, "if node.Token == nil {\nreturn nil, fmt.Errorf(\"expected a token for the rule %%q, got node %%q\", %q, node.Type)\n}\n", generated.rule)
+		fmt.Fprintf(output, "return &%s{Token: *node.Token}, nil\n}\n\n", generated.name)
+
+	case shapeStruct:
+		fmt.Fprintf(output, "// Node of the rule %s\n", generated.rule)
+		fmt.Fprintf(output, "type %s struct {\nNode *model.Node\n", generated.name)
+		for _, field := range generated.fields {
+			fieldType := gen.typeRef(field.rule)
+			if field.repeated {
+				fieldType = "[]" + fieldType
+			}
+			fmt.Fprintf(output, "%s %s\n", field.name, fieldType)
+		}
+		fmt.Fprintf(output, "}\n\n")
+		gen.writeMarkers(output, generated, "*"+generated.name)
+		fmt.Fprintf(output, "func convert%s(node *model.Node) (*%s, error) {\n", generated.name, generated.name)
+		fmt.Fprintf(output, "result := &%s{Node: node}\n", generated.name)
+		if len(generated.fields) > 0 {
+			gen.writeFieldAssignments(output, generated.fields)
+		}
+		fmt.Fprintf(output, "return result, nil\n}\n\n")
+
+	case shapeSlice:
+		fmt.Fprintf(output, "// Nodes repeated by the rule %s\n", generated.rule)
+		fmt.Fprintf(output, "type %s []%s\n\n", generated.name, gen.typeRef(generated.item))
+		gen.writeMarkers(output, generated, generated.name)
+		fmt.Fprintf(output, "func convert%s(node *model.Node) (%s, error) {\n", generated.name, generated.name)
+		fmt.Fprintf(output, "result := %s{}\nfor i := range node.Rules {\nchild := &node.Rules[i]\nswitch child.Type {\n", generated.name)
+		fmt.Fprintf(output, "case %s:\n", quotedList(gen.nodeTypes(generated.item, map[string]bool{})))
+		gen.writeConversion(output, generated.item, "child", "nil")
+		fmt.Fprintf(output, "result = append(result, value)\n}\n}\nreturn result, nil\n}\n\n")
+
+	case shapeSum:
+		names := []string{}
+		for _, alternative := range generated.alternatives {
+			names = append(names, gen.types[alternative].name)
+		}
+		fmt.Fprintf(output, "// One of the alternatives of the rule %s: %s\n", generated.rule, strings.Join(names, ", "))
+		fmt.Fprintf(output, "type %s interface {\nis%s()\n}\n\n", generated.name, generated.name)
+		gen.writeMarkers(output, generated, "")
+		fmt.Fprintf(output, "func convert%s(node *model.Node) (%s, error) {\n", generated.name, generated.name)
+		fmt.Fprintf(output, "if node.Type == %q {\nif len(node.Rules) == 0 {\nreturn nil, nil\n}\nnode = &node.Rules[0]\n}\n", generated.rule)
+		fmt.Fprintf(output, "switch node.Type {\n")
+		used := map[string]bool{}
+		for _, alternative := range generated.alternatives {
+			nodeTypes := []string{}
+			for _, nodeType := range gen.nodeTypes(alternative, map[string]bool{}) {
+				if !used[nodeType] {
+					used[nodeType] = true
+					nodeTypes = append(nodeTypes, nodeType)
+				}
+			}
+			if len(nodeTypes) == 0 {
+				continue
+			}
+			fmt.Fprintf(output, "case %s:\n", quotedList(nodeTypes))
+			gen.writeConversion(output, alternative, "node", "nil")
+			if gen.types[alternative].shape == shapeSum {
+				fmt.Fprintf(output, "return value.(%s), nil\n", generated.name)
+			} else {
+				fmt.Fprintf(output, "return value, nil\n")
+			}
+		}
+		fmt.Fprintf(output, "}\nreturn nil, fmt.Errorf(\"unexpected node %%q in the rule %%q\", node.Type, %q)\n}\n\n", generated.rule)
+	}
+}
+
+func (gen *typesGenerator) writeMarkers(output *bytes.Buffer, generated *generatedType, receiver string) {
+	for _, sum := range generated.sums {
+		fmt.Fprintf(output, "func (%s) is%s() {}\n\n", receiver, sum)
+	}
+}
+
+// Writes the code that converts the node in variable to the type of the rule, storing it in a variable called value
+func (gen *typesGenerator) writeConversion(output *bytes.Buffer, rule, variable, zero string) {
+	fmt.Fprintf(output, "value, err := convert%s(%s)\nif err != nil {\nreturn %s, err\n}\n", gen.types[rule].name, variable, zero)
+}
+
+// Writes a loop over the children of a node, storing each one in the first field of its rule that is still empty,
+// or appending it to a repeated field
+func (gen *typesGenerator) writeFieldAssignments(output *bytes.Buffer, fields []typeField) {
+	nodeTypes := []string{}
+	fieldsByType := map[string][]typeField{}
+	for _, field := range fields {
+		for _, nodeType := range gen.nodeTypes(field.rule, map[string]bool{}) {
+			if _, ok := fieldsByType[nodeType]; !ok {
+				nodeTypes = append(nodeTypes, nodeType)
+			}
+			fieldsByType[nodeType] = append(fieldsByType[nodeType], field)
+		}
+	}
+
+	fmt.Fprintf(output, "for i := range node.Rules {\nchild := &node.Rules[i]\nswitch child.Type {\n")
+	for _, nodeType := range nodeTypes {
+		fmt.Fprintf(output, "case %q:\n", nodeType)
+		open := false
+		for _, field := range fieldsByType[nodeType] {
+			if field.repeated {
+				if open {
+					fmt.Fprintf(output, "} else {\n")
+				}
+				gen.writeConversion(output, field.rule, "child", "nil")
+				fmt.Fprintf(output, "result.%s = append(result.%s, value)\n", field.name, field.name)
+				break
+			}
+			if open {
+				fmt.Fprintf(output, "} else ")
+			}
+			fmt.Fprintf(output, "if result.%s == nil {\n", field.name)
+			gen.writeConversion(output, field.rule, "child", "nil")
+			fmt.Fprintf(output, "result.%s = value\n", field.name)
+			open = true
+		}
+		if open {
+			fmt.Fprintf(output, "}\n")
+		}
+	}
+	fmt.Fprintf(output, "}\n}\n")
+}
+
+func quotedList(values []string) string {
+	quoted := []string{}
+	for _, value := range values {
+		quoted = append(quoted, fmt.Sprintf("%q", value))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// Returns an exported Go identifier for a rule name, made of its letters and digits.
+// Literals are named after their value, with the hexadecimal code of each symbol, so '{' becomes Literal7B
+func goIdentifier(rule string, definition ruleDefinition) string {
+	words := rule
+	prefix := ""
+	if definition.kind == kindLiteral {
+		prefix = "Literal"
+		words = ""
+		for _, char := range definition.value {
+			if unicode.IsLetter(char) || unicode.IsDigit(char) {
+				words += string(char)
+			} else {
+				words += fmt.Sprintf(" %X ", char)
+			}
+		}
+	}
+
+	builder := strings.Builder{}
+	builder.WriteString(prefix)
+	upper := true
+	for _, char := range words {
+		if char > unicode.MaxASCII || !(unicode.IsLetter(char) || unicode.IsDigit(char)) {
+			upper = true
+			continue
+		}
+		if upper {
+			char = unicode.ToUpper(char)
+			upper = false
+		}
+		builder.WriteRune(char)
+	}
+
+	identifier := builder.String()
+	if identifier == "" {
+		return "Rule"
+	}
+	if unicode.IsDigit(rune(identifier[0])) {
+		return "Rule" + identifier
+	}
+	return identifier
+}
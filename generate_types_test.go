@@ -0,0 +1,86 @@
+package grammatic
+
+import (
+	"strings"
+	"testing"
+)
+
+const typesGrammar = `
+Call := Name '(' Args? ')' Modifier*
+
+Args := Expr (',' Expr)*
+
+Expr := Atom | _Unary
+
+_Unary := Negation | Not
+
+Negation := '-' Atom
+Not := '!' Atom
+
+Atom := Name | Number
+
+Modifier := '!'? Keyword as Flag
+
+Keyword := /[A-Z]+/
+Name := /[a-z]+/
+Number := /\d+/
+Space := $EmptySpaceFormat (ignore)
+`
+
+func assertGenerated(t *testing.T, source []byte, fragments ...string) {
+	t.Helper()
+	for _, fragment := range fragments {
+		if !strings.Contains(string(source), fragment) {
+			t.Fatalf("Expected generated source to contain\n%s\n\nbut it was\n%s", fragment, source)
+		}
+	}
+}
+
+func TestGenerateTypes(t *testing.T) {
+	grammar := Compile(typesGrammar)
+
+	source, err := grammar.GenerateTypes("calls", "Call")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertGenerated(t, source,
+		"// Code generated by grammatic. DO NOT EDIT.\n\npackage calls\n",
+		"func Convert(node *model.Node) (*Call, error) {",
+		"type Call struct {\n\tNode     *model.Node\n\tName     *Name\n\tArgs     *Args\n\tModifier []*Modifier\n}",
+		"type Args struct {\n\tNode  *model.Node\n\tExpr  Expr\n\tExpr2 []Expr\n}",
+		"type Expr interface {\n\tisExpr()\n}",
+		"func (*Negation) isExpr() {}",
+		"func (*Not) isExpr() {}",
+		"func (*Number) isExpr() {}",
+		"type Atom interface {\n\tisAtom()\n}",
+		"func (*Name) isAtom() {}",
+		"type Modifier struct {\n\tNode      *model.Node\n\tLiteral21 *Literal21\n\tFlag      *Flag\n}",
+		"type Flag struct {\n\tmodel.Token\n}",
+		"type Name struct {\n\tmodel.Token\n}",
+	)
+
+	if strings.Contains(string(source), "Unary") {
+		t.Fatalf("Expected the inlined rule _Unary to have no type, but the source was\n%s", source)
+	}
+	if strings.Contains(string(source), "Literal2C") {
+		t.Fatalf("Expected the ',' literal to have no field, but the source was\n%s", source)
+	}
+}
+
+func TestGenerateTypesErrors(t *testing.T) {
+	grammar := Compile(typesGrammar)
+
+	if _, err := grammar.GenerateTypes("calls", "Missing"); err == nil || err.Error() != `Undefined rule "Missing"` {
+		t.Fatalf("Expected an undefined rule error, got %v", err)
+	}
+
+	if _, err := grammar.GenerateTypes("calls", "_Unary"); err == nil {
+		t.Fatal("Expected an error for an inlined root rule")
+	}
+
+	grammar.Collapse("Args")
+	if _, err := grammar.GenerateTypes("calls", "Call"); err == nil || !strings.Contains(err.Error(), `collapsed rule "Args"`) {
+		t.Fatalf("Expected an error for a collapsed Seq rule, got %v", err)
+	}
+}
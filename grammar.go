@@ -1,11 +1,23 @@
 package grammatic
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/jsanchesleao/grammatic/lexer"
 	"github.com/jsanchesleao/grammatic/model"
 	"github.com/jsanchesleao/grammatic/parser"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
+// Rule name that places a cut point when used inside a Seq combinator.
+// After the cut is passed, a failure in the sequence is a hard error and no other alternatives are tried
+const CutMarker = "^"
+
 type TokenReducer = func([]model.Token, TokenReducerState, model.Token) ([]model.Token, TokenReducerState)
 type TokenReducerState = interface{}
 
@@ -14,6 +26,29 @@ type Grammar struct {
 	TokenDefs         []model.TokenDef
 	IgnoredTokenTypes []string
 	TokenReducers     []TokenReducer
+
+	literalDefs    []model.TokenDef
+	inlineRules    map[string]bool
+	droppedRules   map[string]bool
+	collapsedRules map[string]bool
+	groupCount     int
+	templates      map[string]*ruleTemplate
+	actions        map[string]Action
+	definitions    map[string]ruleDefinition
+	ruleOrder      []string
+	frozen         bool
+}
+
+// Returned when trying to change a grammar after Freeze was called
+var ErrFrozenGrammar = errors.New("grammar is frozen and cannot be changed")
+
+// Limits the resources used by ParseContext. A zero value means no limit.
+// MaxDepth bounds how deeply rules may be nested, MaxInvocations how many times rules may be checked,
+// and MaxNodes how many tree nodes may be produced, including the ones later discarded by backtracking
+type ParseOptions struct {
+	MaxDepth       int
+	MaxInvocations int
+	MaxNodes       int
 }
 
 type GrammarCombinator struct {
@@ -21,6 +56,8 @@ type GrammarCombinator struct {
 	IsIgnoredToken bool
 	Pattern        string
 	Create         func(string) *model.Rule
+
+	definition ruleDefinition
 }
 
 func NewGrammar() Grammar {
@@ -33,32 +70,55 @@ func NewGrammar() Grammar {
 }
 
 func (g *Grammar) DeclareRule(name string) {
-	if g.Rules[name] == nil {
+	if !g.frozen && g.Rules[name] == nil {
 		g.Rules[name] = &model.Rule{Type: name}
 	}
 }
 
+// Returns the rule with the given name, declaring it if it doesn't exist yet.
+// On a frozen grammar, an unknown name returns a rule that is not added to the grammar
 func (g *Grammar) GetRule(name string) *model.Rule {
 	g.DeclareRule(name)
-	return g.Rules[name]
+	if rule := g.Rules[name]; rule != nil {
+		return rule
+	}
+	return &model.Rule{Type: name}
+}
+
+// Returns the rule with the given name without changing the grammar, or an error if it was never defined
+func (g *Grammar) lookupRule(name string) (*model.Rule, error) {
+	rule := g.Rules[name]
+	if rule == nil || (rule.Check == nil && rule.CheckContext == nil) {
+		return nil, fmt.Errorf("Undefined rule %q", name)
+	}
+	return rule, nil
 }
 
-func (g *Grammar) DefineRule(ruleType string, combinator GrammarCombinator) {
+func (g *Grammar) DefineRule(ruleType string, combinator GrammarCombinator) error {
+	if g.frozen {
+		return ErrFrozenGrammar
+	}
 	g.DeclareRule(ruleType)
 	if g.Rules[ruleType].Type != ruleType {
 		panic("Cannot override rule type")
 	}
 	if combinator.IsToken && combinator.IsIgnoredToken {
-		g.DefineIgnoredToken(ruleType, combinator.Pattern)
+		return g.DefineIgnoredToken(ruleType, combinator.Pattern)
 	} else if combinator.IsToken && !combinator.IsIgnoredToken {
-		g.DefineToken(ruleType, combinator.Pattern)
+		return g.DefineToken(ruleType, combinator.Pattern)
 	} else {
 		*g.Rules[ruleType] = *combinator.Create(ruleType)
+		g.recordDefinition(ruleType, combinator.definition)
 	}
+	return nil
 }
 
-func (g *Grammar) AddTokenReducer(reducer TokenReducer) {
+func (g *Grammar) AddTokenReducer(reducer TokenReducer) error {
+	if g.frozen {
+		return ErrFrozenGrammar
+	}
 	g.TokenReducers = append(g.TokenReducers, reducer)
+	return nil
 }
 
 func (g *Grammar) Token(pattern string) GrammarCombinator {
@@ -77,9 +137,14 @@ func (g *Grammar) IgnoredToken(pattern string) GrammarCombinator {
 	}
 }
 
-func (g *Grammar) DefineVirtualTokenRule(name string) {
+func (g *Grammar) DefineVirtualTokenRule(name string) error {
+	if g.frozen {
+		return ErrFrozenGrammar
+	}
 	g.DeclareRule(name)
 	*g.Rules[name] = *parser.RuleTokenType(name, name)
+	g.recordDefinition(name, ruleDefinition{kind: kindVirtualToken})
+	return nil
 }
 
 func (g *Grammar) Or(ruleNames ...string) GrammarCombinator {
@@ -91,18 +156,24 @@ func (g *Grammar) Or(ruleNames ...string) GrammarCombinator {
 		Create: func(ruleType string) *model.Rule {
 			return parser.Or(ruleType, rules...)
 		},
+		definition: ruleDefinition{kind: kindOr, rules: append([]string{}, ruleNames...)},
 	}
 }
 
 func (g *Grammar) Seq(ruleNames ...string) GrammarCombinator {
 	rules := []*model.Rule{}
 	for _, name := range ruleNames {
+		if name == CutMarker {
+			rules = append(rules, parser.Cut())
+			continue
+		}
 		rules = append(rules, g.GetRule(name))
 	}
 	return GrammarCombinator{
 		Create: func(ruleType string) *model.Rule {
 			return parser.Seq(ruleType, rules...)
 		},
+		definition: ruleDefinition{kind: kindSeq, rules: append([]string{}, ruleNames...)},
 	}
 }
 
@@ -111,6 +182,7 @@ func (g *Grammar) Rename(rule string) GrammarCombinator {
 		Create: func(ruleType string) *model.Rule {
 			return parser.Rename(ruleType, g.GetRule(rule))
 		},
+		definition: ruleDefinition{kind: kindRename, rules: []string{rule}},
 	}
 }
 
@@ -119,6 +191,7 @@ func (g *Grammar) OneOrNone(rule string) GrammarCombinator {
 		Create: func(ruleType string) *model.Rule {
 			return parser.OneOrNone(ruleType, g.GetRule(rule))
 		},
+		definition: ruleDefinition{kind: kindOneOrNone, rules: []string{rule}},
 	}
 }
 
@@ -127,6 +200,7 @@ func (g *Grammar) Many(rule string) GrammarCombinator {
 		Create: func(ruleType string) *model.Rule {
 			return parser.Many(ruleType, g.GetRule(rule))
 		},
+		definition: ruleDefinition{kind: kindMany, rules: []string{rule}},
 	}
 }
 
@@ -135,6 +209,7 @@ func (g *Grammar) ManyWithSeparator(rule, separator string) GrammarCombinator {
 		Create: func(ruleType string) *model.Rule {
 			return parser.ManyWithSeparator(ruleType, g.GetRule(rule), g.GetRule(separator))
 		},
+		definition: ruleDefinition{kind: kindManyWithSeparator, rules: []string{rule}, separator: separator},
 	}
 }
 
@@ -143,6 +218,27 @@ func (g *Grammar) OneOrManyWithSeparator(rule, separator string) GrammarCombinat
 		Create: func(ruleType string) *model.Rule {
 			return parser.OneOrManyWithSeparator(ruleType, g.GetRule(rule), g.GetRule(separator))
 		},
+		definition: ruleDefinition{kind: kindOneOrManyWithSeparator, rules: []string{rule}, separator: separator},
+	}
+}
+
+// Same as ManyWithSeparator, but also accepts an optional separator after the last item, which is kept in the tree
+func (g *Grammar) ManyWithSeparatorTrailing(rule, separator string) GrammarCombinator {
+	return GrammarCombinator{
+		Create: func(ruleType string) *model.Rule {
+			return parser.ManyWithSeparatorTrailing(ruleType, g.GetRule(rule), g.GetRule(separator))
+		},
+		definition: ruleDefinition{kind: kindManyWithSeparator, rules: []string{rule}, separator: separator, trailing: true},
+	}
+}
+
+// Same as OneOrManyWithSeparator, but also accepts an optional separator after the last item, which is kept in the tree
+func (g *Grammar) OneOrManyWithSeparatorTrailing(rule, separator string) GrammarCombinator {
+	return GrammarCombinator{
+		Create: func(ruleType string) *model.Rule {
+			return parser.OneOrManyWithSeparatorTrailing(ruleType, g.GetRule(rule), g.GetRule(separator))
+		},
+		definition: ruleDefinition{kind: kindOneOrManyWithSeparator, rules: []string{rule}, separator: separator, trailing: true},
 	}
 }
 
@@ -151,25 +247,159 @@ func (g *Grammar) OneOrMany(ruleName string) GrammarCombinator {
 		Create: func(ruleType string) *model.Rule {
 			return parser.OneOrMany(ruleType, g.GetRule(ruleName))
 		},
+		definition: ruleDefinition{kind: kindOneOrMany, rules: []string{ruleName}},
 	}
 }
 
-func (g *Grammar) DefineToken(name, pattern string) {
-	g.TokenDefs = append(g.TokenDefs, lexer.NewTokenDef(name, pattern))
+// Matches the rule at least min and at most max times. Use parser.Unbounded as max to allow any number of matches
+func (g *Grammar) Repeat(ruleName string, min, max int) GrammarCombinator {
+	return GrammarCombinator{
+		Create: func(ruleType string) *model.Rule {
+			return parser.Repeat(ruleType, g.GetRule(ruleName), min, max)
+		},
+		definition: ruleDefinition{kind: kindRepeat, rules: []string{ruleName}, min: min, max: max},
+	}
+}
+
+// Matches the rule at least min and at most max times, with the separator between the matches.
+// Use parser.Unbounded as max to allow any number of matches
+func (g *Grammar) RepeatWithSeparator(rule, separator string, min, max int) GrammarCombinator {
+	return GrammarCombinator{
+		Create: func(ruleType string) *model.Rule {
+			return parser.RepeatWithSeparator(ruleType, g.GetRule(rule), g.GetRule(separator), min, max)
+		},
+		definition: ruleDefinition{kind: kindRepeatWithSeparator, rules: []string{rule}, separator: separator, min: min, max: max},
+	}
+}
+
+// Same as RepeatWithSeparator, but also accepts an optional separator after the last item, which is kept in the tree
+func (g *Grammar) RepeatWithSeparatorTrailing(rule, separator string, min, max int) GrammarCombinator {
+	return GrammarCombinator{
+		Create: func(ruleType string) *model.Rule {
+			return parser.RepeatWithSeparatorTrailing(ruleType, g.GetRule(rule), g.GetRule(separator), min, max)
+		},
+		definition: ruleDefinition{kind: kindRepeatWithSeparator, rules: []string{rule}, separator: separator, min: min, max: max, trailing: true},
+	}
+}
+
+// Shares the results of the combinator for each position during a parse, so the rule is checked only once
+// at every place it is tried. Results are still found one at a time, as the rules that use them need them.
+// Useful for rules that many alternatives start with, which the grammar language itself needs to load grammars quickly
+func (g *Grammar) Memo(combinator GrammarCombinator) GrammarCombinator {
+	if combinator.IsToken {
+		return combinator
+	}
+	definition := combinator.definition
+	definition.memo = true
+	return GrammarCombinator{
+		Create: func(ruleType string) *model.Rule {
+			return parser.Memo(combinator.Create(ruleType))
+		},
+		definition: definition,
+	}
+}
+
+// Returns the name of a rule that matches a token with exactly the given value, defining the rule if needed.
+// The lexer also gets a token definition for the value, which is only used when no other token matches the input
+func (g *Grammar) Literal(value string) string {
+	return g.defineLiteral(value, false)
+}
+
+// Same as Literal, but the value is matched ignoring case
+func (g *Grammar) LiteralIgnoreCase(value string) string {
+	return g.defineLiteral(value, true)
+}
+
+func literalName(value string, ignoreCase bool) string {
+	escaped := strings.ReplaceAll(strings.ReplaceAll(value, "\\", "\\\\"), "'", "\\'")
+	if ignoreCase {
+		return fmt.Sprintf("'%s'i", escaped)
+	}
+	return fmt.Sprintf("'%s'", escaped)
+}
+
+func literalPattern(value string, ignoreCase bool) string {
+	pattern := "^" + regexp.QuoteMeta(value)
+	if ignoreCase {
+		pattern = "^(?i)" + regexp.QuoteMeta(value)
+	}
+	if last, _ := utf8.DecodeLastRuneInString(value); last == '_' || unicode.IsLetter(last) || unicode.IsDigit(last) {
+		pattern += "\\b"
+	}
+	return pattern
+}
+
+func (g *Grammar) defineLiteral(value string, ignoreCase bool) string {
+	name := literalName(value, ignoreCase)
+	if g.frozen || (g.Rules[name] != nil && g.Rules[name].Check != nil) {
+		return name
+	}
+
+	// longer literals come first, so the lexer doesn't split them into shorter ones
+	def := lexer.NewTokenDef(name, literalPattern(value, ignoreCase))
+	index := 0
+	for index < len(g.literalDefs) && len(g.literalDefs[index].Type) >= len(name) {
+		index++
+	}
+	g.literalDefs = append(g.literalDefs[:index], append([]model.TokenDef{def}, g.literalDefs[index:]...)...)
+
 	g.DefineRule(name, GrammarCombinator{
+		Create: func(ruleType string) *model.Rule {
+			return parser.RuleTokenValue(ruleType, value, ignoreCase)
+		},
+		definition: ruleDefinition{kind: kindLiteral, value: value, ignoreCase: ignoreCase},
+	})
+	return name
+}
+
+func (g *Grammar) tokenDefs() []model.TokenDef {
+	return append(append([]model.TokenDef{}, g.TokenDefs...), g.literalDefs...)
+}
+
+func (g *Grammar) DefineToken(name, pattern string) error {
+	if g.frozen {
+		return ErrFrozenGrammar
+	}
+	// a token that is defined again keeps its place in the lexer
+	def := lexer.NewTokenDef(name, pattern)
+	replaced := false
+	for i := range g.TokenDefs {
+		if g.TokenDefs[i].Type == name {
+			g.TokenDefs[i] = def
+			replaced = true
+		}
+	}
+	if !replaced {
+		g.TokenDefs = append(g.TokenDefs, def)
+	}
+
+	ignoredTokenTypes := []string{}
+	for _, ignored := range g.IgnoredTokenTypes {
+		if ignored != name {
+			ignoredTokenTypes = append(ignoredTokenTypes, ignored)
+		}
+	}
+	g.IgnoredTokenTypes = ignoredTokenTypes
+
+	return g.DefineRule(name, GrammarCombinator{
 		Create: func(name string) *model.Rule {
 			return parser.RuleTokenType(name, name)
 		},
+		definition: ruleDefinition{kind: kindToken, pattern: pattern},
 	})
 }
 
-func (g *Grammar) DefineIgnoredToken(name, pattern string) {
-	g.DefineToken(name, pattern)
+func (g *Grammar) DefineIgnoredToken(name, pattern string) error {
+	if err := g.DefineToken(name, pattern); err != nil {
+		return err
+	}
 	g.IgnoredTokenTypes = append(g.IgnoredTokenTypes, name)
+	g.recordDefinition(name, ruleDefinition{kind: kindToken, pattern: pattern, ignored: true})
+	return nil
 }
 
 func (g *Grammar) RunRule(ruleType, input string) model.RuleResultIterator {
-	tokens, err := lexer.ExtractTokens(input, g.TokenDefs)
+	tokens, err := lexer.ExtractTokens(input, g.tokenDefs())
 
 	if err != nil {
 		panic(err)
@@ -190,19 +420,45 @@ func (g *Grammar) RunRule(ruleType, input string) model.RuleResultIterator {
 			validTokens = append(validTokens, t)
 		}
 	}
-	return g.GetRule(ruleType).Check(validTokens)
+	rule, err := g.lookupRule(ruleType)
+	if err != nil {
+		panic(err)
+	}
+	return rule.Check(validTokens)
 }
 
-// Will return a tree or an error after applying the rule defined as ruleType to the input string.
-func (g *Grammar) Parse(ruleType, input string) (*model.Node, error) {
-	tokens, lexerError := lexer.ExtractTokens(input, g.TokenDefs)
+// Returns the tokens of the input, after the token reducers are applied.
+// Ignored tokens are kept, and the last token has the lexer.TYPE_EOF type
+func (g *Grammar) Tokenize(input string) ([]model.Token, error) {
+	return g.tokenize(input)
+}
+
+// Checks that every rule used by another rule is defined, returning an error with the names of the missing ones
+func (g *Grammar) Validate() error {
+	missing := []string{}
+	for name, rule := range g.Rules {
+		if rule.Check == nil && rule.CheckContext == nil {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return fmt.Errorf("these rules are used but never defined: %s", strings.Join(missing, ", "))
+}
+
+func (g *Grammar) tokenize(input string) ([]model.Token, error) {
+	tokens, lexerError := lexer.ExtractTokens(input, g.tokenDefs())
 
 	if lexerError != nil {
 		return nil, lexerError
 	}
 
-	rule := parser.Seq("Root", g.GetRule(ruleType), parser.RuleTokenType("EOF", "TOKEN_EOF"))
+	return g.reduceTokens(tokens), nil
+}
 
+func (g *Grammar) reduceTokens(tokens []model.Token) []model.Token {
 	tokensToParse := tokens
 	for _, tokenReducer := range g.TokenReducers {
 		result := []model.Token{}
@@ -212,6 +468,133 @@ func (g *Grammar) Parse(ruleType, input string) (*model.Node, error) {
 		}
 		tokensToParse = result
 	}
+	return tokensToParse
+}
+
+func (g *Grammar) rootRule(ruleType string) (*model.Rule, error) {
+	rule, err := g.lookupRule(ruleType)
+	if err != nil {
+		return nil, err
+	}
+	return parser.Seq("Root", rule, parser.RuleTokenType("EOF", "TOKEN_EOF")), nil
+}
+
+// Will return a tree or an error after applying the rule defined as ruleType to the input string.
+func (g *Grammar) Parse(ruleType, input string) (*model.Node, error) {
+	node, err := g.parseUnshaped(ruleType, input)
+	return g.shapeTree(node), err
+}
+
+// Works like Parse, but returns the tree before the Inline, Drop and Collapse settings are applied,
+// so that the node of ruleType is always the first child of Root
+func (g *Grammar) parseUnshaped(ruleType, input string) (*model.Node, error) {
+	tokens, err := g.tokenize(input)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := g.rootRule(ruleType)
+	if err != nil {
+		return nil, err
+	}
+
+	return parser.ParseRule(*rule, g.IgnoredTokenTypes, tokens)
+}
+
+// Works like Parse, but stops when the context is cancelled or its deadline passes, returning the context error.
+// When one of the limits in opts is exceeded, it returns a *parser.LimitError
+func (g *Grammar) ParseContext(ctx context.Context, ruleType, input string, opts ParseOptions) (*model.Node, error) {
+	tokens, err := g.tokenize(input)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := g.rootRule(ruleType)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = parser.WithLimits(ctx, parser.ParseLimits{
+		MaxDepth:       opts.MaxDepth,
+		MaxInvocations: opts.MaxInvocations,
+		MaxNodes:       opts.MaxNodes,
+	})
+
+	node, err := parser.ParseRuleContext(ctx, *rule, g.IgnoredTokenTypes, tokens)
+	return g.shapeTree(node), err
+}
+
+// Will parse only the beginning of the input string with the rule defined as ruleType, instead of requiring the whole input to match.
+// Returns the longest tree found, without the Root and EOF wrapping nodes, and the offset of the first unparsed byte of the input.
+// Text that the lexer cannot handle is allowed after the parsed prefix
+func (g *Grammar) ParsePrefix(ruleType, input string) (*model.Node, int, error) {
+	rule, err := g.lookupRule(ruleType)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tokens, lexerError := lexer.ExtractTokens(input, g.tokenDefs())
+	tokens = g.reduceTokens(tokens)
+
+	node, remaining, err := parser.ParsePrefixRule(*rule, g.IgnoredTokenTypes, tokens)
+
+	if err != nil {
+		if lexerError != nil {
+			return nil, 0, lexerError
+		}
+		return nil, 0, err
+	}
+
+	node = g.shapeTree(node)
+
+	if len(remaining) > 0 {
+		return node, remaining[0].Offset, nil
+	}
+
+	if len(tokens) == 0 {
+		return node, 0, nil
+	}
+	lastToken := tokens[len(tokens)-1]
+	return node, lastToken.Offset + len(lastToken.Value), nil
+}
+
+// Will return every distinct tree that the rule defined as ruleType can produce for the whole input string, up to limit trees.
+// A limit of zero or less returns all of them, which may never finish if the grammar allows too many parses.
+// When a cut fails, parsing stops, and the trees found before it are returned along with the error
+func (g *Grammar) ParseAll(ruleType, input string, limit int) ([]*model.Node, error) {
+	tokens, err := g.tokenize(input)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := g.rootRule(ruleType)
+	if err != nil {
+		return nil, err
+	}
+
+	trees, err := parser.ParseAllRule(*rule, g.IgnoredTokenTypes, tokens, limit)
+	for _, tree := range trees {
+		g.shapeTree(tree)
+	}
+	return trees, err
+}
+
+// Checks if the input string can be parsed in more than one way by the rule defined as ruleType.
+// When it can, the returned Ambiguity describes where the first two trees found differ.
+// A cut that fails after a tree was found only stops the search for a second one, so the input is not ambiguous
+func (g *Grammar) IsAmbiguous(ruleType, input string) (bool, *Ambiguity, error) {
+	trees, err := g.ParseAll(ruleType, input, 2)
+
+	if err != nil && len(trees) == 0 {
+		return false, nil, err
+	}
+
+	if len(trees) < 2 {
+		return false, nil, nil
+	}
 
-	return parser.ParseRule(*rule, g.IgnoredTokenTypes, tokensToParse)
+	return true, findAmbiguity(trees[0], trees[1]), nil
 }
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"github.com/jsanchesleao/grammatic/lexer"
 	"github.com/jsanchesleao/grammatic/model"
+	"github.com/jsanchesleao/grammatic/parser"
+	"strconv"
 	"strings"
 )
 
@@ -20,11 +22,36 @@ func GrammarParsingGrammar() Grammar {
 	g.DefineRule("VirtualTokenNames", g.OneOrMany("RuleName"))
 
 	g.DefineRule("GrammarRule",
-		g.Seq("RuleName", "Assignment", "RuleExpression"))
+		g.Seq("RuleName", "TemplateParameters", "RuleOperator", "RuleExpression", "RuleFlags"))
+
+	g.DefineRule("RuleOperator", g.Or("Assignment", "AlternativesAssignment"))
+
+	g.DefineRule("TemplateParameters", g.OneOrNone("TemplateParameterList"))
+
+	g.DefineRule("TemplateParameterList",
+		g.Seq("LeftAngle", "TemplateParameterNames", "RightAngle"))
+
+	g.DefineRule("TemplateParameterNames",
+		g.OneOrManyWithSeparator("RuleName", "Comma"))
+
+	g.DefineRule("TemplateInstance",
+		g.Memo(g.Seq("RuleName", "LeftAngle", "TemplateArguments", "RightAngle")))
+
+	g.DefineRule("TemplateArguments",
+		g.OneOrManyWithSeparator("TemplateArgument", "Comma"))
+
+	g.DefineRule("TemplateArgument",
+		g.Or("TemplateInstance", "RuleName", "Literal"))
+
+	g.DefineRule("RuleFlags", g.Many("RuleFlag"))
+
+	g.DefineRule("RuleFlag", g.Or("InlineFlag", "CollapseFlag"))
 
 	g.DefineRule("RuleExpression",
-		g.Or(
+		g.Memo(g.Or(
+			"TemplateInstance",
 			"RuleName",
+			"Literal",
 			"TokenExpression",
 			"SeqExpression",
 			"OrExpression",
@@ -32,50 +59,84 @@ func GrammarParsingGrammar() Grammar {
 			"OneOrManyExpression",
 			"OneOrNoneExpression",
 			"ManyWithSeparatorExpression",
-			"OneOrManyWithSeparatorExpression"))
+			"OneOrManyWithSeparatorExpression",
+			"RepeatExpression",
+			"RepeatWithSeparatorExpression")))
 
 	g.DefineRule("InlineRenameExpression",
-		g.Seq("RuleName", "As", "RuleName"))
+		g.Seq("InlineRenameExpressionItem", "As", "RuleName"))
+
+	g.DefineRule("InlineRenameExpressionItem",
+		g.Or("TemplateInstance", "RuleName", "Literal"))
 
 	g.DefineRule("ManyExpression",
-		g.Seq("ManyExpressionItem", "Star"))
+		g.Memo(g.Seq("ManyExpressionItem", "Star")))
 
 	g.DefineRule("ManyExpressionItem",
-		g.Or("RuleName", "InlineRuleExpression"))
+		g.Memo(g.Or("TemplateInstance", "RuleName", "Literal", "InlineRuleExpression", "GroupExpression")))
 
 	g.DefineRule("ManyWithSeparatorExpression",
-		g.Seq(
+		g.Memo(g.Seq(
 			"ManyExpressionItem",
 			"LeftBracket",
 			"ManyExpressionItem",
+			"TrailingSeparator",
 			"RightBracket",
-			"Star"))
+			"Star")))
+
+	g.DefineRule("TrailingSeparator",
+		g.OneOrNone("Comma"))
 
 	g.DefineRule("OneOrManyExpression",
-		g.Seq("OneOrManyExpressionItem", "Plus"))
+		g.Memo(g.Seq("OneOrManyExpressionItem", "Plus")))
 
 	g.DefineRule("OneOrManyExpressionItem",
-		g.Or("RuleName", "InlineRuleExpression"))
+		g.Memo(g.Or("TemplateInstance", "RuleName", "Literal", "InlineRuleExpression", "GroupExpression")))
 
 	g.DefineRule("OneOrManyWithSeparatorExpression",
-		g.Seq(
+		g.Memo(g.Seq(
 			"OneOrManyExpressionItem",
 			"LeftBracket",
 			"OneOrManyExpressionItem",
+			"TrailingSeparator",
 			"RightBracket",
-			"Plus"))
+			"Plus")))
 
 	g.DefineRule("OneOrNoneExpression",
-		g.Seq("OneOrNoneExpressionItem", "QuestionMark"))
+		g.Memo(g.Seq("OneOrNoneExpressionItem", "QuestionMark")))
 
 	g.DefineRule("OneOrNoneExpressionItem",
-		g.Or("RuleName", "InlineRuleExpression"))
+		g.Memo(g.Or("TemplateInstance", "RuleName", "Literal", "InlineRuleExpression", "GroupExpression")))
+
+	g.DefineRule("RepeatExpression",
+		g.Memo(g.Seq("ManyExpressionItem", "RepeatCount")))
+
+	g.DefineRule("RepeatWithSeparatorExpression",
+		g.Memo(g.Seq(
+			"ManyExpressionItem",
+			"LeftBracket",
+			"ManyExpressionItem",
+			"TrailingSeparator",
+			"RightBracket",
+			"RepeatCount")))
+
+	g.DefineRule("RepeatCount",
+		g.Seq("LeftCurly", "Count", "RepeatCountRange", "RightCurly"))
+
+	g.DefineRule("RepeatCountRange",
+		g.OneOrNone("RepeatCountMax"))
+
+	g.DefineRule("RepeatCountMax",
+		g.Seq("Comma", "RepeatCountMaxValue"))
+
+	g.DefineRule("RepeatCountMaxValue",
+		g.OneOrNone("Count"))
 
 	g.DefineRule("OrExpression",
 		g.Seq("OrExpressionItem", "Pipe", "OrExpressionTail"))
 
 	g.DefineRule("OrExpressionItem",
-		g.Or(
+		g.Memo(g.Or(
 			"InlineSeqExpression",
 			"InlineRuleExpression",
 			"InlineManyExpression",
@@ -83,7 +144,19 @@ func GrammarParsingGrammar() Grammar {
 			"InlineOneOrManyExpression",
 			"InlineOneOrManyWithSeparatorExpression",
 			"InlineOneOrNoneExpression",
-			"RuleName"))
+			"InlineRepeatExpression",
+			"InlineRepeatWithSeparatorExpression",
+			"TemplateInstance",
+			"RuleName",
+			"Literal",
+			"ManyExpression",
+			"ManyWithSeparatorExpression",
+			"OneOrManyExpression",
+			"OneOrManyWithSeparatorExpression",
+			"OneOrNoneExpression",
+			"RepeatExpression",
+			"RepeatWithSeparatorExpression",
+			"GroupExpression")))
 
 	g.DefineRule("OrExpressionTail",
 		g.OneOrManyWithSeparator("OrExpressionItem", "Pipe"))
@@ -92,26 +165,44 @@ func GrammarParsingGrammar() Grammar {
 		g.Seq("SeqExpressionItem", "SeqExpressionTail"))
 
 	g.DefineRule("SeqExpressionItem",
-		g.Or(
+		g.Memo(g.Or(
 			"InlineRuleExpression",
 			"InlineManyExpression",
 			"InlineManyWithSeparatorExpression",
 			"InlineOneOrManyExpression",
 			"InlineOneOrManyWithSeparatorExpression",
 			"InlineOneOrNoneExpression",
+			"InlineRepeatExpression",
+			"InlineRepeatWithSeparatorExpression",
 			"InlineRenameExpression",
-			"RuleName"))
+			"TemplateInstance",
+			"RuleName",
+			"Literal",
+			"ManyExpression",
+			"ManyWithSeparatorExpression",
+			"OneOrManyExpression",
+			"OneOrManyWithSeparatorExpression",
+			"OneOrNoneExpression",
+			"RepeatExpression",
+			"RepeatWithSeparatorExpression",
+			"GroupExpression",
+			"Cut")))
 
 	g.DefineRule("SeqExpressionTail",
 		g.OneOrMany("SeqExpressionItem"))
 
 	g.DefineRule("InlineRuleExpression",
-		g.Seq("LeftParens", "RuleExpression", "As", "RuleName", "RightParens"))
+		g.Memo(g.Seq("LeftParens", "RuleExpression", "As", "RuleName", "RightParens")))
+
+	g.DefineRule("GroupExpression",
+		g.Memo(g.Seq("LeftParens", "RuleExpression", "RightParens")))
 
 	g.DefineRule("InlineSeqExpression",
-		g.Seq("RuleName", "InlineSeqExpressionTail", "As", "RuleName"))
+		g.Seq("InlineSeqExpressionItem", "InlineSeqExpressionTail", "As", "RuleName"))
 
-	g.DefineRule("InlineSeqExpressionTail", g.OneOrMany("RuleName"))
+	g.DefineRule("InlineSeqExpressionTail", g.OneOrMany("InlineSeqExpressionItem"))
+
+	g.DefineRule("InlineSeqExpressionItem", g.Memo(g.Or("TemplateInstance", "RuleName", "Literal", "GroupExpression", "Cut")))
 
 	g.DefineRule("InlineManyExpression",
 		g.Seq("ManyExpression", "As", "RuleName"))
@@ -128,6 +219,12 @@ func GrammarParsingGrammar() Grammar {
 	g.DefineRule("InlineOneOrNoneExpression",
 		g.Seq("OneOrNoneExpression", "As", "RuleName"))
 
+	g.DefineRule("InlineRepeatExpression",
+		g.Seq("RepeatExpression", "As", "RuleName"))
+
+	g.DefineRule("InlineRepeatWithSeparatorExpression",
+		g.Seq("RepeatWithSeparatorExpression", "As", "RuleName"))
+
 	g.DefineRule("TokenExpression",
 		g.Seq("TokenExpressionBody", "TokenExpressionFlag"))
 
@@ -138,14 +235,24 @@ func GrammarParsingGrammar() Grammar {
 		g.OneOrNone("TokenExpressionFlagValue"))
 
 	g.DefineRule("TokenExpressionFlagValue",
-		g.Seq("LeftParens", "Ignore", "RightParens"))
+		g.Seq("LeftParens", "TokenExpressionFlagName", "RightParens"))
+
+	// checked when the grammar is processed, so that words like drop can still name rules
+	g.DefineRule("TokenExpressionFlagName", g.Or("Ignore", "RuleName"))
 
 	g.DefineToken("Token", "^\\/(\\\\/|[^/])+?\\/")
 	g.DefineToken("ConvenienceToken", "^\\$\\w+")
+	g.DefineToken("Literal", "^'(\\\\.|[^'\\\\])+'i?")
 	g.DefineToken("As", "^as")
 	g.DefineToken("Ignore", "^ignore")
-	g.DefineToken("RuleName", lexer.KeywordFormat)
+	// the flags of production rules are single tokens, so that inline and collapse can still name rules,
+	// and a flag is not read as a group holding a rule
+	g.DefineToken("InlineFlag", "^\\(\\s*inline\\s*\\)")
+	g.DefineToken("CollapseFlag", "^\\(\\s*collapse\\s*\\)")
+	g.DefineToken("RuleName", "^(?i)_*[a-z][-_\\w]*(\\._*[a-z][-_\\w]*)*")
+	g.DefineToken("AlternativesAssignment", "^\\|=")
 	g.DefineToken("Pipe", "^\\|")
+	g.DefineToken("Cut", "^\\^")
 	g.DefineToken("Star", "^\\*")
 	g.DefineToken("Plus", "^\\+")
 	g.DefineToken("QuestionMark", "^\\?")
@@ -153,6 +260,12 @@ func GrammarParsingGrammar() Grammar {
 	g.DefineToken("RightBracket", "^\\]")
 	g.DefineToken("LeftParens", "^\\(")
 	g.DefineToken("RightParens", "^\\)")
+	g.DefineToken("LeftCurly", "^\\{")
+	g.DefineToken("RightCurly", "^\\}")
+	g.DefineToken("Count", "^\\d+")
+	g.DefineToken("Comma", "^,")
+	g.DefineToken("LeftAngle", "^<")
+	g.DefineToken("RightAngle", "^>")
 	g.DefineToken("Assignment", "^:=")
 	g.DefineToken("Virtual", "^:virtual:")
 
@@ -174,7 +287,17 @@ func createRules(grammar *Grammar, node *model.Node) *GrammarCombinator {
 	case "Grammar":
 		grammarRuleNodes := node.GetNodeWithType("GrammarRules").GetNodesWithType("GrammarRule")
 		for _, grammarRuleNode := range grammarRuleNodes {
-			createRules(grammar, grammarRuleNode)
+			processTemplateDefinition(grammar, grammarRuleNode)
+		}
+		for _, grammarRuleNode := range grammarRuleNodes {
+			if !isAlternativesRule(grammarRuleNode) {
+				createRules(grammar, grammarRuleNode)
+			}
+		}
+		for _, grammarRuleNode := range grammarRuleNodes {
+			if isAlternativesRule(grammarRuleNode) {
+				processAlternativesRule(grammar, grammarRuleNode)
+			}
 		}
 		virtual := node.GetNodeWithType("VirtualTokens").GetNodeWithType("VirtualTokenStatement")
 		if virtual != nil {
@@ -187,13 +310,18 @@ func createRules(grammar *Grammar, node *model.Node) *GrammarCombinator {
 		return nil
 
 	case "GrammarRule":
-		nameNode := node.Rules[0]
-		ruleExpressionNode := node.Rules[2]
+		nameNode := node.GetNodeWithType("RuleName")
+		ruleExpressionNode := node.GetNodeWithType("RuleExpression")
 
-		grammarCombinator := createRules(grammar, &ruleExpressionNode)
+		if len(node.GetNodeWithType("TemplateParameters").Rules) > 0 {
+			return nil
+		}
+
+		grammarCombinator := createRules(grammar, ruleExpressionNode)
 		if grammarCombinator != nil {
 			grammar.DefineRule(nameNode.Token.Value, *grammarCombinator)
 		}
+		processRuleFlags(grammar, nameNode.Token.Value, node)
 		return nil
 
 	case "TokenExpression":
@@ -212,94 +340,72 @@ func createRules(grammar *Grammar, node *model.Node) *GrammarCombinator {
 		combinator := grammar.Rename(node.Token.Value)
 		return &combinator
 
-	case "ManyExpression":
-		item := node.GetNodeWithType("ManyExpressionItem")
-		ruleName := item.GetNodeWithType("RuleName")
-		inlineRule := item.GetNodeWithType("InlineRuleExpresion")
+	case "Literal":
+		combinator := grammar.Rename(processLiteral(grammar, node))
+		return &combinator
 
-		if ruleName != nil {
-			combinator := grammar.Many(ruleName.Token.Value)
-			return &combinator
-		} else if inlineRule != nil {
-			inlineRuleName := processInlineRuleExpression(grammar, inlineRule)
-			combinator := grammar.Many(inlineRuleName)
-			return &combinator
-		}
-		return nil
+	case "TemplateInstance":
+		combinator := grammar.Rename(processTemplateInstance(grammar, node))
+		return &combinator
 
-	case "ManyWithSeparatorExpression":
-		item := node.Rules[0]
-		separator := node.Rules[2]
+	case "ManyExpression":
+		itemName := processExpressionItem(grammar, node.GetNodeWithType("ManyExpressionItem"))
 
-		itemName := ""
-		separatorName := ""
+		combinator := grammar.Many(itemName)
+		return &combinator
 
-		if item.GetNodeWithType("InlineRuleExpression") != nil {
-			itemName = processInlineRuleExpression(grammar, item.GetNodeWithType("InlineRuleExpression"))
-		} else {
-			itemName = item.GetNodeWithType("RuleName").Token.Value
-		}
+	case "ManyWithSeparatorExpression":
+		itemName := processExpressionItem(grammar, &node.Rules[0])
+		separatorName := processExpressionItem(grammar, &node.Rules[2])
 
-		if separator.GetNodeWithType("InlineRuleExpression") != nil {
-			separatorName = processInlineRuleExpression(grammar, separator.GetNodeWithType("InlineRuleExpression"))
-		} else {
-			separatorName = separator.GetNodeWithType("RuleName").Token.Value
+		if hasTrailingSeparator(node) {
+			combinator := grammar.ManyWithSeparatorTrailing(itemName, separatorName)
+			return &combinator
 		}
-
 		combinator := grammar.ManyWithSeparator(itemName, separatorName)
 		return &combinator
 
 	case "OneOrManyExpression":
-		item := node.GetNodeWithType("OneOrManyExpressionItem")
-		ruleName := item.GetNodeWithType("RuleName")
-		inlineRule := item.GetNodeWithType("InlineRuleExpresion")
+		itemName := processExpressionItem(grammar, node.GetNodeWithType("OneOrManyExpressionItem"))
 
-		if ruleName != nil {
-			combinator := grammar.OneOrMany(ruleName.Token.Value)
-			return &combinator
-		} else if inlineRule != nil {
-			inlineRuleName := processInlineRuleExpression(grammar, inlineRule)
-			combinator := grammar.OneOrMany(inlineRuleName)
-			return &combinator
-		}
-		return nil
+		combinator := grammar.OneOrMany(itemName)
+		return &combinator
 
 	case "OneOrManyWithSeparatorExpression":
-		item := node.Rules[0]
-		separator := node.Rules[2]
+		itemName := processExpressionItem(grammar, &node.Rules[0])
+		separatorName := processExpressionItem(grammar, &node.Rules[2])
 
-		itemName := ""
-		separatorName := ""
-
-		if item.GetNodeWithType("InlineRuleExpression") != nil {
-			itemName = processInlineRuleExpression(grammar, item.GetNodeWithType("InlineRuleExpression"))
-		} else {
-			itemName = item.GetNodeWithType("RuleName").Token.Value
-		}
-
-		if separator.GetNodeWithType("InlineRuleExpression") != nil {
-			separatorName = processInlineRuleExpression(grammar, separator.GetNodeWithType("InlineRuleExpression"))
-		} else {
-			separatorName = separator.GetNodeWithType("RuleName").Token.Value
+		if hasTrailingSeparator(node) {
+			combinator := grammar.OneOrManyWithSeparatorTrailing(itemName, separatorName)
+			return &combinator
 		}
-
 		combinator := grammar.OneOrManyWithSeparator(itemName, separatorName)
 		return &combinator
 
 	case "OneOrNoneExpression":
-		item := node.GetNodeWithType("OneOrNoneExpressionItem")
-		ruleName := item.GetNodeWithType("RuleName")
-		inlineRule := item.GetNodeWithType("InlineRuleExpresion")
+		itemName := processExpressionItem(grammar, node.GetNodeWithType("OneOrNoneExpressionItem"))
 
-		if ruleName != nil {
-			combinator := grammar.OneOrNone(ruleName.Token.Value)
-			return &combinator
-		} else if inlineRule != nil {
-			inlineRuleName := processInlineRuleExpression(grammar, inlineRule)
-			combinator := grammar.OneOrNone(inlineRuleName)
+		combinator := grammar.OneOrNone(itemName)
+		return &combinator
+
+	case "RepeatExpression":
+		itemName := processExpressionItem(grammar, &node.Rules[0])
+		min, max := processRepeatCount(node.GetNodeWithType("RepeatCount"))
+
+		combinator := grammar.Repeat(itemName, min, max)
+		return &combinator
+
+	case "RepeatWithSeparatorExpression":
+		itemName := processExpressionItem(grammar, &node.Rules[0])
+		separatorName := processExpressionItem(grammar, &node.Rules[2])
+		min, max := processRepeatCount(node.GetNodeWithType("RepeatCount"))
+
+		if hasTrailingSeparator(node) {
+			combinator := grammar.RepeatWithSeparatorTrailing(itemName, separatorName, min, max)
 			return &combinator
 		}
-		return nil
+		combinator := grammar.RepeatWithSeparator(itemName, separatorName, min, max)
+		return &combinator
 
 	case "SeqExpression":
 		firstItem := node.GetNodeWithType("SeqExpressionItem")
@@ -311,7 +417,7 @@ func createRules(grammar *Grammar, node *model.Node) *GrammarCombinator {
 		for _, item := range items {
 			ruleName := processSeqOrExpressionItem(grammar, item)
 			if ruleName != "" {
-				ruleNames = append(ruleNames, processSeqOrExpressionItem(grammar, item))
+				ruleNames = append(ruleNames, ruleName)
 			}
 		}
 
@@ -328,7 +434,7 @@ func createRules(grammar *Grammar, node *model.Node) *GrammarCombinator {
 		for _, item := range items {
 			ruleName := processSeqOrExpressionItem(grammar, item)
 			if ruleName != "" {
-				ruleNames = append(ruleNames, processSeqOrExpressionItem(grammar, item))
+				ruleNames = append(ruleNames, ruleName)
 			}
 		}
 
@@ -340,6 +446,63 @@ func createRules(grammar *Grammar, node *model.Node) *GrammarCombinator {
 	return nil
 }
 
+func isAlternativesRule(node *model.Node) bool {
+	return node.GetNodeWithType("RuleOperator").GetNodeWithType("AlternativesAssignment") != nil
+}
+
+// Adds the alternatives of a rule defined with |= to the existing Or rule with the same name
+func processAlternativesRule(grammar *Grammar, node *model.Node) {
+	ruleName := node.GetNodeWithType("RuleName").Token.Value
+	if len(node.GetNodeWithType("TemplateParameters").Rules) > 0 {
+		panic(fmt.Errorf("Cannot add alternatives to template %q", ruleName))
+	}
+
+	expression := node.GetNodeWithType("RuleExpression")
+	alternatives := []string{}
+
+	switch expression.Rules[0].Type {
+	case "OrExpression":
+		orExpression := &expression.Rules[0]
+		items := append([]*model.Node{orExpression.GetNodeWithType("OrExpressionItem")},
+			orExpression.GetNodeWithType("OrExpressionTail").GetNodesWithType("OrExpressionItem")...)
+		for _, item := range items {
+			if name := processSeqOrExpressionItem(grammar, item); name != "" {
+				alternatives = append(alternatives, name)
+			}
+		}
+	case "RuleName", "Literal", "TemplateInstance":
+		alternatives = append(alternatives, processSeqOrExpressionItem(grammar, &expression.Rules[0]))
+	default:
+		combinator := createRules(grammar, expression)
+		if combinator != nil {
+			alternatives = append(alternatives, grammar.defineGroup(*combinator))
+		}
+	}
+
+	if err := grammar.AddAlternatives(ruleName, alternatives...); err != nil {
+		panic(err)
+	}
+	processRuleFlags(grammar, ruleName, node)
+}
+
+// Applies the flags of a grammar rule, like (inline) or (drop), to the rule defined with the given name
+func processRuleFlags(grammar *Grammar, ruleName string, node *model.Node) {
+	if tokenExpression := node.GetNodeWithType("RuleExpression").GetNodeWithType("TokenExpression"); tokenExpression != nil {
+		if processTokenFlag(tokenExpression.GetNodeWithType("TokenExpressionFlag")) == "drop" {
+			grammar.Drop(ruleName)
+		}
+	}
+
+	for _, flag := range node.GetNodeWithType("RuleFlags").GetNodesWithType("RuleFlag") {
+		switch flag.Rules[0].Type {
+		case "InlineFlag":
+			grammar.Inline(ruleName)
+		case "CollapseFlag":
+			grammar.Collapse(ruleName)
+		}
+	}
+}
+
 func processToken(grammar *Grammar, node *model.Node, flag string) GrammarCombinator {
 	convenienceToken := node.GetNodeWithType("ConvenienceToken")
 	token := node.GetNodeWithType("Token")
@@ -369,7 +532,104 @@ func processTokenFlag(node *model.Node) string {
 		return ""
 	}
 	valueNode := node.GetNodeWithType("TokenExpressionFlagValue")
-	return valueNode.GetNodeWithType("Ignore").Token.Value
+	token := valueNode.GetNodeWithType("TokenExpressionFlagName").Rules[0].Token
+	if token.Value != "ignore" && token.Value != "drop" {
+		panic(&model.SyntaxError{Token: *token})
+	}
+	return token.Value
+}
+
+// Returns the name of the rule used by an item of a repeating expression, defining it if it is an inline rule
+func processExpressionItem(grammar *Grammar, item *model.Node) string {
+	if inlineRule := item.GetNodeWithType("InlineRuleExpression"); inlineRule != nil {
+		return processInlineRuleExpression(grammar, inlineRule)
+	}
+	if literal := item.GetNodeWithType("Literal"); literal != nil {
+		return processLiteral(grammar, literal)
+	}
+	if group := item.GetNodeWithType("GroupExpression"); group != nil {
+		return processGroupExpression(grammar, group)
+	}
+	if instance := item.GetNodeWithType("TemplateInstance"); instance != nil {
+		return processTemplateInstance(grammar, instance)
+	}
+	return item.GetNodeWithType("RuleName").Token.Value
+}
+
+// Defines the rule for a parenthesized group without a name, and returns the name generated for it.
+// A group around a single rule or literal is the same as the rule itself
+func processGroupExpression(grammar *Grammar, node *model.Node) string {
+	expression := node.GetNodeWithType("RuleExpression")
+
+	switch expression.Rules[0].Type {
+	case "RuleName":
+		return expression.Rules[0].Token.Value
+	case "Literal":
+		return processLiteral(grammar, &expression.Rules[0])
+	case "TemplateInstance":
+		return processTemplateInstance(grammar, &expression.Rules[0])
+	}
+
+	combinator := createRules(grammar, expression)
+	if combinator == nil {
+		return ""
+	}
+	return grammar.defineGroup(*combinator)
+}
+
+// Defines the rule for a quoted literal, like ',' or 'select'i, and returns its name
+func processLiteral(grammar *Grammar, node *model.Node) string {
+	text := node.Token.Value
+	ignoreCase := strings.HasSuffix(text, "i")
+	if ignoreCase {
+		text = text[:len(text)-1]
+	}
+
+	value := strings.Builder{}
+	escaped := false
+	for _, char := range text[1 : len(text)-1] {
+		if char == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+		escaped = false
+		value.WriteRune(char)
+	}
+
+	if ignoreCase {
+		return grammar.LiteralIgnoreCase(value.String())
+	}
+	return grammar.Literal(value.String())
+}
+
+func hasTrailingSeparator(node *model.Node) bool {
+	return len(node.GetNodeWithType("TrailingSeparator").Rules) > 0
+}
+
+func processRepeatCount(node *model.Node) (int, int) {
+	min, err := strconv.Atoi(node.GetNodeWithType("Count").Token.Value)
+	if err != nil {
+		panic(err)
+	}
+
+	countMax := node.GetNodeWithType("RepeatCountRange").GetNodeWithType("RepeatCountMax")
+	if countMax == nil {
+		return min, min
+	}
+
+	maxValue := countMax.GetNodeWithType("RepeatCountMaxValue").GetNodeWithType("Count")
+	if maxValue == nil {
+		return min, parser.Unbounded
+	}
+
+	max, err := strconv.Atoi(maxValue.Token.Value)
+	if err != nil {
+		panic(err)
+	}
+	if max < min {
+		panic(fmt.Errorf("Invalid repetition count: maximum %d is lower than minimum %d", max, min))
+	}
+	return min, max
 }
 
 func processInlineRuleExpression(grammar *Grammar, node *model.Node) string {
@@ -388,13 +648,34 @@ func processInlineRuleExpression(grammar *Grammar, node *model.Node) string {
 
 func processSeqOrExpressionItem(grammar *Grammar, node *model.Node) string {
 	itemNode := node
-	if node.Type == "SeqExpressionItem" || node.Type == "OrExpressionItem" {
+	if node.Type == "SeqExpressionItem" || node.Type == "OrExpressionItem" || node.Type == "InlineSeqExpressionItem" || node.Type == "TemplateArgument" {
 		itemNode = &node.Rules[0]
 	}
 
 	switch itemNode.Type {
 	case "RuleName":
 		return itemNode.Token.Value
+
+	case "Literal":
+		return processLiteral(grammar, itemNode)
+
+	case "TemplateInstance":
+		return processTemplateInstance(grammar, itemNode)
+
+	case "Cut":
+		return CutMarker
+
+	case "GroupExpression":
+		return processGroupExpression(grammar, itemNode)
+
+	case "ManyExpression", "ManyWithSeparatorExpression", "OneOrManyExpression", "OneOrManyWithSeparatorExpression",
+		"OneOrNoneExpression", "RepeatExpression", "RepeatWithSeparatorExpression":
+		combinator := createRules(grammar, itemNode)
+		if combinator == nil {
+			return ""
+		}
+		return grammar.defineGroup(*combinator)
+
 	case "InlineRuleExpression":
 		ruleName := itemNode.GetNodeWithType("RuleName")
 		ruleExpression := itemNode.GetNodeWithType("RuleExpression")
@@ -405,7 +686,7 @@ func processSeqOrExpressionItem(grammar *Grammar, node *model.Node) string {
 		return ruleName.Token.Value
 
 	case "InlineRenameExpression":
-		originalName := itemNode.Rules[0]
+		originalName := itemNode.Rules[0].Rules[0]
 		newName := itemNode.Rules[2]
 		combinator := createRules(grammar, &originalName)
 		if combinator != nil {
@@ -463,9 +744,29 @@ func processSeqOrExpressionItem(grammar *Grammar, node *model.Node) string {
 		}
 		return ""
 
+	case "InlineRepeatExpression":
+		ruleName := itemNode.GetNodeWithType("RuleName")
+		repeatExpression := itemNode.GetNodeWithType("RepeatExpression")
+		combinator := createRules(grammar, repeatExpression)
+		if combinator != nil {
+			grammar.DefineRule(ruleName.Token.Value, *combinator)
+			return ruleName.Token.Value
+		}
+		return ""
+
+	case "InlineRepeatWithSeparatorExpression":
+		ruleName := itemNode.GetNodeWithType("RuleName")
+		repeatExpression := itemNode.GetNodeWithType("RepeatWithSeparatorExpression")
+		combinator := createRules(grammar, repeatExpression)
+		if combinator != nil {
+			grammar.DefineRule(ruleName.Token.Value, *combinator)
+			return ruleName.Token.Value
+		}
+		return ""
+
 	case "InlineSeqExpression":
 		seqHead := itemNode.Rules[0]
-		seqTail := itemNode.Rules[1].GetNodesWithType("RuleName")
+		seqTail := itemNode.Rules[1].GetNodesWithType("InlineSeqExpressionItem")
 
 		seqItems := append([]*model.Node{&seqHead}, seqTail...)
 
@@ -475,7 +776,7 @@ func processSeqOrExpressionItem(grammar *Grammar, node *model.Node) string {
 		for _, item := range seqItems {
 			seqRuleName := processSeqOrExpressionItem(grammar, item)
 			if seqRuleName != "" {
-				ruleNames = append(ruleNames, processSeqOrExpressionItem(grammar, item))
+				ruleNames = append(ruleNames, seqRuleName)
 			}
 		}
 
@@ -492,18 +793,42 @@ func processSeqOrExpressionItem(grammar *Grammar, node *model.Node) string {
 // It panics if the grammar syntax is invalid
 func Compile(grammarText string) Grammar {
 
-	g := GrammarParsingGrammar()
-
-	node, err := g.Parse("Grammar", grammarText)
+	grammar := NewGrammar()
 
-	if err != nil {
+	if err := grammar.Load(grammarText); err != nil {
 		panic(err)
 	}
 
-	grammar := NewGrammar()
+	return grammar
 
-	createRules(&grammar, node)
+}
 
-	return grammar
+// Adds the rules of a grammar definition to the grammar, returning an error if the definition is invalid.
+// Rules that already exist are replaced, and rules written with |= add alternatives to existing Or rules
+func (g *Grammar) Load(grammarText string) (err error) {
+	if g.frozen {
+		return ErrFrozenGrammar
+	}
+
+	parsingGrammar := GrammarParsingGrammar()
+
+	node, err := parsingGrammar.Parse("Grammar", grammarText)
+
+	if err != nil {
+		return err
+	}
 
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			if recoveredError, ok := recovered.(error); ok {
+				err = recoveredError
+			} else {
+				err = fmt.Errorf("%v", recovered)
+			}
+		}
+	}()
+
+	createRules(g, node)
+
+	return nil
 }
@@ -1,6 +1,9 @@
 package grammatic
 
 import (
+	"errors"
+	"github.com/jsanchesleao/grammatic/model"
+	"os"
 	"testing"
 )
 
@@ -78,3 +81,556 @@ func TestJSONParsing(t *testing.T) {
 	}
 
 }
+
+const statementGrammar = `
+Program := Statement+
+
+Statement := IfStatement | Call
+
+IfStatement := If ^ LParen Name RParen Semicolon
+
+Call := Name LParen Name RParen Semicolon
+
+If := /if/
+LParen := /\(/
+RParen := /\)/
+Semicolon := /;/
+Name := $KeywordFormat
+Space := $EmptySpaceFormat (ignore)`
+
+func TestCutErrorPosition(t *testing.T) {
+	grammar := Compile(statementGrammar)
+
+	_, err := grammar.Parse("Program", "print(x); if (x;")
+	if err == nil {
+		t.Fatalf("Expected parsing to fail, but it did not")
+	}
+
+	expectedErrorMessage := "Unexpected token \";\" at line 1, column 16"
+	if err.Error() != expectedErrorMessage {
+		t.Fatalf("Expected error message to be\n%q\n but was\n%q\n", expectedErrorMessage, err.Error())
+	}
+
+	_, err = grammar.Parse("Program", "print(x); if (x);")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCutInsideInlineSequence(t *testing.T) {
+	grammar := Compile(`
+Statement := If ^ LParen RParen as IfStatement
+           | If Name as Other
+
+If := /if/
+LParen := /\(/
+RParen := /\)/
+Name := $KeywordFormat
+Space := $EmptySpaceFormat (ignore)`)
+
+	_, err := grammar.Parse("Statement", "if foo")
+	if err == nil {
+		t.Fatalf("Expected the cut to prevent the second alternative, but parsing succeeded")
+	}
+}
+
+const repetitionGrammar = `
+Declaration := Address Qualifier{0,2} as Qualifiers Names
+
+Address := HexGroup[Colon]{4}
+
+Names := Name[Comma]{1,}
+
+HexGroup := /[0-9a-f]{2}/
+Qualifier := /@\w+/
+Name := $KeywordFormat
+Colon := /:/
+Comma := /,/
+Space := $EmptySpaceFormat (ignore)`
+
+func TestBoundedRepetition(t *testing.T) {
+	grammar := Compile(repetitionGrammar)
+
+	node, err := grammar.Parse("Declaration", "0a:1b:2c:3d @first @second one, two")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedSyntaxTree := `Root
+  ├─Declaration
+  │ ├─Address
+  │ │ ├─HexGroup • 0a
+  │ │ ├─Colon • :
+  │ │ ├─HexGroup • 1b
+  │ │ ├─Colon • :
+  │ │ ├─HexGroup • 2c
+  │ │ ├─Colon • :
+  │ │ └─HexGroup • 3d
+  │ ├─Qualifiers
+  │ │ ├─Qualifier • @first
+  │ │ └─Qualifier • @second
+  │ └─Names
+  │   ├─Name • one
+  │   ├─Comma • ,
+  │   └─Name • two
+  └─EOF • 
+
+`
+	if expectedSyntaxTree != node.PrettyPrint() {
+		t.Fatalf("Unexpected syntax tree\n%s", node.PrettyPrint())
+	}
+
+	invalidInputs := []string{
+		"0a:1b:2c one",
+		"0a:1b:2c:3d:4e one",
+		"0a:1b:2c:3d @a @b @c one",
+		"0a:1b:2c:3d @a",
+	}
+	for _, input := range invalidInputs {
+		if _, err := grammar.Parse("Declaration", input); err == nil {
+			t.Fatalf("Expected %q to fail, but it was parsed", input)
+		}
+	}
+}
+
+func TestTrailingSeparator(t *testing.T) {
+	grammar := Compile(`
+Array := LeftBrackets Value[Comma,]* as Items RightBrackets
+
+Value := Number | Array
+
+LeftBrackets := /\[/
+RightBrackets := /\]/
+Comma := /,/
+Number := $NumberFormat
+Space := $EmptySpaceFormat (ignore)`)
+
+	node, err := grammar.Parse("Array", "[1, [2,], 3,]")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedSyntaxTree := `Root
+  ├─Array
+  │ ├─LeftBrackets • [
+  │ ├─Items
+  │ │ ├─Value
+  │ │ │ └─Number • 1
+  │ │ ├─Comma • ,
+  │ │ ├─Value
+  │ │ │ └─Array
+  │ │ │   ├─LeftBrackets • [
+  │ │ │   ├─Items
+  │ │ │   │ ├─Value
+  │ │ │   │ │ └─Number • 2
+  │ │ │   │ └─Comma • ,
+  │ │ │   └─RightBrackets • ]
+  │ │ ├─Comma • ,
+  │ │ ├─Value
+  │ │ │ └─Number • 3
+  │ │ └─Comma • ,
+  │ └─RightBrackets • ]
+  └─EOF • 
+
+`
+	if expectedSyntaxTree != node.PrettyPrint() {
+		t.Fatalf("Unexpected syntax tree\n%s", node.PrettyPrint())
+	}
+
+	for _, input := range []string{"[]", "[1]", "[1, 2]"} {
+		if _, err := grammar.Parse("Array", input); err != nil {
+			t.Fatalf("Expected %q to be parsed, but it failed with %v", input, err)
+		}
+	}
+
+	for _, input := range []string{"[,]", "[1,,]"} {
+		if _, err := grammar.Parse("Array", input); err == nil {
+			t.Fatalf("Expected %q to fail, but it was parsed", input)
+		}
+	}
+}
+
+func TestLiteralRules(t *testing.T) {
+	grammar := Compile(`
+Object := '{' Entry[',']* as Entries '}'
+
+Entry := String ':' Value
+
+Value := Number | String | 'true' | 'false' | 'null' | Object
+
+String := /"[^"]*"/
+Number := $NumberFormat
+Space := $EmptySpaceFormat (ignore)`)
+
+	node, err := grammar.Parse("Object", `{"a": 1, "b": true}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedSyntaxTree := `Root
+  ├─Object
+  │ ├─'{' • {
+  │ ├─Entries
+  │ │ ├─Entry
+  │ │ │ ├─String • "a"
+  │ │ │ ├─':' • :
+  │ │ │ └─Value
+  │ │ │   └─Number • 1
+  │ │ ├─',' • ,
+  │ │ └─Entry
+  │ │   ├─String • "b"
+  │ │   ├─':' • :
+  │ │   └─Value
+  │ │     └─'true' • true
+  │ └─'}' • }
+  └─EOF • 
+
+`
+	if expectedSyntaxTree != node.PrettyPrint() {
+		t.Fatalf("Unexpected syntax tree\n%s", node.PrettyPrint())
+	}
+
+	if _, err := grammar.Parse("Object", `{"a": trueish}`); err == nil {
+		t.Fatal("Expected a keyword literal not to match a prefix of a longer word")
+	}
+}
+
+func TestLiteralRulesIgnoreCase(t *testing.T) {
+	grammar := Compile(`
+Query := 'select'i Name[',']+ as Columns 'from'i Name
+
+Name := /[a-z_]+/
+Space := $EmptySpaceFormat (ignore)`)
+
+	for _, input := range []string{"select a, b from t", "SELECT a FROM t", "Select a, b, c From t"} {
+		if _, err := grammar.Parse("Query", input); err != nil {
+			t.Fatalf("Expected %q to be parsed, but it failed with %v", input, err)
+		}
+	}
+
+	if _, err := grammar.Parse("Query", "selector a from t"); err == nil {
+		t.Fatal("Expected \"selector\" not to match the select keyword")
+	}
+}
+
+func TestAnonymousGroups(t *testing.T) {
+	grammar := Compile(`
+List := Value (Comma Value)* Terminator
+
+Value := (Number | Name) (Colon (Number | Name))?
+
+Terminator := Dot
+
+Comma := /,/
+Colon := /:/
+Dot := /\./
+Number := $NumberFormat
+Name := /[a-z]+/
+Space := $EmptySpaceFormat (ignore)`)
+
+	node, err := grammar.Parse("List", "a, 1: b, c.")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedSyntaxTree := `Root
+  ├─List
+  │ ├─Value
+  │ │ └─Name • a
+  │ ├─Comma • ,
+  │ ├─Value
+  │ │ ├─Number • 1
+  │ │ ├─Colon • :
+  │ │ └─Name • b
+  │ ├─Comma • ,
+  │ ├─Value
+  │ │ └─Name • c
+  │ └─Terminator • .
+  └─EOF • 
+
+`
+	if expectedSyntaxTree != node.PrettyPrint() {
+		t.Fatalf("Unexpected syntax tree\n%s", node.PrettyPrint())
+	}
+}
+
+func TestNamedGroupsInsideRepetitions(t *testing.T) {
+	grammar := Compile(`
+Pairs := (Name Colon Number as Pair)+
+
+Colon := /:/
+Number := $NumberFormat
+Name := /[a-z]+/
+Space := $EmptySpaceFormat (ignore)`)
+
+	node, err := grammar.Parse("Pairs", "a: 1 b: 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pairs := node.GetNodeWithType("Pairs").GetNodesWithType("Pair")
+	if len(pairs) != 2 {
+		t.Fatalf("Expected 2 pairs, got\n%s", node.PrettyPrint())
+	}
+}
+
+func TestNestedAnonymousGroups(t *testing.T) {
+	grammar := Compile(`
+Path := ((Name | Number) (Dot (Name | (Star Star)))*)[Slash]+
+
+Dot := /\./
+Slash := /\//
+Star := /\*/
+Number := $NumberFormat
+Name := /[a-z]+/`)
+
+	node, err := grammar.Parse("Path", "a.b/1.**")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedSyntaxTree := `Root
+  ├─Path
+  │ ├─Name • a
+  │ ├─Dot • .
+  │ ├─Name • b
+  │ ├─Slash • /
+  │ ├─Number • 1
+  │ ├─Dot • .
+  │ ├─Star • *
+  │ └─Star • *
+  └─EOF • 
+
+`
+	if expectedSyntaxTree != node.PrettyPrint() {
+		t.Fatalf("Unexpected syntax tree\n%s", node.PrettyPrint())
+	}
+}
+
+func TestTreeShapingFlags(t *testing.T) {
+	grammar := Compile(`
+Value := Object
+       | Array
+       | Number
+       | String
+       | Bool (collapse)
+
+Object := LeftBraces ObjectBody RightBraces
+
+ObjectBody := ObjectEntry[Comma]* (inline)
+
+ObjectEntry := String Colon Value
+
+Array := LeftBrackets Value[Comma]* as _ArrayBody RightBrackets
+
+LeftBraces := /\{/ (drop)
+RightBraces := /\}/ (drop)
+LeftBrackets := /\[/ (drop)
+RightBrackets := /\]/ (drop)
+Comma := /,/ (drop)
+Colon := /:/ (drop)
+Number := $NumberFormat
+Bool   := /true|false/
+String := $DoubleQuotedStringFormat
+Space := $EmptySpaceFormat (ignore)`)
+
+	node, err := grammar.Parse("Value", `{"name": "grammatic", "awesome": [true, 1]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedSyntaxTree := `Root
+  ├─Object
+  │ ├─ObjectEntry
+  │ │ ├─String • "name"
+  │ │ └─String • "grammatic"
+  │ └─ObjectEntry
+  │   ├─String • "awesome"
+  │   └─Array
+  │     ├─Bool • true
+  │     └─Number • 1
+  └─EOF • 
+
+`
+	if expectedSyntaxTree != node.PrettyPrint() {
+		t.Fatalf("Unexpected syntax tree\n%s", node.PrettyPrint())
+	}
+}
+
+func TestFlagWordsAsRuleNames(t *testing.T) {
+	grammar := Compile(`
+Command := drop inline collapse
+
+drop := /drop\b/ (drop)
+inline := /inline\b/
+collapse := /collapse\b/
+Space := $EmptySpaceFormat (ignore)`)
+
+	node, err := grammar.Parse("Command", "drop inline collapse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedSyntaxTree := `Root
+  ├─Command
+  │ ├─inline • inline
+  │ └─collapse • collapse
+  └─EOF • 
+
+`
+	if expectedSyntaxTree != node.PrettyPrint() {
+		t.Fatalf("Unexpected syntax tree\n%s", node.PrettyPrint())
+	}
+
+	// a group of a single rule is still a group, and not a flag
+	grammar = Compile(`
+Pair := Name (Name)
+
+Name := /[a-z]+/
+Space := $EmptySpaceFormat (ignore)`)
+	if _, err := grammar.Parse("Pair", "a b"); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := NewGrammar()
+	err = invalid.Load("Comma := /,/ (skip)")
+	var syntaxError *model.SyntaxError
+	if !errors.As(err, &syntaxError) || syntaxError.Token.Value != "skip" {
+		t.Fatalf("Expected an unknown token flag to be a syntax error at the flag, got %v", err)
+	}
+}
+
+func TestRuleTemplates(t *testing.T) {
+	grammar := Compile(`
+Call := Name Delimited<LParen, Expr, RParen>
+
+Expr := Call | Name | Array
+
+Array := Delimited<'[', Expr, ']'>
+
+Delimited<Open, Item, Close> := Open Item[Comma]* as Items Close
+
+LParen := /\(/
+RParen := /\)/
+Comma := /,/
+Name := /[a-z]+/
+Space := $EmptySpaceFormat (ignore)`)
+
+	node, err := grammar.Parse("Call", "f(a, [b], g())")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedSyntaxTree := `Root
+  ├─Call
+  │ ├─Name • f
+  │ └─Delimited<LParen, Expr, RParen>
+  │   ├─LParen • (
+  │   ├─Delimited<LParen, Expr, RParen>.Items
+  │   │ ├─Expr
+  │   │ │ └─Name • a
+  │   │ ├─Comma • ,
+  │   │ ├─Expr
+  │   │ │ └─Array
+  │   │ │   ├─'[' • [
+  │   │ │   ├─Delimited<'[', Expr, ']'>.Items
+  │   │ │   │ └─Expr
+  │   │ │   │   └─Name • b
+  │   │ │   └─']' • ]
+  │   │ ├─Comma • ,
+  │   │ └─Expr
+  │   │   └─Call
+  │   │     ├─Name • g
+  │   │     └─Delimited<LParen, Expr, RParen>
+  │   │       ├─LParen • (
+  │   │       ├─Delimited<LParen, Expr, RParen>.Items
+  │   │       └─RParen • )
+  │   └─RParen • )
+  └─EOF • 
+
+`
+	if expectedSyntaxTree != node.PrettyPrint() {
+		t.Fatalf("Unexpected syntax tree\n%s", node.PrettyPrint())
+	}
+}
+
+func TestNestedRuleTemplates(t *testing.T) {
+	grammar := Compile(`
+Document := List<Pair<Name, Number>>
+
+List<Item> := Item[Comma]+ (inline)
+Pair<Key, Value> := Key Colon Value
+
+Colon := /:/ (drop)
+Comma := /,/ (drop)
+Number := $NumberFormat
+Name := /[a-z]+/
+Space := $EmptySpaceFormat (ignore)`)
+
+	node, err := grammar.Parse("Document", "a: 1, b: 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedSyntaxTree := `Root
+  ├─Document
+  │ ├─Pair<Name, Number>
+  │ │ ├─Name • a
+  │ │ └─Number • 1
+  │ └─Pair<Name, Number>
+  │   ├─Name • b
+  │   └─Number • 2
+  └─EOF • 
+
+`
+	if expectedSyntaxTree != node.PrettyPrint() {
+		t.Fatalf("Unexpected syntax tree\n%s", node.PrettyPrint())
+	}
+}
+
+// Loading stays fast because the rules of the grammar language that many alternatives start with are memoized
+func BenchmarkLoadGrammar(b *testing.B) {
+	text, err := os.ReadFile("examples/calcparser/calc.grammar")
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < b.N; i++ {
+		grammar := NewGrammar()
+		if err := grammar.Load(string(text)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestAnonymousGroupIsDefinedOnce(t *testing.T) {
+	groups := func(grammar Grammar) []string {
+		names := []string{}
+		for _, name := range grammar.ruleOrder {
+			if isGroupName(name) {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+
+	grammar := Compile(`
+Pair := Value (Comma | Semicolon) Value
+
+Value := /\d+/
+Comma := /,/
+Semicolon := /;/`)
+	if names := groups(grammar); len(names) != 1 {
+		t.Fatalf("Expected the group to be defined once, got %v", names)
+	}
+
+	// the repetition and the sequence inside it are one group each
+	grammar = Compile(`
+List := Value (Comma Value)* End
+
+Value := /\d+/
+Comma := /,/
+End := /;/`)
+	if names := groups(grammar); len(names) != 2 {
+		t.Fatalf("Expected one group for the repetition and one for its sequence, got %v", names)
+	}
+}
@@ -1,7 +1,10 @@
 package grammatic
 
 import (
+	"errors"
 	"github.com/jsanchesleao/grammatic/lexer"
+	"github.com/jsanchesleao/grammatic/model"
+	"strings"
 	"testing"
 )
 
@@ -64,3 +67,136 @@ func TestGrammar(t *testing.T) {
 		t.Fatalf("Unexpected tree:\n%s", tree.PrettyPrint())
 	}
 }
+
+func TestParsePrefix(t *testing.T) {
+	g := NewGrammar()
+
+	g.DefineRule("Statement", g.Seq("Name", "Equals", "Number", "Semicolon"))
+
+	g.DefineToken("Name", lexer.KeywordFormat)
+	g.DefineToken("Number", lexer.NumberTokenFormat)
+	g.DefineToken("Equals", "^=")
+	g.DefineToken("Semicolon", "^;")
+
+	g.DefineIgnoredToken("Space", lexer.EmptySpaceFormat)
+
+	input := "x = 1; y = 2;"
+
+	node, offset, err := g.ParsePrefix("Statement", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.Type != "Statement" || node.GetNodeWithType("Name").Token.Value != "x" {
+		t.Fatalf("Unexpected tree:\n%s", node.PrettyPrint())
+	}
+	if offset != 7 {
+		t.Fatalf("Expected prefix to end at offset 7, but it was %d", offset)
+	}
+
+	node, offset, err = g.ParsePrefix("Statement", input[offset:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.GetNodeWithType("Name").Token.Value != "y" {
+		t.Fatalf("Unexpected tree:\n%s", node.PrettyPrint())
+	}
+	if offset != 6 {
+		t.Fatalf("Expected prefix to end at offset 6, but it was %d", offset)
+	}
+
+	input = "x = 1; {free text}"
+	_, offset, err = g.ParsePrefix("Statement", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if input[offset:] != "{free text}" {
+		t.Fatalf("Expected the remaining input to be the free text, but it was %q", input[offset:])
+	}
+
+	_, _, err = g.ParsePrefix("Statement", "x = ;")
+	if err == nil {
+		t.Fatalf("Expected invalid input to produce an error, but it did not")
+	}
+}
+
+func TestTreeShaping(t *testing.T) {
+
+	g := NewGrammar()
+
+	g.DefineRule("Expression", g.Or("Term", "Addition"))
+	g.DefineRule("Addition", g.Seq("Term", "Plus", "Expression"))
+	g.DefineRule("Term", g.Or("Number", "_Parens"))
+	g.DefineRule("_Parens", g.Seq("LeftParens", "Expression", "RightParens"))
+
+	g.DefineToken("Number", lexer.NumberTokenFormat)
+	g.DefineToken("LeftParens", "^\\(")
+	g.DefineToken("RightParens", "^\\)")
+	g.DefineToken("Plus", "^\\+")
+
+	g.DefineIgnoredToken("Space", lexer.EmptySpaceFormat)
+
+	g.Collapse("Expression", "Term")
+	g.Drop("Plus", "LeftParens", "RightParens")
+
+	tree, err := g.Parse("Expression", "1 + (2 + 3)")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedTree := `Root
+  ├─Addition
+  │ ├─Number • 1
+  │ └─Addition
+  │   ├─Number • 2
+  │   └─Number • 3
+  └─EOF • 
+
+`
+	if expectedTree != tree.PrettyPrint() {
+		t.Fatalf("Unexpected tree:\n%s", tree.PrettyPrint())
+	}
+}
+
+func TestTokenizeAndValidate(t *testing.T) {
+
+	g := NewGrammar()
+
+	g.DefineRule("Sum", g.Seq("Number", "Plus", "Operand"))
+	g.DefineToken("Number", lexer.NumberTokenFormat)
+	g.DefineToken("Plus", "^\\+")
+	g.DefineIgnoredToken("Space", lexer.EmptySpaceFormat)
+
+	tokens, err := g.Tokenize("1 +")
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := []string{}
+	for _, token := range tokens {
+		types = append(types, token.Type)
+	}
+	if strings.Join(types, " ") != "Number Space Plus TOKEN_EOF" {
+		t.Fatalf("Unexpected tokens %v", types)
+	}
+
+	_, err = g.Tokenize("1 ? 2")
+	var characterError *lexer.IllegalCharacterError
+	if !errors.As(err, &characterError) || characterError.Line != 1 || characterError.Col != 3 {
+		t.Fatalf("Expected an illegal character error at 1:3, got %v", err)
+	}
+
+	if err := g.Validate(); err == nil || err.Error() != "these rules are used but never defined: Operand" {
+		t.Fatalf("Expected Operand to be reported as undefined, got %v", err)
+	}
+
+	g.DefineRule("Operand", g.Rename("Number"))
+	if err := g.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = g.Parse("Sum", "1 + +")
+	var syntaxError *model.SyntaxError
+	if !errors.As(err, &syntaxError) || syntaxError.Token.Col != 5 {
+		t.Fatalf("Expected a syntax error at column 5, got %v", err)
+	}
+}
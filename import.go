@@ -0,0 +1,489 @@
+package grammatic
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// One thing of an imported grammar that could not be converted, with where it was found
+type ImportIssue struct {
+	Line    int
+	Col     int
+	Message string
+}
+
+func (i ImportIssue) String() string {
+	return fmt.Sprintf("line %d, column %d: %s", i.Line, i.Col, i.Message)
+}
+
+// Returned by ImportANTLR and ImportW3CEBNF when the grammar uses constructs that have no equivalent in grammatic,
+// with all of them in the order they were found
+type ImportError struct {
+	Issues []ImportIssue
+}
+
+func (e *ImportError) Error() string {
+	lines := []string{fmt.Sprintf("Cannot import grammar, found %d unsupported constructs:", len(e.Issues))}
+	for _, issue := range e.Issues {
+		lines = append(lines, "  "+issue.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// The kinds of the parts of an imported rule
+const (
+	importEmpty = iota
+	importReference
+	importLiteral
+	importClass
+	importAny
+	importSequence
+	importAlternatives
+	importRepeat
+)
+
+// A part of a rule read from another grammar notation. Classes keep the inside of a regular expression class in text,
+// and repetitions of lexical rules can be lazy, like .*? in ANTLR
+type importNode struct {
+	op      int
+	text    string
+	negated bool
+	items   []*importNode
+	min     int
+	max     int
+	lazy    bool
+	line    int
+	col     int
+}
+
+// The kinds of imported rules
+const (
+	importProduction = iota
+	importToken
+	importFragment
+)
+
+type importRule struct {
+	name    string
+	kind    int
+	ignored bool
+	body    *importNode
+	line    int
+	col     int
+}
+
+// Builds a grammar from the rules read by an importer. Tokens are defined in the order they were read,
+// with fragments written into the patterns of the tokens that use them
+type importBuilder struct {
+	grammar  Grammar
+	rules    map[string]*importRule
+	patterns map[string]string
+	visiting map[string]bool
+	issues   []ImportIssue
+}
+
+func (b *importBuilder) issue(line, col int, format string, args ...any) {
+	b.issues = append(b.issues, ImportIssue{Line: line, Col: col, Message: fmt.Sprintf(format, args...)})
+}
+
+// Defines the rules in the grammar, returning an ImportError with the issues found by the importer and by the conversion
+func buildImportedGrammar(rules []*importRule, virtuals []string, issues []ImportIssue) (Grammar, error) {
+	b := &importBuilder{
+		grammar:  NewGrammar(),
+		rules:    map[string]*importRule{},
+		patterns: map[string]string{},
+		visiting: map[string]bool{},
+		issues:   issues,
+	}
+	for _, rule := range rules {
+		if _, ok := b.rules[rule.name]; ok {
+			b.issue(rule.line, rule.col, "rule %s is defined more than once", rule.name)
+		}
+		b.rules[rule.name] = rule
+	}
+
+	for _, name := range virtuals {
+		if _, ok := b.rules[name]; !ok {
+			b.grammar.DefineVirtualTokenRule(name)
+		}
+	}
+	for _, rule := range rules {
+		if rule.kind == importProduction {
+			b.defineProduction(rule)
+		}
+	}
+	for _, rule := range rules {
+		if rule.kind != importToken {
+			continue
+		}
+		pattern, ok := b.pattern(rule.name)
+		if !ok {
+			continue
+		}
+		pattern = "^" + pattern
+		if rule.body.op == importLiteral {
+			// like literals, keywords don't match the start of longer words
+			pattern = escapeControls(literalPattern(rule.body.text, false))
+		}
+		if rule.ignored {
+			b.grammar.DefineIgnoredToken(rule.name, pattern)
+		} else {
+			b.grammar.DefineToken(rule.name, pattern)
+		}
+	}
+	for _, name := range leftRecursiveRules(&b.grammar) {
+		rule := b.rules[name]
+		b.issue(rule.line, rule.col, "rule %s is left-recursive, which grammatic cannot parse", name)
+	}
+
+	if len(b.issues) > 0 {
+		sort.SliceStable(b.issues, func(i, j int) bool {
+			if b.issues[i].Line != b.issues[j].Line {
+				return b.issues[i].Line < b.issues[j].Line
+			}
+			return b.issues[i].Col < b.issues[j].Col
+		})
+		return Grammar{}, &ImportError{Issues: b.issues}
+	}
+	return b.grammar, nil
+}
+
+func (b *importBuilder) defineProduction(rule *importRule) {
+	body := b.withoutEmpty(rule.body)
+	if body == nil {
+		b.issue(rule.line, rule.col, "rule %s only matches the empty input", rule.name)
+		return
+	}
+
+	var combinator GrammarCombinator
+	switch body.op {
+	case importSequence:
+		combinator = b.grammar.Seq(b.productionItems(body.items)...)
+	case importAlternatives:
+		combinator = b.grammar.Or(b.productionItems(body.items)...)
+	case importRepeat:
+		combinator = b.repeatCombinator(body)
+	default:
+		combinator = b.grammar.Rename(b.production(body))
+	}
+	b.grammar.DefineRule(rule.name, combinator)
+}
+
+// Returns the node without the empty parts of sequences, with alternatives that can be empty made optional,
+// or nil when it only matches the empty input
+func (b *importBuilder) withoutEmpty(node *importNode) *importNode {
+	switch node.op {
+	case importEmpty:
+		return nil
+	case importSequence:
+		items := []*importNode{}
+		for _, item := range node.items {
+			if item = b.withoutEmpty(item); item != nil {
+				items = append(items, item)
+			}
+		}
+		switch len(items) {
+		case 0:
+			return nil
+		case 1:
+			return items[0]
+		}
+		return &importNode{op: importSequence, items: items, line: node.line, col: node.col}
+	case importAlternatives:
+		items := []*importNode{}
+		optional := false
+		for _, item := range node.items {
+			if item = b.withoutEmpty(item); item != nil {
+				items = append(items, item)
+			} else {
+				optional = true
+			}
+		}
+		var result *importNode
+		switch len(items) {
+		case 0:
+			return nil
+		case 1:
+			result = items[0]
+		default:
+			result = &importNode{op: importAlternatives, items: items, line: node.line, col: node.col}
+		}
+		if optional {
+			result = &importNode{op: importRepeat, items: []*importNode{result}, min: 0, max: 1, line: node.line, col: node.col}
+		}
+		return result
+	case importRepeat:
+		item := b.withoutEmpty(node.items[0])
+		if item == nil {
+			return nil
+		}
+		repeat := *node
+		repeat.items = []*importNode{item}
+		return &repeat
+	}
+	return node
+}
+
+func (b *importBuilder) productionItems(nodes []*importNode) []string {
+	names := []string{}
+	for _, node := range nodes {
+		names = append(names, b.production(node))
+	}
+	return names
+}
+
+// Returns the name of the rule that matches the node in a production, defining groups and literals as needed
+func (b *importBuilder) production(node *importNode) string {
+	switch node.op {
+	case importReference:
+		rule, ok := b.rules[node.text]
+		switch {
+		case !ok && !b.grammar.isDefined(node.text):
+			b.issue(node.line, node.col, "rule %s is not defined", node.text)
+		case ok && rule.kind == importFragment:
+			b.issue(node.line, node.col, "fragment %s can only be used by lexer rules", node.text)
+		}
+		return node.text
+	case importLiteral:
+		return b.grammar.Literal(node.text)
+	case importSequence:
+		return b.grammar.defineGroup(b.grammar.Seq(b.productionItems(node.items)...))
+	case importAlternatives:
+		return b.grammar.defineGroup(b.grammar.Or(b.productionItems(node.items)...))
+	case importRepeat:
+		if node.lazy {
+			b.issue(node.line, node.col, "non-greedy repetitions are only supported in lexer rules")
+		}
+		return b.grammar.defineGroup(b.repeatCombinator(node))
+	case importClass:
+		b.issue(node.line, node.col, "character sets are only supported in lexer rules")
+	case importAny:
+		b.issue(node.line, node.col, "wildcards are only supported in lexer rules")
+	}
+	return ""
+}
+
+func (b *importBuilder) repeatCombinator(node *importNode) GrammarCombinator {
+	item := b.production(node.items[0])
+	switch {
+	case node.min == 0 && node.max == 1:
+		return b.grammar.OneOrNone(item)
+	case node.min == 0 && node.max < 0:
+		return b.grammar.Many(item)
+	case node.min == 1 && node.max < 0:
+		return b.grammar.OneOrMany(item)
+	}
+	return b.grammar.Repeat(item, node.min, node.max)
+}
+
+// Returns the regular expression of a token or fragment, without the ^ anchor
+func (b *importBuilder) pattern(name string) (string, bool) {
+	if pattern, ok := b.patterns[name]; ok {
+		return pattern, true
+	}
+	rule := b.rules[name]
+	if b.visiting[name] {
+		b.issue(rule.line, rule.col, "lexer rule %s is recursive, which regular expressions cannot match", name)
+		return "", false
+	}
+	b.visiting[name] = true
+	pattern, ok := b.regexp(rule.body)
+	delete(b.visiting, name)
+	if !ok {
+		return "", false
+	}
+	if _, err := regexp.Compile(pattern); err != nil {
+		b.issue(rule.line, rule.col, "lexer rule %s has an invalid pattern: %v", name, err)
+		return "", false
+	}
+	b.patterns[name] = pattern
+	return pattern, true
+}
+
+func (b *importBuilder) regexp(node *importNode) (string, bool) {
+	switch node.op {
+	case importEmpty:
+		return "", true
+	case importLiteral:
+		return escapeControls(regexp.QuoteMeta(node.text)), true
+	case importClass:
+		if node.negated {
+			return "[^" + node.text + "]", true
+		}
+		return "[" + node.text + "]", true
+	case importAny:
+		return "(?s:.)", true
+	case importReference:
+		rule, ok := b.rules[node.text]
+		if !ok || rule.kind == importProduction {
+			b.issue(node.line, node.col, "lexer rules can only use other lexer rules, and %s is not one", node.text)
+			return "", false
+		}
+		pattern, ok := b.pattern(node.text)
+		if !isRegexpAtom(rule.body) {
+			pattern = "(?:" + pattern + ")"
+		}
+		return pattern, ok
+	case importSequence, importAlternatives:
+		parts := []string{}
+		for _, item := range node.items {
+			part, ok := b.regexp(item)
+			if !ok {
+				return "", false
+			}
+			if node.op == importSequence && item.op == importAlternatives {
+				part = "(?:" + part + ")"
+			}
+			parts = append(parts, part)
+		}
+		if node.op == importSequence {
+			return strings.Join(parts, ""), true
+		}
+		return strings.Join(parts, "|"), true
+	case importRepeat:
+		item, ok := b.regexp(node.items[0])
+		if !ok {
+			return "", false
+		}
+		if !isRegexpAtom(node.items[0]) {
+			item = "(?:" + item + ")"
+		}
+		suffix := ""
+		switch {
+		case node.min == 0 && node.max == 1:
+			suffix = "?"
+		case node.min == 0 && node.max < 0:
+			suffix = "*"
+		case node.min == 1 && node.max < 0:
+			suffix = "+"
+		case node.max < 0:
+			suffix = fmt.Sprintf("{%d,}", node.min)
+		default:
+			suffix = fmt.Sprintf("{%d,%d}", node.min, node.max)
+		}
+		if node.lazy {
+			suffix += "?"
+		}
+		return item + suffix, true
+	}
+	return "", false
+}
+
+// Checks if the pattern of the node can be repeated without a group
+func isRegexpAtom(node *importNode) bool {
+	switch node.op {
+	case importClass, importAny, importReference:
+		// references to other lexer rules are grouped when needed
+		return true
+	case importLiteral:
+		return utf8.RuneCountInString(node.text) == 1
+	}
+	return false
+}
+
+// Returns the character as written inside a regular expression class
+func classRune(char rune) string {
+	switch {
+	case char == '_' || char == ' ' || unicode.IsLetter(char) || unicode.IsDigit(char):
+		return string(char)
+	case char < utf8.RuneSelf && (unicode.IsPunct(char) || unicode.IsSymbol(char)):
+		return "\\" + string(char)
+	case char == '\t':
+		return "\\t"
+	case char == '\n':
+		return "\\n"
+	case char == '\r':
+		return "\\r"
+	case char >= utf8.RuneSelf && unicode.IsPrint(char):
+		return string(char)
+	}
+	return fmt.Sprintf("\\x{%X}", char)
+}
+
+// Returns the production rules that can call themselves again before consuming any token,
+// which would make the parser loop forever
+func leftRecursiveRules(g *Grammar) []string {
+	nullable := map[string]bool{}
+	for changed := true; changed; {
+		changed = false
+		for name, definition := range g.definitions {
+			if !nullable[name] && g.isNullable(definition, nullable) {
+				nullable[name] = true
+				changed = true
+			}
+		}
+	}
+
+	recursive := []string{}
+	for _, name := range g.ruleOrder {
+		if isGroupName(name) {
+			continue
+		}
+		seen := map[string]bool{}
+		pending := g.leftmostRules(g.definitions[name], nullable)
+		for len(pending) > 0 {
+			next := pending[0]
+			pending = pending[1:]
+			if next == name {
+				recursive = append(recursive, name)
+				break
+			}
+			if !seen[next] {
+				seen[next] = true
+				pending = append(pending, g.leftmostRules(g.definitions[next], nullable)...)
+			}
+		}
+	}
+	return recursive
+}
+
+func (g *Grammar) isNullable(definition ruleDefinition, nullable map[string]bool) bool {
+	switch definition.kind {
+	case kindSeq:
+		for _, rule := range definition.rules {
+			if rule != CutMarker && !nullable[rule] {
+				return false
+			}
+		}
+		return true
+	case kindOr:
+		for _, rule := range definition.rules {
+			if nullable[rule] {
+				return true
+			}
+		}
+		return false
+	case kindRename, kindOneOrMany, kindOneOrManyWithSeparator:
+		return nullable[definition.rules[0]]
+	case kindOneOrNone, kindMany, kindManyWithSeparator:
+		return true
+	case kindRepeat, kindRepeatWithSeparator:
+		return definition.min == 0 || nullable[definition.rules[0]]
+	}
+	return false
+}
+
+// Returns the rules that the definition can start by matching
+func (g *Grammar) leftmostRules(definition ruleDefinition, nullable map[string]bool) []string {
+	switch definition.kind {
+	case kindSeq:
+		rules := []string{}
+		for _, rule := range definition.rules {
+			if rule == CutMarker {
+				continue
+			}
+			rules = append(rules, rule)
+			if !nullable[rule] {
+				break
+			}
+		}
+		return rules
+	case kindToken, kindVirtualToken, kindLiteral, "":
+		return nil
+	}
+	return definition.rules
+}
@@ -0,0 +1,716 @@
+package grammatic
+
+import (
+	"fmt"
+	"github.com/jsanchesleao/grammatic/model"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The kinds of tokens of ANTLR grammars
+const (
+	antlrEOF = iota
+	antlrName
+	antlrString
+	antlrSet
+	antlrAction
+	antlrPunctuation
+)
+
+type antlrToken struct {
+	kind  int
+	value string
+	line  int
+	col   int
+}
+
+var antlrPunctuations = []string{"..", "->", "+=", "::", ":", ";", "|", "(", ")", "?", "*", "+", "~", ".", "#", "=", ",", "<", ">", "@"}
+
+var antlrOption = regexp.MustCompile(`(\w+)\s*=\s*([^;]*);`)
+
+// Builds a grammar from an ANTLR 4 grammar (.g4) with parser and lexer rules.
+// Lexer rules become tokens, in the order they are written, and fragments are written into the tokens that use them.
+// Rules with the skip or channel commands become ignored tokens, literals in parser rules become literals,
+// names in the tokens block become virtual tokens and EOF at the end of a rule is left out, since Parse always matches
+// the whole input. Labels are accepted and left out, since they only name parts of the ANTLR parse tree.
+// Actions, predicates, arguments, modes, imports and left-recursive rules have no equivalent in grammatic,
+// and are returned as the issues of an ImportError, and invalid grammars return a model.SyntaxError
+func ImportANTLR(grammarText string) (Grammar, error) {
+	tokens, err := scanANTLR(grammarText)
+	if err != nil {
+		return Grammar{}, err
+	}
+	p := &antlrParser{tokens: tokens}
+	if err := p.parseGrammar(); err != nil {
+		return Grammar{}, err
+	}
+	return buildImportedGrammar(p.rules, p.virtuals, p.issues)
+}
+
+// Splits an ANTLR grammar in tokens, leaving out comments. Sets and actions are kept whole
+func scanANTLR(text string) ([]antlrToken, error) {
+	tokens := []antlrToken{}
+	line, col := 1, 1
+	index := 0
+
+	advance := func(count int) {
+		for _, char := range text[index : index+count] {
+			if char == '\n' {
+				line++
+				col = 1
+			} else {
+				col++
+			}
+		}
+		index += count
+	}
+	syntaxError := func(value string) error {
+		return &model.SyntaxError{Token: model.Token{Type: "Illegal", Value: value, Line: line, Col: col}}
+	}
+
+	for index < len(text) {
+		rest := text[index:]
+		char, size := utf8.DecodeRuneInString(rest)
+		start := antlrToken{line: line, col: col}
+
+		switch {
+		case unicode.IsSpace(char):
+			advance(size)
+			continue
+		case strings.HasPrefix(rest, "//"):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			advance(end)
+			continue
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				return nil, syntaxError("/*")
+			}
+			advance(end + 4)
+			continue
+		case char == '_' || unicode.IsLetter(char):
+			end := strings.IndexFunc(rest, func(char rune) bool {
+				return char != '_' && !unicode.IsLetter(char) && !unicode.IsDigit(char)
+			})
+			if end < 0 {
+				end = len(rest)
+			}
+			start.kind, start.value = antlrName, rest[:end]
+		case char == '\'' || char == '[':
+			closing := byte('\'')
+			kind := antlrString
+			if char == '[' {
+				closing, kind = ']', antlrSet
+			}
+			end := 1
+			for end < len(rest) && rest[end] != closing && rest[end] != '\n' {
+				if rest[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(rest) || rest[end] != closing {
+				return nil, syntaxError(rest[:1])
+			}
+			start.kind, start.value = kind, rest[:end+1]
+		case char == '{':
+			end, ok := actionEnd(rest)
+			if !ok {
+				return nil, syntaxError("{")
+			}
+			start.kind, start.value = antlrAction, rest[:end]
+		default:
+			for _, punctuation := range antlrPunctuations {
+				if strings.HasPrefix(rest, punctuation) {
+					start.kind, start.value = antlrPunctuation, punctuation
+					break
+				}
+			}
+			if start.value == "" {
+				return nil, syntaxError(string(char))
+			}
+		}
+
+		advance(len(start.value))
+		tokens = append(tokens, start)
+	}
+	return append(tokens, antlrToken{kind: antlrEOF, line: line, col: col}), nil
+}
+
+// Returns the length of the action at the start of the text, with its nested braces and quoted strings
+func actionEnd(text string) (int, bool) {
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1, true
+			}
+		case '"', '\'':
+			quote := text[i]
+			for i++; i < len(text) && text[i] != quote; i++ {
+				if text[i] == '\\' {
+					i++
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
+type antlrParser struct {
+	tokens   []antlrToken
+	position int
+	rules    []*importRule
+	virtuals []string
+	issues   []ImportIssue
+	mode     bool
+}
+
+func (p *antlrParser) peek() antlrToken {
+	return p.tokens[p.position]
+}
+
+func (p *antlrParser) next() antlrToken {
+	token := p.tokens[p.position]
+	if token.kind != antlrEOF {
+		p.position++
+	}
+	return token
+}
+
+// Checks if the next token is the punctuation or name, consuming it when it is
+func (p *antlrParser) accept(value string) bool {
+	token := p.peek()
+	if (token.kind == antlrPunctuation || token.kind == antlrName) && token.value == value {
+		p.position++
+		return true
+	}
+	return false
+}
+
+func (p *antlrParser) expect(value string) error {
+	if !p.accept(value) {
+		return p.unexpected()
+	}
+	return nil
+}
+
+func (p *antlrParser) expectName() (antlrToken, error) {
+	token := p.next()
+	if token.kind != antlrName {
+		return token, p.unexpectedToken(token)
+	}
+	return token, nil
+}
+
+func (p *antlrParser) unexpected() error {
+	return p.unexpectedToken(p.peek())
+}
+
+func (p *antlrParser) unexpectedToken(token antlrToken) error {
+	tokenType := "Name"
+	if token.kind == antlrEOF {
+		tokenType = "TOKEN_EOF"
+	}
+	return &model.SyntaxError{Token: model.Token{Type: tokenType, Value: token.value, Line: token.line, Col: token.col}}
+}
+
+func (p *antlrParser) issue(token antlrToken, format string, args ...any) {
+	p.issues = append(p.issues, ImportIssue{Line: token.line, Col: token.col, Message: fmt.Sprintf(format, args...)})
+}
+
+func (p *antlrParser) parseGrammar() error {
+	p.accept("lexer")
+	p.accept("parser")
+	if err := p.expect("grammar"); err != nil {
+		return err
+	}
+	if _, err := p.expectName(); err != nil {
+		return err
+	}
+	if err := p.expect(";"); err != nil {
+		return err
+	}
+
+	for p.peek().kind != antlrEOF {
+		token := p.peek()
+		switch {
+		case p.accept("options"):
+			if err := p.parseOptions(); err != nil {
+				return err
+			}
+		case p.accept("tokens"):
+			action := p.next()
+			if action.kind != antlrAction {
+				return p.unexpectedToken(action)
+			}
+			for _, name := range strings.Split(strings.Trim(action.value, "{}"), ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					p.virtuals = append(p.virtuals, name)
+				}
+			}
+		case p.accept("channels"):
+			if action := p.next(); action.kind != antlrAction {
+				return p.unexpectedToken(action)
+			}
+		case p.accept("import"):
+			p.issue(token, "imports of other grammars are not supported")
+			if err := p.skipTo(";"); err != nil {
+				return err
+			}
+		case p.accept("mode"):
+			if !p.mode {
+				p.issue(token, "lexer modes are not supported")
+			}
+			p.mode = true
+			if err := p.skipTo(";"); err != nil {
+				return err
+			}
+		case p.accept("@"):
+			p.issue(token, "actions are not supported")
+			if err := p.skipAction(); err != nil {
+				return err
+			}
+		default:
+			if err := p.parseRule(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (p *antlrParser) skipTo(value string) error {
+	for !p.accept(value) {
+		if p.next().kind == antlrEOF {
+			return p.unexpected()
+		}
+	}
+	return nil
+}
+
+func (p *antlrParser) skipSet() {
+	if p.peek().kind == antlrSet {
+		p.next()
+	}
+}
+
+// Skips a named action, like @header { ... } or @lexer::members { ... }
+func (p *antlrParser) skipAction() error {
+	for {
+		token := p.next()
+		switch token.kind {
+		case antlrAction:
+			return nil
+		case antlrEOF:
+			return p.unexpectedToken(token)
+		}
+	}
+}
+
+// Reads an options block. Only the options that change the language matched by the grammar are reported
+func (p *antlrParser) parseOptions() error {
+	action := p.next()
+	if action.kind != antlrAction {
+		return p.unexpectedToken(action)
+	}
+	for _, option := range antlrOption.FindAllStringSubmatch(action.value, -1) {
+		switch option[1] {
+		case "caseInsensitive":
+			if strings.TrimSpace(option[2]) == "true" {
+				p.issue(action, "the caseInsensitive option is not supported")
+			}
+		case "tokenVocab":
+			p.issue(action, "the tokenVocab option is not supported, since it needs another grammar")
+		}
+	}
+	return nil
+}
+
+func (p *antlrParser) parseRule() error {
+	fragment := p.accept("fragment")
+	name, err := p.expectName()
+	if err != nil {
+		return err
+	}
+	rule := &importRule{name: name.value, line: name.line, col: name.col, kind: importProduction}
+	if unicode.IsUpper([]rune(name.value)[0]) {
+		rule.kind = importToken
+		if fragment {
+			rule.kind = importFragment
+		}
+	}
+
+	// arguments, return values and other parts that come before the colon of parser rules
+	for p.peek().value != ":" {
+		token := p.next()
+		switch {
+		case token.kind == antlrEOF:
+			return p.unexpectedToken(token)
+		case token.kind == antlrSet:
+			p.issue(token, "rule arguments are not supported")
+		case token.value == "returns":
+			p.issue(token, "return values are not supported")
+			p.skipSet()
+		case token.value == "locals":
+			p.issue(token, "local variables are not supported")
+			p.skipSet()
+		case token.value == "options":
+			if err := p.parseOptions(); err != nil {
+				return err
+			}
+		case token.value == "@":
+			p.issue(token, "actions are not supported")
+			if err := p.skipAction(); err != nil {
+				return err
+			}
+		}
+	}
+	p.next()
+
+	body, err := p.parseAlternatives(rule, true)
+	if err != nil {
+		return err
+	}
+	rule.body = body
+	if err := p.expect(";"); err != nil {
+		return err
+	}
+
+	for p.peek().value == "catch" || p.peek().value == "finally" {
+		p.issue(p.peek(), "exception handlers are not supported")
+		if err := p.skipAction(); err != nil {
+			return err
+		}
+	}
+
+	if !p.mode {
+		p.rules = append(p.rules, rule)
+	}
+	return nil
+}
+
+func (p *antlrParser) parseAlternatives(rule *importRule, top bool) (*importNode, error) {
+	start := p.peek()
+	alternatives := []*importNode{}
+	for {
+		alternative, err := p.parseAlternative(rule, top)
+		if err != nil {
+			return nil, err
+		}
+		alternatives = append(alternatives, alternative)
+		if !p.accept("|") {
+			break
+		}
+	}
+	if len(alternatives) == 1 {
+		return alternatives[0], nil
+	}
+	return &importNode{op: importAlternatives, items: alternatives, line: start.line, col: start.col}, nil
+}
+
+func (p *antlrParser) parseAlternative(rule *importRule, top bool) (*importNode, error) {
+	start := p.peek()
+	items := []*importNode{}
+	if p.peek().value == "<" {
+		p.issue(p.peek(), "element options are not supported")
+		if err := p.skipTo(">"); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		token := p.peek()
+		switch {
+		case token.kind == antlrPunctuation && (token.value == "|" || token.value == ";" || token.value == ")"):
+			return sequenceNode(items, start.line, start.col), nil
+
+		case token.value == "#" && token.kind == antlrPunctuation:
+			p.next()
+			if _, err := p.expectName(); err != nil {
+				return nil, err
+			}
+
+		case token.value == "->" && token.kind == antlrPunctuation:
+			p.next()
+			if err := p.parseCommands(rule, top); err != nil {
+				return nil, err
+			}
+
+		case token.kind == antlrAction:
+			p.next()
+			if p.accept("?") {
+				p.issue(token, "semantic predicates are not supported")
+			} else {
+				p.issue(token, "actions are not supported")
+			}
+
+		default:
+			if token.value == "EOF" && token.kind == antlrName && rule.kind == importProduction {
+				p.next()
+				if next := p.peek(); !top || !(next.value == "|" || next.value == ";") {
+					p.issue(token, "EOF is only supported at the end of a rule")
+				}
+				continue
+			}
+			element, err := p.parseElement(rule)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, element)
+		}
+	}
+}
+
+func sequenceNode(items []*importNode, line, col int) *importNode {
+	switch len(items) {
+	case 0:
+		return &importNode{op: importEmpty, line: line, col: col}
+	case 1:
+		return items[0]
+	}
+	return &importNode{op: importSequence, items: items, line: line, col: col}
+}
+
+// Reads the commands of a lexer rule, after ->
+func (p *antlrParser) parseCommands(rule *importRule, top bool) error {
+	for {
+		command, err := p.expectName()
+		if err != nil {
+			return err
+		}
+		if p.accept("(") {
+			if err := p.skipTo(")"); err != nil {
+				return err
+			}
+		}
+		switch {
+		case !top || rule.kind != importToken:
+			p.issue(command, "lexer commands are only supported at the end of lexer rules")
+		case command.value == "skip" || command.value == "channel":
+			rule.ignored = true
+		default:
+			p.issue(command, "the lexer command %s is not supported", command.value)
+		}
+		if !p.accept(",") {
+			return nil
+		}
+	}
+}
+
+func (p *antlrParser) parseElement(rule *importRule) (*importNode, error) {
+	// labels like name=ID and items+=item only name parts of the tree, so they are left out
+	if p.peek().kind == antlrName && (p.tokens[p.position+1].value == "=" || p.tokens[p.position+1].value == "+=") {
+		p.position += 2
+	}
+
+	atom, err := p.parseAtom(rule)
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().value == "<" {
+		p.issue(p.peek(), "element options are not supported")
+		if err := p.skipTo(">"); err != nil {
+			return nil, err
+		}
+	}
+
+	suffix := p.peek()
+	repeat := &importNode{op: importRepeat, items: []*importNode{atom}, line: suffix.line, col: suffix.col}
+	switch {
+	case p.accept("?"):
+		repeat.min, repeat.max = 0, 1
+	case p.accept("*"):
+		repeat.min, repeat.max = 0, -1
+	case p.accept("+"):
+		repeat.min, repeat.max = 1, -1
+	default:
+		return atom, nil
+	}
+	repeat.lazy = p.accept("?")
+	return repeat, nil
+}
+
+func (p *antlrParser) parseAtom(rule *importRule) (*importNode, error) {
+	token := p.next()
+	node := &importNode{line: token.line, col: token.col}
+
+	switch {
+	case token.kind == antlrName:
+		node.op, node.text = importReference, token.value
+
+	case token.kind == antlrString:
+		value, err := antlrUnquote(token.value)
+		if err != nil {
+			return nil, p.unexpectedToken(token)
+		}
+		node.op, node.text = importLiteral, value
+		if p.accept("..") {
+			last := p.next()
+			to, err := antlrUnquote(last.value)
+			if last.kind != antlrString || err != nil || utf8.RuneCountInString(value) != 1 || utf8.RuneCountInString(to) != 1 {
+				return nil, p.unexpectedToken(last)
+			}
+			from, _ := utf8.DecodeRuneInString(value)
+			until, _ := utf8.DecodeRuneInString(to)
+			node.op, node.text = importClass, classRune(from)+"-"+classRune(until)
+		}
+
+	case token.kind == antlrSet:
+		text, err := antlrSetClass(token.value)
+		if err != nil {
+			return nil, p.unexpectedToken(token)
+		}
+		node.op, node.text = importClass, text
+
+	case token.value == ".":
+		node.op = importAny
+
+	case token.value == "~":
+		operand, err := p.parseAtom(rule)
+		if err != nil {
+			return nil, err
+		}
+		text, ok := negatedClass(operand)
+		if !ok {
+			p.issue(token, "~ is only supported before characters, ranges and sets")
+		}
+		node.op, node.text, node.negated = importClass, text, true
+
+	case token.value == "(":
+		group, err := p.parseAlternatives(rule, false)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return group, nil
+
+	default:
+		return nil, p.unexpectedToken(token)
+	}
+	return node, nil
+}
+
+// Returns the inside of the class that matches the same characters as the node, for ~
+func negatedClass(node *importNode) (string, bool) {
+	switch node.op {
+	case importClass:
+		return node.text, !node.negated
+	case importLiteral:
+		char, size := utf8.DecodeRuneInString(node.text)
+		return classRune(char), size == len(node.text) && size > 0
+	case importAlternatives:
+		text := ""
+		for _, item := range node.items {
+			itemText, ok := negatedClass(item)
+			if !ok {
+				return "", false
+			}
+			text += itemText
+		}
+		return text, true
+	}
+	return "", false
+}
+
+// Returns the value of an ANTLR string literal, with its escapes replaced
+func antlrUnquote(literal string) (string, error) {
+	value := strings.Builder{}
+	text := literal[1 : len(literal)-1]
+	for len(text) > 0 {
+		char, size, err := antlrEscape(text)
+		if err != nil {
+			return "", err
+		}
+		value.WriteString(char)
+		text = text[size:]
+	}
+	return value.String(), nil
+}
+
+// Returns the character at the start of the text, reading escapes like \n and \u00E9, and the length it was written with
+func antlrEscape(text string) (string, int, error) {
+	if text[0] != '\\' {
+		_, size := utf8.DecodeRuneInString(text)
+		return text[:size], size, nil
+	}
+	if len(text) < 2 {
+		return "", 0, fmt.Errorf("invalid escape at the end of %q", text)
+	}
+	switch text[1] {
+	case 'n':
+		return "\n", 2, nil
+	case 'r':
+		return "\r", 2, nil
+	case 't':
+		return "\t", 2, nil
+	case 'b':
+		return "\b", 2, nil
+	case 'f':
+		return "\f", 2, nil
+	case 'u':
+		digits, size := "", 0
+		if strings.HasPrefix(text, "\\u{") {
+			end := strings.IndexByte(text, '}')
+			if end < 0 {
+				return "", 0, fmt.Errorf("invalid escape in %q", text)
+			}
+			digits, size = text[3:end], end+1
+		} else if len(text) >= 6 {
+			digits, size = text[2:6], 6
+		}
+		code, err := strconv.ParseUint(digits, 16, 32)
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid escape in %q", text)
+		}
+		return string(rune(code)), size, nil
+	}
+	_, size := utf8.DecodeRuneInString(text[1:])
+	return text[1 : 1+size], 1 + size, nil
+}
+
+// Returns the inside of the regular expression class for an ANTLR set like [a-z_À-ÿ]
+func antlrSetClass(set string) (string, error) {
+	class := strings.Builder{}
+	text := set[1 : len(set)-1]
+	for len(text) > 0 {
+		if strings.HasPrefix(text, "\\p{") || strings.HasPrefix(text, "\\P{") {
+			end := strings.IndexByte(text, '}')
+			if end < 0 {
+				return "", fmt.Errorf("invalid property in %q", set)
+			}
+			class.WriteString(text[:end+1])
+			text = text[end+1:]
+			continue
+		}
+		char, size, err := antlrEscape(text)
+		if err != nil {
+			return "", err
+		}
+		first, _ := utf8.DecodeRuneInString(char)
+		text = text[size:]
+		class.WriteString(classRune(first))
+		if len(text) > 1 && text[0] == '-' {
+			char, size, err := antlrEscape(text[1:])
+			if err != nil {
+				return "", err
+			}
+			last, _ := utf8.DecodeRuneInString(char)
+			text = text[1+size:]
+			class.WriteString("-" + classRune(last))
+		}
+	}
+	return class.String(), nil
+}
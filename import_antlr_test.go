@@ -0,0 +1,165 @@
+package grammatic
+
+import (
+	"errors"
+	"github.com/jsanchesleao/grammatic/model"
+	"strings"
+	"testing"
+)
+
+const antlrGrammar = `
+grammar Expr;
+options { language = Java; }
+tokens { INDENT }
+
+/** a program is a list of statements */
+prog : stat+ EOF ;
+stat : expr ';'               # exprStat
+     | ID '=' value=expr ';'  # assign
+     | ';'
+     ;
+expr : term (('+'|'-') term)* ;
+term : factor (('*'|'/') factor)* ;
+factor : INT | ID | '(' expr ')' | STRING | 'if'? INDENT ;
+
+IF : 'if' ;
+ID : [a-zA-Z_] [a-zA-Z_0-9]* ;
+INT : DIGIT+ ;
+STRING : '"' (ESC | ~["\\])* '"' ;
+fragment ESC : '\\' [btnr"\\] ;
+fragment DIGIT : '0'..'9' ;
+COMMENT : '/*' .*? '*/' -> skip ;
+WS : [ \t\r\n]+ -> channel(HIDDEN) ;
+`
+
+func TestImportANTLR(t *testing.T) {
+	grammar, err := ImportANTLR(antlrGrammar)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `prog := stat+
+
+stat := (expr ';') | (ID '=' expr ';') | ';'
+
+expr := term (('+' | '-') term)*
+
+term := factor (('*' | '/') factor)*
+
+factor := INT | ID | ('(' expr ')') | STRING | ('if'? INDENT)
+
+IF      := /if\b/
+ID      := /[a-zA-Z_][a-zA-Z_0-9]*/
+INT     := /[0-9]+/
+STRING  := /"(?:(?:\\[btnr\"\\])|[^\"\\])*"/
+COMMENT := /\/\*(?s:.)*?\*\// (ignore)
+WS      := /[ \t\r\n]+/ (ignore)
+
+:virtual: INDENT
+`
+	if grammar.String() != expected {
+		t.Fatalf("Expected the grammar\n%s\ngot\n%s", expected, grammar.String())
+	}
+
+	node, err := grammar.Parse("prog", "x = 1 + \"a\\\"b\" * (2 - y); /* comment */ iffy;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	statements := node.GetNodeWithType("prog").GetNodesWithType("stat")
+	if len(statements) != 2 || statements[1].GetNodeWithType("expr").GetNodeWithType("term").GetNodeWithType("factor").GetNodeWithType("ID") == nil {
+		t.Fatalf("Expected two statements, the last one with the name iffy, got %v", node)
+	}
+}
+
+func TestImportANTLRControlCharacters(t *testing.T) {
+	grammar, err := ImportANTLR(`
+grammar Lines;
+lines : (WORD NEWLINE)+ ;
+WORD : [a-z]+ ;
+NEWLINE : '\r'? '\n' ;
+TAB : '\t' -> skip ;
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `lines := (WORD NEWLINE)+
+
+WORD    := /[a-z]+/
+NEWLINE := /\r?\n/
+TAB     := /\t/ (ignore)
+`
+	text := grammar.String()
+	if text != expected {
+		t.Fatalf("Expected the grammar\n%s\ngot\n%s", expected, text)
+	}
+	if pattern := grammar.definitions["NEWLINE"].pattern; pattern != `^\r?\n` {
+		t.Fatalf("Expected the control characters to be escaped in the pattern, got %q", pattern)
+	}
+
+	loaded := NewGrammar()
+	if err := loaded.Load(text); err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range []Grammar{grammar, loaded} {
+		node, err := g.Parse("lines", "one\r\n\ttwo\n")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if words := node.GetNodeWithType("lines").GetNodesWithType("WORD"); len(words) != 2 {
+			t.Fatalf("Expected two words, got\n%s", node.PrettyPrint())
+		}
+	}
+}
+
+func TestImportANTLRUnsupported(t *testing.T) {
+	_, err := ImportANTLR(`grammar Bad;
+@header { package bad; }
+e : e '+' e | INT {count++;} | {enabled}? INT ;
+s[int a] returns [int b] : <assoc=right> INT? EOF INT ;
+p : INT ~';' .*? ;
+INT : [0-9]+ -> type(NUMBER) ;
+A : 'a' A? ;
+mode Inside;
+B : 'b' ;
+`)
+	var importError *ImportError
+	if !errors.As(err, &importError) {
+		t.Fatalf("Expected an ImportError, got %v", err)
+	}
+
+	expected := []string{
+		"line 2, column 1: actions are not supported",
+		"line 3, column 1: rule e is left-recursive, which grammatic cannot parse",
+		"line 3, column 19: actions are not supported",
+		"line 3, column 32: semantic predicates are not supported",
+		"line 4, column 2: rule arguments are not supported",
+		"line 4, column 10: return values are not supported",
+		"line 4, column 28: element options are not supported",
+		"line 4, column 47: EOF is only supported at the end of a rule",
+		"line 5, column 9: character sets are only supported in lexer rules",
+		"line 5, column 14: wildcards are only supported in lexer rules",
+		"line 5, column 15: non-greedy repetitions are only supported in lexer rules",
+		"line 6, column 17: the lexer command type is not supported",
+		"line 7, column 1: lexer rule A is recursive, which regular expressions cannot match",
+		"line 8, column 1: lexer modes are not supported",
+	}
+	issues := []string{}
+	for _, issue := range importError.Issues {
+		issues = append(issues, issue.String())
+	}
+	if strings.Join(issues, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected the issues\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(issues, "\n"))
+	}
+	if !strings.HasPrefix(err.Error(), "Cannot import grammar, found 14 unsupported constructs:\n  line 2, column 1: ") {
+		t.Fatalf("Expected the issues in the error message, got %q", err.Error())
+	}
+}
+
+func TestImportANTLRSyntaxError(t *testing.T) {
+	_, err := ImportANTLR("grammar Broken;\nrule : 'a' ")
+	var syntaxError *model.SyntaxError
+	if !errors.As(err, &syntaxError) || syntaxError.Token.Line != 2 || syntaxError.Token.Type != "TOKEN_EOF" {
+		t.Fatalf("Expected a syntax error at the end of the input, got %v", err)
+	}
+}
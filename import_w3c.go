@@ -0,0 +1,426 @@
+package grammatic
+
+import (
+	"github.com/jsanchesleao/grammatic/model"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Builds a grammar from a grammar in the EBNF notation of the W3C, used by the XML specification.
+// Rules can be numbered like [1] and the notation has no tokens, so the rules that only use strings, characters
+// and other such rules become tokens when they are used by the other rules, and are otherwise written
+// into the tokens that use them. The remaining rules, and the ones that use themselves, become production rules.
+// Exceptions like A - B and constraints like [ wfc: ... ] have no equivalent in grammatic,
+// and are returned as the issues of an ImportError, and invalid grammars return a model.SyntaxError
+func ImportW3CEBNF(grammarText string) (Grammar, error) {
+	p := &w3cParser{text: grammarText, line: 1, col: 1}
+	rules := []*importRule{}
+	for {
+		p.skipSpace()
+		if p.index >= len(p.text) {
+			break
+		}
+		rule, err := p.parseRule()
+		if err != nil {
+			return Grammar{}, err
+		}
+		rules = append(rules, rule)
+	}
+	classifyW3CRules(rules)
+	return buildImportedGrammar(rules, nil, p.issues)
+}
+
+type w3cParser struct {
+	text   string
+	index  int
+	line   int
+	col    int
+	issues []ImportIssue
+}
+
+func (p *w3cParser) advance(count int) {
+	for _, char := range p.text[p.index : p.index+count] {
+		if char == '\n' {
+			p.line++
+			p.col = 1
+		} else {
+			p.col++
+		}
+	}
+	p.index += count
+}
+
+func (p *w3cParser) rest() string {
+	return p.text[p.index:]
+}
+
+// Skips spaces and /* comments */
+func (p *w3cParser) skipSpace() {
+	for p.index < len(p.text) {
+		rest := p.rest()
+		if strings.HasPrefix(rest, "/*") {
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				p.advance(len(rest))
+				return
+			}
+			p.advance(end + 4)
+			continue
+		}
+		char, size := utf8.DecodeRuneInString(rest)
+		if !unicode.IsSpace(char) {
+			return
+		}
+		p.advance(size)
+	}
+}
+
+func (p *w3cParser) unexpected() error {
+	value := ""
+	tokenType := "TOKEN_EOF"
+	if p.index < len(p.text) {
+		_, size := utf8.DecodeRuneInString(p.rest())
+		value, tokenType = p.rest()[:size], "Illegal"
+	}
+	return &model.SyntaxError{Token: model.Token{Type: tokenType, Value: value, Line: p.line, Col: p.col}}
+}
+
+// Matches the start of the constraints of the XML specification, like [ wfc: Unique Att Spec ] or [ VC: ID ]
+var w3cConstraint = regexp.MustCompile(`^\[\s*(?i:wfc|vc):`)
+
+func isW3CNameChar(char rune) bool {
+	return char == '_' || char == '-' || char == '.' || char == ':' || unicode.IsLetter(char) || unicode.IsDigit(char)
+}
+
+func (p *w3cParser) name() string {
+	end := strings.IndexFunc(p.rest(), func(char rune) bool {
+		return !isW3CNameChar(char)
+	})
+	if end < 0 {
+		end = len(p.rest())
+	}
+	name := p.rest()[:end]
+	if first, _ := utf8.DecodeRuneInString(name); first == '-' || first == '.' || unicode.IsDigit(first) {
+		return ""
+	}
+	return name
+}
+
+// Checks if a rule starts at the current position, like Name ::= or [12] Name ::=
+func (p *w3cParser) atRuleStart() bool {
+	saved := *p
+	defer func() { *p = saved }()
+
+	if strings.HasPrefix(p.rest(), "[") {
+		end := strings.IndexByte(p.rest(), ']')
+		if end < 0 {
+			return false
+		}
+		if _, err := strconv.Atoi(strings.TrimSpace(p.rest()[1:end])); err != nil {
+			return false
+		}
+		p.advance(end + 1)
+		p.skipSpace()
+	}
+	name := p.name()
+	if name == "" {
+		return false
+	}
+	p.advance(len(name))
+	p.skipSpace()
+	return strings.HasPrefix(p.rest(), "::=")
+}
+
+func (p *w3cParser) parseRule() (*importRule, error) {
+	if strings.HasPrefix(p.rest(), "[") {
+		end := strings.IndexByte(p.rest(), ']')
+		if end < 0 {
+			return nil, p.unexpected()
+		}
+		p.advance(end + 1)
+		p.skipSpace()
+	}
+
+	rule := &importRule{name: p.name(), line: p.line, col: p.col}
+	if rule.name == "" {
+		return nil, p.unexpected()
+	}
+	p.advance(len(rule.name))
+	p.skipSpace()
+	if !strings.HasPrefix(p.rest(), "::=") {
+		return nil, p.unexpected()
+	}
+	p.advance(3)
+
+	body, err := p.parseAlternatives()
+	if err != nil {
+		return nil, err
+	}
+	rule.body = body
+
+	// constraints of the XML specification, like [ wfc: Unique Att Spec ], follow the rule
+	for p.skipSpace(); w3cConstraint.MatchString(p.rest()); p.skipSpace() {
+		end := strings.IndexByte(p.rest(), ']')
+		if end < 0 {
+			return nil, p.unexpected()
+		}
+		p.issues = append(p.issues, ImportIssue{Line: p.line, Col: p.col, Message: "constraints like " + p.rest()[:end+1] + " are not supported"})
+		p.advance(end + 1)
+	}
+	return rule, nil
+}
+
+func (p *w3cParser) parseAlternatives() (*importNode, error) {
+	line, col := p.line, p.col
+	alternatives := []*importNode{}
+	for {
+		alternative, err := p.parseSequence()
+		if err != nil {
+			return nil, err
+		}
+		alternatives = append(alternatives, alternative)
+		p.skipSpace()
+		if !strings.HasPrefix(p.rest(), "|") {
+			break
+		}
+		p.advance(1)
+	}
+	if len(alternatives) == 1 {
+		return alternatives[0], nil
+	}
+	return &importNode{op: importAlternatives, items: alternatives, line: line, col: col}, nil
+}
+
+func (p *w3cParser) parseSequence() (*importNode, error) {
+	line, col := p.line, p.col
+	items := []*importNode{}
+	for {
+		p.skipSpace()
+		if p.index >= len(p.text) || strings.ContainsAny(p.rest()[:1], "|)") || p.atRuleStart() {
+			return sequenceNode(items, line, col), nil
+		}
+		if w3cConstraint.MatchString(p.rest()) {
+			return sequenceNode(items, line, col), nil
+		}
+
+		item, err := p.parseItem()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if strings.HasPrefix(p.rest(), "-") {
+			p.issues = append(p.issues, ImportIssue{Line: p.line, Col: p.col, Message: "exceptions like A - B are not supported"})
+			p.advance(1)
+			if _, err := p.parseItem(); err != nil {
+				return nil, err
+			}
+		}
+		items = append(items, item)
+	}
+}
+
+func (p *w3cParser) parseItem() (*importNode, error) {
+	p.skipSpace()
+	item, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for len(p.rest()) > 0 && strings.ContainsAny(p.rest()[:1], "?*+") {
+		repeat := &importNode{op: importRepeat, items: []*importNode{item}, line: p.line, col: p.col}
+		switch p.rest()[0] {
+		case '?':
+			repeat.min, repeat.max = 0, 1
+		case '*':
+			repeat.min, repeat.max = 0, -1
+		case '+':
+			repeat.min, repeat.max = 1, -1
+		}
+		p.advance(1)
+		item = repeat
+	}
+	return item, nil
+}
+
+func (p *w3cParser) parsePrimary() (*importNode, error) {
+	node := &importNode{line: p.line, col: p.col}
+	rest := p.rest()
+	if rest == "" {
+		return nil, p.unexpected()
+	}
+
+	switch {
+	case rest[0] == '(':
+		p.advance(1)
+		group, err := p.parseAlternatives()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if !strings.HasPrefix(p.rest(), ")") {
+			return nil, p.unexpected()
+		}
+		p.advance(1)
+		return group, nil
+
+	case rest[0] == '"' || rest[0] == '\'':
+		end := strings.IndexByte(rest[1:], rest[0])
+		if end < 0 {
+			return nil, p.unexpected()
+		}
+		node.op, node.text = importLiteral, rest[1:end+1]
+		p.advance(end + 2)
+
+	case strings.HasPrefix(rest, "#x"):
+		char, size, ok := w3cChar(rest)
+		if !ok {
+			return nil, p.unexpected()
+		}
+		node.op, node.text = importLiteral, string(char)
+		p.advance(size)
+
+	case rest[0] == '[':
+		text, negated, size, ok := w3cClass(rest)
+		if !ok {
+			return nil, p.unexpected()
+		}
+		node.op, node.text, node.negated = importClass, text, negated
+		p.advance(size)
+
+	default:
+		name := p.name()
+		if name == "" {
+			return nil, p.unexpected()
+		}
+		node.op, node.text = importReference, name
+		p.advance(len(name))
+	}
+	return node, nil
+}
+
+// Reads a character written like #x20 at the start of the text, returning the length it was written with
+func w3cChar(text string) (rune, int, bool) {
+	end := 2
+	for end < len(text) && strings.IndexByte("0123456789abcdefABCDEF", text[end]) >= 0 {
+		end++
+	}
+	code, err := strconv.ParseUint(text[2:end], 16, 32)
+	if err != nil {
+		return 0, 0, false
+	}
+	return rune(code), end, true
+}
+
+// Reads a class like [a-zA-Z] or [^#x0-#x1F] at the start of the text, returning the inside of the regular expression class
+func w3cClass(text string) (string, bool, int, bool) {
+	class := strings.Builder{}
+	index := 1
+	negated := strings.HasPrefix(text, "[^")
+	if negated {
+		index++
+	}
+
+	readChar := func() (rune, bool) {
+		if strings.HasPrefix(text[index:], "#x") {
+			char, size, ok := w3cChar(text[index:])
+			index += size
+			return char, ok
+		}
+		char, size := utf8.DecodeRuneInString(text[index:])
+		index += size
+		return char, char != utf8.RuneError
+	}
+
+	for index < len(text) && text[index] != ']' {
+		first, ok := readChar()
+		if !ok {
+			return "", false, 0, false
+		}
+		class.WriteString(classRune(first))
+		if index+1 < len(text) && text[index] == '-' && text[index+1] != ']' {
+			index++
+			last, ok := readChar()
+			if !ok {
+				return "", false, 0, false
+			}
+			class.WriteString("-" + classRune(last))
+		}
+	}
+	if index >= len(text) {
+		return "", false, 0, false
+	}
+	return class.String(), negated, index + 1, true
+}
+
+// Decides which rules become tokens. Rules that only use terminals and other such rules, without using themselves,
+// are lexical: they become tokens when a production uses them or no rule does, and are otherwise fragments
+func classifyW3CRules(rules []*importRule) {
+	byName := map[string]*importRule{}
+	for _, rule := range rules {
+		byName[rule.name] = rule
+	}
+
+	lexical := map[string]bool{}
+	decided := map[string]bool{}
+	var isLexical func(name string, visiting map[string]bool) bool
+	isLexical = func(name string, visiting map[string]bool) bool {
+		if decided[name] {
+			return lexical[name]
+		}
+		rule, ok := byName[name]
+		if !ok || visiting[name] {
+			return false
+		}
+		visiting[name] = true
+		result := true
+		for _, reference := range importReferences(rule.body) {
+			if !isLexical(reference, visiting) {
+				result = false
+			}
+		}
+		delete(visiting, name)
+		if len(visiting) == 0 || !result {
+			decided[name] = true
+			lexical[name] = result
+		}
+		return result
+	}
+
+	used := map[string]bool{}
+	for _, rule := range rules {
+		if !isLexical(rule.name, map[string]bool{}) {
+			rule.kind = importProduction
+			for _, reference := range importReferences(rule.body) {
+				used[reference] = true
+			}
+		}
+	}
+	referenced := map[string]bool{}
+	for _, rule := range rules {
+		for _, reference := range importReferences(rule.body) {
+			referenced[reference] = true
+		}
+	}
+	for _, rule := range rules {
+		if lexical[rule.name] {
+			rule.kind = importFragment
+			if used[rule.name] || !referenced[rule.name] {
+				rule.kind = importToken
+			}
+		}
+	}
+}
+
+// Returns the names of the rules used by the node
+func importReferences(node *importNode) []string {
+	if node.op == importReference {
+		return []string{node.text}
+	}
+	names := []string{}
+	for _, item := range node.items {
+		names = append(names, importReferences(item)...)
+	}
+	return names
+}
@@ -0,0 +1,78 @@
+package grammatic
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const w3cGrammar = `
+/* a small expression language */
+[1] Expr   ::= Term (AddOp Term)*
+[2] Term   ::= Factor (MulOp Factor)*
+[3] Factor ::= Number | Name | "(" Expr ")" | Call
+[4] Call   ::= Name "(" (Expr ("," Expr)*)? ")"
+AddOp  ::= [+#x2D]
+MulOp  ::= '*' | '/'
+Number ::= Digit+ ("." Digit+)?
+Digit  ::= [0-9]
+Name   ::= [a-zA-Z_] [a-zA-Z_0-9]*
+String ::= '"' [^"#xA]* '"'
+`
+
+func TestImportW3CEBNF(t *testing.T) {
+	grammar, err := ImportW3CEBNF(w3cGrammar)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `Expr := Term (AddOp Term)*
+
+Term := Factor (MulOp Factor)*
+
+Factor := Number | Name | ('(' Expr ')') | Call
+
+Call := Name '(' (Expr (',' Expr)*)? ')'
+
+AddOp  := /[\+\-]/
+MulOp  := /\*|\//
+Number := /[0-9]+(?:\.[0-9]+)?/
+Name   := /[a-zA-Z_][a-zA-Z_0-9]*/
+String := /"[^\"\n]*"/
+`
+	if grammar.String() != expected {
+		t.Fatalf("Expected the grammar\n%s\ngot\n%s", expected, grammar.String())
+	}
+
+	if _, err := grammar.Parse("Expr", "1.5+max(x,2)*(y-2)"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestImportW3CEBNFUnsupported(t *testing.T) {
+	_, err := ImportW3CEBNF(`Char ::= [#x1-#xD7FF]
+CharData ::= [^<&]* - ([^<&]* ']]>' [^<&]*)
+Element ::= '<' Name '>' Element* '</' Name '>'  [ WFC: Element Type Match ]
+Name ::= [a-z]+
+List ::= List ',' Name | Name
+Item ::= Missing
+`)
+	var importError *ImportError
+	if !errors.As(err, &importError) {
+		t.Fatalf("Expected an ImportError, got %v", err)
+	}
+
+	expected := []string{
+		"line 2, column 21: exceptions like A - B are not supported",
+		"line 3, column 50: constraints like [ WFC: Element Type Match ] are not supported",
+		"line 5, column 1: rule List is left-recursive, which grammatic cannot parse",
+		"line 6, column 10: rule Missing is not defined",
+	}
+	issues := []string{}
+	for _, issue := range importError.Issues {
+		issues = append(issues, issue.String())
+	}
+	if strings.Join(issues, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected the issues\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(issues, "\n"))
+	}
+}
@@ -0,0 +1,108 @@
+package grammatic
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Separates the prefix given to Include from the names of the included rules, like in C.Number
+const NamespaceSeparator = "."
+
+// Copies the rules and tokens of the other grammar into this one, with their names prefixed by prefix and NamespaceSeparator,
+// so a rule Number included with the prefix C is named C.Number. An empty prefix keeps the original names.
+// Literal rules keep their names, since they are the same in every grammar.
+// Nothing is changed and an error is returned if any of the new names is already defined in this grammar,
+// or if the other grammar has rules created by custom combinators. Actions are included, but token reducers are not
+func (g *Grammar) Include(other *Grammar, prefix string) error {
+	if g.frozen {
+		return ErrFrozenGrammar
+	}
+
+	// anonymous groups get new names, so they don't clash with the groups of this grammar
+	groupNames := map[string]string{}
+	groupCount := g.groupCount
+	for _, name := range other.ruleOrder {
+		if strings.HasPrefix(name, "(group ") {
+			groupCount++
+			groupNames[name] = fmt.Sprintf("(group %d)", groupCount)
+		}
+	}
+
+	rename := func(name string) string {
+		if groupName, ok := groupNames[name]; ok {
+			return groupName
+		}
+		if prefix == "" || isLiteralName(name) {
+			return name
+		}
+		return prefix + NamespaceSeparator + name
+	}
+
+	collisions := []string{}
+	for _, name := range other.ruleOrder {
+		definition := other.definitions[name]
+		if definition.kind == "" {
+			return fmt.Errorf("cannot include rule %q, because it was not created with the grammar combinators", name)
+		}
+		if definition.kind != kindLiteral && g.isDefined(rename(name)) {
+			collisions = append(collisions, rename(name))
+		}
+	}
+	if len(collisions) > 0 {
+		sort.Strings(collisions)
+		return fmt.Errorf("cannot include grammar, because these rules are already defined: %s", strings.Join(collisions, ", "))
+	}
+
+	g.groupCount = groupCount
+	for _, name := range other.ruleOrder {
+		definition := other.definitions[name]
+		newName := rename(name)
+
+		switch definition.kind {
+		case kindToken:
+			if definition.ignored {
+				g.DefineIgnoredToken(newName, definition.pattern)
+			} else {
+				g.DefineToken(newName, definition.pattern)
+			}
+		case kindVirtualToken:
+			g.DefineVirtualTokenRule(newName)
+		case kindLiteral:
+			g.defineLiteral(definition.value, definition.ignoreCase)
+		default:
+			combinator, err := g.combinatorFor(definition, rename)
+			if err != nil {
+				return err
+			}
+			g.DefineRule(newName, combinator)
+		}
+	}
+
+	for name := range other.inlineRules {
+		g.Inline(rename(name))
+	}
+	for name := range other.droppedRules {
+		g.Drop(rename(name))
+	}
+	for name := range other.collapsedRules {
+		g.Collapse(rename(name))
+	}
+	for name, action := range other.actions {
+		g.Action(rename(name), action)
+	}
+	return nil
+}
+
+// Checks if the name is used by a rule or a token of the grammar
+func (g *Grammar) isDefined(name string) bool {
+	if _, err := g.lookupRule(name); err == nil {
+		return true
+	}
+	for _, def := range g.TokenDefs {
+		if def.Type == name {
+			return true
+		}
+	}
+	return false
+}
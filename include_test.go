@@ -0,0 +1,75 @@
+package grammatic
+
+import (
+	"testing"
+)
+
+const commonGrammar = `
+Number := $NumberFormat
+String := $DoubleQuotedStringFormat
+Comma := /,/ (drop)
+Space := $EmptySpaceFormat (ignore)`
+
+func TestInclude(t *testing.T) {
+	common := Compile(commonGrammar)
+
+	grammar := Compile(`
+List := '[' Item[C.Comma]* as _Items ']'
+
+Item := C.Number | C.String | List (collapse)`)
+
+	if err := grammar.Include(&common, "C"); err != nil {
+		t.Fatal(err)
+	}
+
+	node, err := grammar.Parse("List", `[1, "a", []]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedSyntaxTree := `Root
+  ├─List
+  │ ├─'[' • [
+  │ ├─C.Number • 1
+  │ ├─C.String • "a"
+  │ ├─List
+  │ │ ├─'[' • [
+  │ │ └─']' • ]
+  │ └─']' • ]
+  └─EOF • 
+
+`
+	if expectedSyntaxTree != node.PrettyPrint() {
+		t.Fatalf("Unexpected syntax tree\n%s", node.PrettyPrint())
+	}
+
+	if _, err := common.Parse("Number", "1"); err != nil {
+		t.Fatalf("Expected the included grammar to keep working, but got %v", err)
+	}
+}
+
+func TestIncludeCollisions(t *testing.T) {
+	common := Compile(commonGrammar)
+
+	grammar := Compile(`
+Pair := Number Colon Number
+
+Colon := /:/
+Number := $NumberFormat`)
+
+	err := grammar.Include(&common, "")
+	if err == nil {
+		t.Fatal("Expected including a grammar with the same rule names to fail")
+	}
+	expectedError := "cannot include grammar, because these rules are already defined: Number"
+	if err.Error() != expectedError {
+		t.Fatalf("Expected error %q, got %q", expectedError, err.Error())
+	}
+
+	if err := grammar.Include(&common, "C"); err != nil {
+		t.Fatal(err)
+	}
+	if err := grammar.Include(&common, "C"); err == nil {
+		t.Fatal("Expected including a grammar twice with the same prefix to fail")
+	}
+}
@@ -20,6 +20,17 @@ const OpenBracesFormat = "^(\\(|\\[|\\{)"
 const CloseBracesFormat = "^(\\)|\\]|\\})"
 const PunctuationFormat = "^[,;:.]"
 
+// Returned when none of the token definitions match the input at a position
+type IllegalCharacterError struct {
+	Character string
+	Line      int
+	Col       int
+}
+
+func (e *IllegalCharacterError) Error() string {
+	return fmt.Sprintf("Illegal character %q at line %d, column %d", e.Character, e.Line, e.Col)
+}
+
 func GetConvenienceTokenPattern(name string) string {
 	switch name {
 	case "DigitsFormat":
@@ -65,11 +76,11 @@ func ExtractTokens(text string, tokendefs []model.TokenDef) ([]model.Token, erro
 
 	for {
 		if index >= len(text) {
-			tokens = append(tokens, model.Token{Type: TYPE_EOF, Value: "", Line: line + 1, Col: 0})
+			tokens = append(tokens, model.Token{Type: TYPE_EOF, Value: "", Line: line + 1, Col: 0, Offset: len(text)})
 			break
 		}
 
-		nextToken := model.Token{}
+		nextToken := model.Token{Offset: index}
 		if text[index] == '\n' {
 			nextToken.Col = col + 1
 			nextToken.Line = line
@@ -99,7 +110,7 @@ func ExtractTokens(text string, tokendefs []model.TokenDef) ([]model.Token, erro
 			}
 		}
 		if !hasToken {
-			err = fmt.Errorf("Illegal character %q at line %d, column %d", string(text[index]), line, col)
+			err = &IllegalCharacterError{Character: string(text[index]), Line: line, Col: col}
 			break
 		} else {
 			tokens = append(tokens, nextToken)
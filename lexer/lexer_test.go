@@ -118,3 +118,27 @@ func TestIllegalCharacter(t *testing.T) {
 	}
 
 }
+
+func TestTokenOffsets(t *testing.T) {
+	tokendefs := []model.TokenDef{
+		NewTokenDef("Keyword", KeywordFormat),
+		NewTokenDef("Space", EmptySpaceFormat),
+	}
+
+	text := "first second\nthird"
+	tokens, err := ExtractTokens(text, tokendefs)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedOffsets := []int{0, 5, 6, 12, 13, 18}
+	if len(tokens) != len(expectedOffsets) {
+		t.Fatalf("Expected %d tokens, but found %d", len(expectedOffsets), len(tokens))
+	}
+	for i, token := range tokens {
+		if token.Offset != expectedOffsets[i] {
+			t.Fatalf("Expected token %q to have offset %d, but it was %d", token.Value, expectedOffsets[i], token.Offset)
+		}
+	}
+}
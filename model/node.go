@@ -5,11 +5,13 @@ import (
 	"strings"
 )
 
-// A Parse Tree Node, which is the basic unit of the parsing result
+// A Parse Tree Node, which is the basic unit of the parsing result.
+// Value holds the result of the semantic actions, when the node is produced by Grammar.ParseValue
 type Node struct {
 	Type  string
 	Token *Token
 	Rules []Node
+	Value any
 }
 
 func (n *Node) format(indentation string, firstChild, lastChild bool) string {
@@ -72,6 +74,28 @@ func (n *Node) GetAllNodes() []Node {
 	return n.Rules
 }
 
+// Checks if both trees have the same structure, types and token values
+func (n *Node) Equals(other *Node) bool {
+	if n == nil || other == nil {
+		return n == other
+	}
+	if n.Type != other.Type || len(n.Rules) != len(other.Rules) {
+		return false
+	}
+	if (n.Token == nil) != (other.Token == nil) {
+		return false
+	}
+	if n.Token != nil && *n.Token != *other.Token {
+		return false
+	}
+	for i := range n.Rules {
+		if !n.Rules[i].Equals(&other.Rules[i]) {
+			return false
+		}
+	}
+	return true
+}
+
 func formatString(text string) string {
 	noBackslashes := strings.ReplaceAll(text, "\\", "\\\\")
 	return strings.ReplaceAll(noBackslashes, "\n", "\\n")
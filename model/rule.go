@@ -1,13 +1,55 @@
 package model
 
 import (
+	"context"
 	"fmt"
 )
 
-// Represents a Generator Rule, which has a type name and a verifying function
+// Represents a Generator Rule, which has a type name and a verifying function.
+// CheckContext, when present, works like Check but receives the context of the whole parse, so it can be cancelled or limited.
+// Description tells how the rule was built, and is nil for rules that were used but never defined
 type Rule struct {
-	Type  string
-	Check func([]Token) RuleResultIterator
+	Type         string
+	Check        func([]Token) RuleResultIterator
+	CheckContext func(context.Context, []Token) RuleResultIterator
+	Description  *RuleDescription
+}
+
+// The kinds of rules found in RuleDescription.Kind
+const (
+	KindToken                  = "token"
+	KindVirtualToken           = "virtual"
+	KindLiteral                = "literal"
+	KindOr                     = "or"
+	KindSeq                    = "seq"
+	KindRename                 = "rename"
+	KindOneOrNone              = "oneOrNone"
+	KindMany                   = "many"
+	KindOneOrMany              = "oneOrMany"
+	KindManyWithSeparator      = "manyWithSeparator"
+	KindOneOrManyWithSeparator = "oneOrManyWithSeparator"
+	KindRepeat                 = "repeat"
+	KindRepeatWithSeparator    = "repeatWithSeparator"
+	KindCustom                 = "custom"
+)
+
+// Describes how a rule was built by the grammar combinators, so tools can inspect a grammar without parsing with it.
+// Rules holds the names of the rules it refers to, in order, and a Seq may include the cut marker "^".
+// Separator is set for rules with separators, Min and Max for repeats, Pattern and Ignored for tokens,
+// and Value and IgnoreCase for literals. Rules created by custom combinators have the KindCustom kind and nothing else
+type RuleDescription struct {
+	Name       string
+	Kind       string
+	Rules      []string
+	Separator  string
+	Min        int
+	Max        int
+	Trailing   bool
+	Memo       bool
+	Pattern    string
+	Ignored    bool
+	Value      string
+	IgnoreCase bool
 }
 
 // Returned by a Rule, this will output RuleResults with the Next() method and nil after it's finished, or after Done() is called
@@ -54,13 +96,29 @@ type RuleResult struct {
 	Error           *RuleError
 }
 
-// Represents an "Unexpected Token" error
+// Represents an "Unexpected Token" error.
+// A Fatal error was raised after a cut point, or because the parse was aborted, and must not be recovered by backtracking into other alternatives.
+// When the parse is aborted, Cause holds the reason
 type RuleError struct {
 	RuleType string
 	Token    Token
+	Fatal    bool
+	Cause    error
 }
 
 // Converts from a RuleError to a golang standard error type
 func (e *RuleError) GetError() error {
-	return fmt.Errorf("Unexpected token %q at line %d, column %d", e.Token.Value, e.Token.Line, e.Token.Col)
+	if e.Cause != nil {
+		return e.Cause
+	}
+	return &SyntaxError{Token: e.Token}
+}
+
+// Returned when the input does not match the grammar, with the token where the match failed
+type SyntaxError struct {
+	Token Token
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("Unexpected token %q at line %d, column %d", e.Token.Value, e.Token.Line, e.Token.Col)
 }
@@ -8,12 +8,14 @@ type TokenDef struct {
 	Pattern *regexp.Regexp
 }
 
-// Holds a chunk of the original parsed input, as well as the matched token type and position
+// Holds a chunk of the original parsed input, as well as the matched token type and position.
+// Offset is the index of the first byte of the token in the original input
 type Token struct {
-	Type  string
-	Value string
-	Line  int
-	Col   int
+	Type   string
+	Value  string
+	Line   int
+	Col    int
+	Offset int
 }
 
 // Checks if other token comes after the given token in the original input
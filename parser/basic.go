@@ -1,13 +1,31 @@
 package parser
 
 import (
+	"context"
 	"github.com/jsanchesleao/grammatic/model"
+	"strings"
 )
 
 func RuleTokenType(ruleType, tokenType string) *model.Rule {
-	return &model.Rule{
+	return ruleToken(ruleType, func(token model.Token) bool {
+		return token.Type == tokenType
+	})
+}
+
+// Matches a single token by its value instead of its type, optionally ignoring case
+func RuleTokenValue(ruleType, value string, ignoreCase bool) *model.Rule {
+	return ruleToken(ruleType, func(token model.Token) bool {
+		if ignoreCase {
+			return strings.EqualFold(token.Value, value)
+		}
+		return token.Value == value
+	})
+}
+
+func ruleToken(ruleType string, matches func(model.Token) bool) *model.Rule {
+	return withContext(&model.Rule{
 		Type: ruleType,
-		Check: func(tokens []model.Token) model.RuleResultIterator {
+		CheckContext: func(ctx context.Context, tokens []model.Token) model.RuleResultIterator {
 
 			stream := NewResultStream()
 
@@ -29,7 +47,7 @@ func RuleTokenType(ruleType, tokenType string) *model.Rule {
 				nextToken := tokens[0]
 				otherTokens := tokens[1:]
 
-				if nextToken.Type == tokenType {
+				if matches(nextToken) {
 					return &model.RuleResult{
 						Match: &model.Node{
 							Type:  ruleType,
@@ -63,5 +81,5 @@ func RuleTokenType(ruleType, tokenType string) *model.Rule {
 			return stream
 
 		},
-	}
+	})
 }
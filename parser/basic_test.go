@@ -52,3 +52,27 @@ func TestBasicRuleFail(t *testing.T) {
 
 	model.AssertTokenList(t, tokens, result.RemainingTokens)
 }
+
+func TestTokenValueRule(t *testing.T) {
+	upperKeyword := model.Token{Type: "TOKEN_KEYWORD", Value: "TEST", Line: 1, Col: 1}
+
+	rule := RuleTokenValue("'test'", "test", false)
+	result := rule.Check([]model.Token{keyword_token, eof_token}).Next()
+
+	if result == nil || result.Match == nil {
+		t.Fatalf("Expected a match, but found %+v", result)
+	}
+	model.AssertNodeEquals(t, model.Node{Type: "'test'", Token: &keyword_token}, *result.Match)
+
+	result = rule.Check([]model.Token{upperKeyword, eof_token}).Next()
+	if result == nil || result.Error == nil {
+		t.Fatalf("Expected an error, but found %+v", result)
+	}
+
+	rule = RuleTokenValue("'test'i", "test", true)
+	result = rule.Check([]model.Token{upperKeyword, eof_token}).Next()
+	if result == nil || result.Match == nil {
+		t.Fatalf("Expected a case insensitive match, but found %+v", result)
+	}
+	model.AssertNodeEquals(t, model.Node{Type: "'test'i", Token: &upperKeyword}, *result.Match)
+}
@@ -0,0 +1,165 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"github.com/jsanchesleao/grammatic/model"
+	"sync/atomic"
+)
+
+const (
+	LimitDepth       = "nested rules"
+	LimitInvocations = "rule invocations"
+	LimitNodes       = "nodes"
+)
+
+// Limits the resources that a single parse may use. A zero value means no limit
+type ParseLimits struct {
+	MaxDepth       int
+	MaxInvocations int
+	MaxNodes       int
+}
+
+// Returned when a parse exceeds one of its ParseLimits. Limit is one of LimitDepth, LimitInvocations or LimitNodes
+type LimitError struct {
+	Limit string
+	Max   int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("parse exceeded the limit of %d %s", e.Max, e.Limit)
+}
+
+type parseState struct {
+	limits      ParseLimits
+	invocations int64
+	nodes       int64
+	memo        memoTable
+}
+
+type parseFrame struct {
+	state *parseState
+	depth int
+}
+
+type frameKey struct{}
+
+// Returns a context that enforces the limits on every rule checked with it
+func WithLimits(ctx context.Context, limits ParseLimits) context.Context {
+	return context.WithValue(ctx, frameKey{}, parseFrame{state: &parseState{limits: limits}})
+}
+
+// Makes sure the context carries the state of a parse, which memoized rules need.
+// The returned function must be called when the parse ends, to finish the checks that memoized rules left unread
+func withParseState(ctx context.Context) (context.Context, func()) {
+	if _, ok := ctx.Value(frameKey{}).(parseFrame); !ok {
+		ctx = WithLimits(ctx, ParseLimits{})
+	}
+	state := ctx.Value(frameKey{}).(parseFrame).state
+	return ctx, state.memo.close
+}
+
+// Fills the Check function of a rule that implements CheckContext
+func withContext(rule *model.Rule) *model.Rule {
+	rule.Check = func(tokens []model.Token) model.RuleResultIterator {
+		return rule.CheckContext(context.Background(), tokens)
+	}
+	return rule
+}
+
+// Checks the rule with the given context, aborting with a fatal error if the context is done or its limits were exceeded.
+// The rule counts as one more level of nesting
+func checkRule(ctx context.Context, rule *model.Rule, tokens []model.Token) model.RuleResultIterator {
+	return checkRuleAt(ctx, rule, tokens, 1)
+}
+
+// Checks the rest of the rule being matched, like the tail of a Seq or the next items of a Many,
+// at the same level of nesting, so that long sequences and lists do not count towards MaxDepth
+func continueRule(ctx context.Context, rule *model.Rule, tokens []model.Token) model.RuleResultIterator {
+	return checkRuleAt(ctx, rule, tokens, 0)
+}
+
+func checkRuleAt(ctx context.Context, rule *model.Rule, tokens []model.Token, nesting int) model.RuleResultIterator {
+	if rule.CheckContext == nil {
+		return rule.Check(tokens)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return abortedResult(rule.Type, tokens, err)
+	}
+
+	frame, ok := ctx.Value(frameKey{}).(parseFrame)
+	if !ok {
+		return rule.CheckContext(ctx, tokens)
+	}
+
+	limits := frame.state.limits
+	frame.depth += nesting
+	if limits.MaxDepth > 0 && frame.depth > limits.MaxDepth {
+		return abortedResult(rule.Type, tokens, &LimitError{Limit: LimitDepth, Max: limits.MaxDepth})
+	}
+	invocations := atomic.AddInt64(&frame.state.invocations, 1)
+	if limits.MaxInvocations > 0 && invocations > int64(limits.MaxInvocations) {
+		return abortedResult(rule.Type, tokens, &LimitError{Limit: LimitInvocations, Max: limits.MaxInvocations})
+	}
+
+	iterator := rule.CheckContext(context.WithValue(ctx, frameKey{}, frame), tokens)
+
+	if limits.MaxNodes > 0 {
+		return &countingIterator{
+			iterator: iterator,
+			state:    frame.state,
+			ruleType: rule.Type,
+			tokens:   tokens,
+		}
+	}
+	return iterator
+}
+
+func abortedResult(ruleType string, tokens []model.Token, cause error) model.RuleResultIterator {
+	err := model.RuleError{
+		RuleType: ruleType,
+		Fatal:    true,
+		Cause:    cause,
+	}
+	if len(tokens) > 0 {
+		err.Token = tokens[0]
+	}
+	return model.FinalResultCandidate(model.RuleResult{
+		Match:           nil,
+		RemainingTokens: tokens,
+		Error:           &err,
+	})
+}
+
+// Counts every node produced by the wrapped iterator, and aborts when there are too many
+type countingIterator struct {
+	iterator model.RuleResultIterator
+	state    *parseState
+	ruleType string
+	tokens   []model.Token
+	aborted  model.RuleResultIterator
+}
+
+func (c *countingIterator) Next() *model.RuleResult {
+	if c.aborted != nil {
+		return c.aborted.Next()
+	}
+	result := c.iterator.Next()
+	if result != nil && result.Match != nil {
+		max := c.state.limits.MaxNodes
+		if atomic.AddInt64(&c.state.nodes, 1) > int64(max) {
+			c.iterator.Done()
+			c.aborted = abortedResult(c.ruleType, c.tokens, &LimitError{Limit: LimitNodes, Max: max})
+			return c.aborted.Next()
+		}
+	}
+	return result
+}
+
+func (c *countingIterator) Done() {
+	c.iterator.Done()
+	if c.aborted != nil {
+		c.aborted.Done()
+	}
+}
@@ -0,0 +1,73 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"github.com/jsanchesleao/grammatic/model"
+	"testing"
+)
+
+func TestCancelledContext(t *testing.T) {
+	rule := Seq("Root", Many("Ints", RuleTokenType("Int", "TOKEN_INT")), RuleTokenType("EOF", "TOKEN_EOF"))
+	tokens := []model.Token{int_token, int_token, eof_token}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := ParseRuleContext(ctx, *rule, []string{}, tokens)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected parsing to be cancelled, but the error was %v", err)
+	}
+
+	_, err = ParseRuleContext(context.Background(), *rule, []string{}, tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Returns a rule for an int wrapped in any number of parens, like ((1))
+func nestedIntRule() *model.Rule {
+	value := &model.Rule{}
+	*value = *Or("Value",
+		RuleTokenType("Int", "TOKEN_INT"),
+		Seq("Parens", RuleTokenType("LParen", "TOKEN_LPAREN"), value, RuleTokenType("RParen", "TOKEN_RPAREN")))
+	return value
+}
+
+func TestLimitError(t *testing.T) {
+	rule := Seq("Root", nestedIntRule(), RuleTokenType("EOF", "TOKEN_EOF"))
+	tokens := []model.Token{lparen_token, lparen_token, lparen_token, int_token, rparen_token, rparen_token, rparen_token, eof_token}
+
+	ctx := WithLimits(context.Background(), ParseLimits{MaxDepth: 4})
+
+	_, err := ParseRuleContext(ctx, *rule, []string{}, tokens)
+
+	var limitError *LimitError
+	if !errors.As(err, &limitError) {
+		t.Fatalf("Expected a limit error, but the error was %v", err)
+	}
+
+	expectedErrorMessage := "parse exceeded the limit of 4 nested rules"
+	if err.Error() != expectedErrorMessage {
+		t.Fatalf("Expected error message to be\n%q\n but was\n%q\n", expectedErrorMessage, err.Error())
+	}
+}
+
+func TestLimitDepthIgnoresListLength(t *testing.T) {
+	rule := Seq("Root", ManyWithSeparator("Ints", nestedIntRule(), RuleTokenType("Comma", "TOKEN_COMMA")), RuleTokenType("EOF", "TOKEN_EOF"))
+	tokens := []model.Token{int_token}
+	for i := 0; i < 100; i++ {
+		tokens = append(tokens, comma_token, int_token)
+	}
+	tokens = append(tokens, eof_token)
+
+	ctx := WithLimits(context.Background(), ParseLimits{MaxDepth: 5})
+
+	tree, err := ParseRuleContext(ctx, *rule, []string{}, tokens)
+	if err != nil {
+		t.Fatalf("Expected a flat list of 101 items to be within 5 nested rules, but the error was %v", err)
+	}
+	if len(tree.GetNodeWithType("Ints").GetNodesWithType("Value")) != 101 {
+		t.Fatalf("Unexpected tree:\n%s", tree.PrettyPrint())
+	}
+}
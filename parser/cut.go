@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"context"
+	"github.com/jsanchesleao/grammatic/model"
+)
+
+var cutRule = &model.Rule{Type: "Cut"}
+
+// Returns the cut marker. When placed inside a Seq, every failure that happens after
+// the marker is matched is reported as a fatal error, and no other alternatives are tried
+func Cut() *model.Rule {
+	return cutRule
+}
+
+// Checks if the rule is the cut marker returned by Cut()
+func IsCut(rule *model.Rule) bool {
+	return rule == cutRule
+}
+
+// Emits a fatal error as the last result of the stream, then finishes it along with the given iterators
+func sendFatal(stream *ResultStream, tokens []model.Token, err *model.RuleError, iterators ...model.RuleResultIterator) {
+	for _, iterator := range iterators {
+		iterator.Done()
+	}
+	stream.Send(&model.RuleResult{
+		Match:           nil,
+		RemainingTokens: tokens,
+		Error:           err,
+	})
+	stream.Continue()
+	stream.Done()
+}
+
+// Checks the rules that come after a cut marker. Successful results are forwarded,
+// and if none is found the furthest error is reported as fatal
+func checkCommitted(ctx context.Context, stream *ResultStream, ruleType string, rules []*model.Rule, tokens []model.Token) {
+	iterator := continueRule(ctx, Seq(ruleType, rules...), tokens)
+
+	success := false
+	var error *model.RuleError = nil
+	for {
+		result := iterator.Next()
+		if result == nil {
+			iterator.Done()
+			break
+		}
+		if result.Error != nil {
+			if result.Error.Fatal {
+				sendFatal(stream, tokens, result.Error, iterator)
+				return
+			}
+			if error == nil || result.Error.Token.IsAfter(error.Token) {
+				error = result.Error
+			}
+			continue
+		}
+
+		success = true
+		stream.Send(result)
+		if !stream.Continue() {
+			iterator.Done()
+			stream.Done()
+			return
+		}
+	}
+
+	if !success {
+		fatal := model.RuleError{RuleType: ruleType, Fatal: true}
+		if error != nil {
+			fatal = *error
+			fatal.Fatal = true
+		} else if len(tokens) > 0 {
+			fatal.Token = tokens[0]
+		}
+		sendFatal(stream, tokens, &fatal)
+		return
+	}
+
+	stream.Done()
+}
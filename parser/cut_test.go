@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"github.com/jsanchesleao/grammatic/model"
+	"testing"
+)
+
+func TestCutPreventsBacktracking(t *testing.T) {
+	keyword := RuleTokenType("Keyword", "TOKEN_KEYWORD")
+
+	withoutCut := Or("Statement",
+		Seq("Call", keyword, RuleTokenType("LParen", "TOKEN_LPAREN")),
+		Seq("Assign", keyword, RuleTokenType("Int", "TOKEN_INT")),
+	)
+
+	withCut := Or("Statement",
+		Seq("Call", keyword, Cut(), RuleTokenType("LParen", "TOKEN_LPAREN")),
+		Seq("Assign", keyword, RuleTokenType("Int", "TOKEN_INT")),
+	)
+
+	tokens := []model.Token{keyword_token, int_token, eof_token}
+
+	result := withoutCut.Check(tokens).Next()
+	if result == nil || result.Error != nil {
+		t.Fatalf("Expected rule without cut to match, but got %+v", result)
+	}
+
+	iterator := withCut.Check(tokens)
+	result = iterator.Next()
+	if result == nil {
+		t.Fatalf("Expected rule with cut to produce an error, but it produced nil")
+	}
+	if result.Error == nil {
+		t.Fatalf("Expected rule with cut to produce an error, but it matched %+v", result.Match)
+	}
+	if !result.Error.Fatal {
+		t.Fatalf("Expected error to be fatal, but it was not")
+	}
+	model.AssertTokenEquals(t, int_token, result.Error.Token)
+
+	if next := iterator.Next(); next != nil {
+		t.Fatalf("Expected no more results after a fatal error, but got %+v", next)
+	}
+}
+
+func TestCutDoesNotAffectMatches(t *testing.T) {
+	rule := Seq("Call",
+		RuleTokenType("Keyword", "TOKEN_KEYWORD"),
+		Cut(),
+		RuleTokenType("LParen", "TOKEN_LPAREN"),
+		RuleTokenType("RParen", "TOKEN_RPAREN"),
+	)
+
+	tokens := []model.Token{keyword_token, lparen_token, rparen_token, eof_token}
+
+	iterator := rule.Check(tokens)
+	result := iterator.Next()
+
+	if result == nil || result.Error != nil {
+		t.Fatalf("Expected rule to match, but got %+v", result)
+	}
+
+	model.AssertNodeEquals(t, model.Node{
+		Type: "Call",
+		Rules: []model.Node{
+			{Type: "Keyword", Token: &keyword_token},
+			{Type: "LParen", Token: &lparen_token},
+			{Type: "RParen", Token: &rparen_token},
+		},
+	}, *result.Match)
+	model.AssertTokenList(t, []model.Token{eof_token}, result.RemainingTokens)
+
+	if next := iterator.Next(); next != nil {
+		t.Fatalf("Expected second result to be nil, but was %+v", next)
+	}
+}
+
+func TestCutInsideRepetition(t *testing.T) {
+	call := Seq("Call",
+		RuleTokenType("Keyword", "TOKEN_KEYWORD"),
+		Cut(),
+		RuleTokenType("LParen", "TOKEN_LPAREN"),
+		RuleTokenType("RParen", "TOKEN_RPAREN"),
+	)
+
+	rule := Seq("Root", Many("Calls", call), RuleTokenType("EOF", "TOKEN_EOF"))
+
+	tokens := []model.Token{keyword_token, lparen_token, rparen_token, keyword_token, lparen_token, eof_token}
+
+	_, err := ParseRule(*rule, []string{}, tokens)
+
+	if err == nil {
+		t.Fatalf("Expected parsing to fail, but it did not")
+	}
+
+	expectedErrorMessage := "Unexpected token \"\" at line 1, column 1"
+	if err.Error() != expectedErrorMessage {
+		t.Fatalf("Expected error message to be\n%q\n but was\n%q\n", expectedErrorMessage, err.Error())
+	}
+}
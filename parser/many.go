@@ -1,13 +1,14 @@
 package parser
 
 import (
+	"context"
 	"github.com/jsanchesleao/grammatic/model"
 )
 
 func Many(ruleType string, rule *model.Rule) *model.Rule {
-	return &model.Rule{
+	return withContext(&model.Rule{
 		Type: ruleType,
-		Check: func(tokens []model.Token) model.RuleResultIterator {
+		CheckContext: func(ctx context.Context, tokens []model.Token) model.RuleResultIterator {
 			stream := NewResultStream()
 
 			go func() {
@@ -17,7 +18,7 @@ func Many(ruleType string, rule *model.Rule) *model.Rule {
 					return
 				}
 
-				iterator := rule.Check(tokens)
+				iterator := checkRule(ctx, rule, tokens)
 			outer:
 				for {
 					result := iterator.Next()
@@ -27,10 +28,14 @@ func Many(ruleType string, rule *model.Rule) *model.Rule {
 					}
 
 					if result.Error != nil {
+						if result.Error.Fatal {
+							sendFatal(stream, tokens, result.Error, iterator)
+							return
+						}
 						continue
 					}
 
-					nextIterator := Many(ruleType, rule).Check(result.RemainingTokens)
+					nextIterator := continueRule(ctx, Many(ruleType, rule), result.RemainingTokens)
 
 				inner:
 					for {
@@ -41,6 +46,10 @@ func Many(ruleType string, rule *model.Rule) *model.Rule {
 						}
 
 						if nextResult.Error != nil {
+							if nextResult.Error.Fatal {
+								sendFatal(stream, tokens, nextResult.Error, nextIterator, iterator)
+								return
+							}
 							continue inner
 						}
 
@@ -88,5 +97,5 @@ func Many(ruleType string, rule *model.Rule) *model.Rule {
 
 			return stream
 		},
-	}
+	})
 }
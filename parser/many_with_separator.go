@@ -1,14 +1,15 @@
 package parser
 
 import (
+	"context"
 	"fmt"
 	"github.com/jsanchesleao/grammatic/model"
 )
 
 func ManyWithSeparator(typeName string, rule *model.Rule, separator *model.Rule) *model.Rule {
-	return &model.Rule{
+	return withContext(&model.Rule{
 		Type: typeName,
-		Check: func(tokens []model.Token) model.RuleResultIterator {
+		CheckContext: func(ctx context.Context, tokens []model.Token) model.RuleResultIterator {
 
 			stream := NewResultStream()
 
@@ -19,7 +20,7 @@ func ManyWithSeparator(typeName string, rule *model.Rule, separator *model.Rule)
 					return
 				}
 
-				iterator := rule.Check(tokens)
+				iterator := checkRule(ctx, rule, tokens)
 				subrule := Many(fmt.Sprintf("%s:Tail", typeName), Seq(fmt.Sprintf("%s:TailItem", typeName), separator, rule))
 
 				for {
@@ -31,10 +32,14 @@ func ManyWithSeparator(typeName string, rule *model.Rule, separator *model.Rule)
 					}
 
 					if result.Error != nil {
+						if result.Error.Fatal {
+							sendFatal(stream, tokens, result.Error, iterator)
+							return
+						}
 						continue
 					}
 
-					tailIterator := subrule.Check(result.RemainingTokens)
+					tailIterator := continueRule(ctx, subrule, result.RemainingTokens)
 				inner:
 					for {
 						tailResult := tailIterator.Next()
@@ -45,6 +50,10 @@ func ManyWithSeparator(typeName string, rule *model.Rule, separator *model.Rule)
 						}
 
 						if tailResult.Error != nil {
+							if tailResult.Error.Fatal {
+								sendFatal(stream, tokens, tailResult.Error, tailIterator, iterator)
+								return
+							}
 							continue
 						}
 
@@ -96,5 +105,5 @@ func ManyWithSeparator(typeName string, rule *model.Rule, separator *model.Rule)
 			return stream
 
 		},
-	}
+	})
 }
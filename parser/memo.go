@@ -0,0 +1,133 @@
+package parser
+
+import (
+	"context"
+	"github.com/jsanchesleao/grammatic/model"
+	"sync"
+)
+
+type memoKey struct {
+	rule      *model.Rule
+	remaining int
+}
+
+// Keeps the results of memoized rules for a single parse, by the position where they were checked
+type memoTable struct {
+	lock    sync.Mutex
+	entries map[memoKey]*memoEntry
+}
+
+// Returns the entry of the rule at the position, starting to check the rule when it is the first time
+func (m *memoTable) entry(ctx context.Context, rule *model.Rule, tokens []model.Token) *memoEntry {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.entries == nil {
+		m.entries = map[memoKey]*memoEntry{}
+	}
+	key := memoKey{rule: rule, remaining: len(tokens)}
+	entry, ok := m.entries[key]
+	if !ok {
+		entry = &memoEntry{source: checkRule(ctx, rule, tokens)}
+		m.entries[key] = entry
+	}
+	return entry
+}
+
+// Finishes the checks that were not read to the end, and forgets every result
+func (m *memoTable) close() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, entry := range m.entries {
+		entry.close()
+	}
+	m.entries = nil
+}
+
+// The results of a rule at one position, read from its iterator only as far as some check of the rule needed them
+type memoEntry struct {
+	lock    sync.Mutex
+	source  model.RuleResultIterator
+	results []model.RuleResult
+}
+
+// Returns the result at the index, reading it from the source when no check has needed it yet
+func (e *memoEntry) result(index int) (model.RuleResult, bool) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	for index >= len(e.results) && e.source != nil {
+		result := e.source.Next()
+		if result == nil {
+			e.source.Done()
+			e.source = nil
+			break
+		}
+		e.results = append(e.results, *result)
+		if result.Error != nil && result.Error.Fatal {
+			e.source.Done()
+			e.source = nil
+		}
+	}
+	if index >= len(e.results) {
+		return model.RuleResult{}, false
+	}
+	return e.results[index], true
+}
+
+func (e *memoEntry) close() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.source != nil {
+		e.source.Done()
+		e.source = nil
+	}
+}
+
+// Shares the results of the rule for each position of the input, so a rule that is tried many times
+// at the same place, by different alternatives, is only checked once per parse.
+// Results are read from the rule as the checks need them, so the first result is available as soon as it is found
+func Memo(rule *model.Rule) *model.Rule {
+	return withContext(&model.Rule{
+		Type: rule.Type,
+		CheckContext: func(ctx context.Context, tokens []model.Token) model.RuleResultIterator {
+			frame, ok := ctx.Value(frameKey{}).(parseFrame)
+			if !ok {
+				return checkRule(ctx, rule, tokens)
+			}
+			return &replayIterator{entry: frame.state.memo.entry(ctx, rule, tokens)}
+		},
+	})
+}
+
+// Produces copies of the shared results, since the rules that receive them may change the nodes and errors
+type replayIterator struct {
+	entry *memoEntry
+	index int
+	done  bool
+}
+
+func (r *replayIterator) Next() *model.RuleResult {
+	if r.done {
+		return nil
+	}
+	result, ok := r.entry.result(r.index)
+	if !ok {
+		r.done = true
+		return nil
+	}
+	r.index++
+
+	if result.Match != nil {
+		match := *result.Match
+		result.Match = &match
+	}
+	if result.Error != nil {
+		err := *result.Error
+		result.Error = &err
+	}
+	return &result
+}
+
+// Stops this replay only, since other checks of the rule at the same position may still read the shared results
+func (r *replayIterator) Done() {
+	r.done = true
+}
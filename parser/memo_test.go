@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"github.com/jsanchesleao/grammatic/model"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMemoChecksRuleOncePerPosition(t *testing.T) {
+	var checks int32 = 0
+	keyword := RuleTokenType("Keyword", "TOKEN_KEYWORD")
+	counted := &model.Rule{
+		Type: "Keyword",
+		Check: func(tokens []model.Token) model.RuleResultIterator {
+			atomic.AddInt32(&checks, 1)
+			return keyword.Check(tokens)
+		},
+	}
+
+	memoized := Memo(counted)
+	rule := Or("Statement",
+		Seq("Call", memoized, RuleTokenType("LParen", "TOKEN_LPAREN")),
+		Seq("Assign", memoized, RuleTokenType("Int", "TOKEN_INT")),
+		Seq("Return", memoized, RuleTokenType("Bool", "TOKEN_BOOL")),
+	)
+
+	tokens := []model.Token{keyword_token, bool_token}
+	node, err := ParseRule(*rule, []string{}, tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	model.AssertNodeEquals(t, model.Node{
+		Type: "Statement",
+		Rules: []model.Node{
+			{
+				Type: "Return",
+				Rules: []model.Node{
+					{Type: "Keyword", Token: &keyword_token},
+					{Type: "Bool", Token: &bool_token},
+				},
+			},
+		},
+	}, *node)
+
+	if checks != 1 {
+		t.Fatalf("Expected the memoized rule to be checked once, but it was checked %d times", checks)
+	}
+}
+
+func TestMemoWithoutParseState(t *testing.T) {
+	rule := Memo(RuleTokenType("Keyword", "TOKEN_KEYWORD"))
+
+	for i := 0; i < 2; i++ {
+		iterator := rule.Check([]model.Token{keyword_token, eof_token})
+		result := iterator.Next()
+		iterator.Done()
+		if result == nil || result.Match == nil {
+			t.Fatalf("Expected a match, but got %+v", result)
+		}
+	}
+}
+
+// Produces the same match a thousand times, counting how many results were read
+type repeatedIterator struct {
+	reads *int32
+	token model.Token
+}
+
+func (r *repeatedIterator) Next() *model.RuleResult {
+	if atomic.AddInt32(r.reads, 1) > 1000 {
+		return nil
+	}
+	return &model.RuleResult{Match: &model.Node{Type: "Keyword", Token: &r.token}, RemainingTokens: []model.Token{eof_token}}
+}
+
+func (r *repeatedIterator) Done() {}
+
+func TestMemoReadsResultsLazily(t *testing.T) {
+	var reads int32 = 0
+	repeated := &model.Rule{
+		Type: "Keyword",
+		Check: func(tokens []model.Token) model.RuleResultIterator {
+			return &repeatedIterator{reads: &reads, token: tokens[0]}
+		},
+	}
+
+	rule := Seq("Statement", Memo(repeated), RuleTokenType("EOF", "TOKEN_EOF"))
+	if _, err := ParseRule(*rule, []string{}, []model.Token{keyword_token, eof_token}); err != nil {
+		t.Fatal(err)
+	}
+	if reads != 1 {
+		t.Fatalf("Expected only the first result to be read, but %d were", reads)
+	}
+}
+
+func TestMemoIsClearedBetweenParses(t *testing.T) {
+	rule := Seq("Root", Memo(Or("Value", RuleTokenType("Int", "TOKEN_INT"), RuleTokenType("Bool", "TOKEN_BOOL"))), RuleTokenType("EOF", "TOKEN_EOF"))
+	ctx := WithLimits(context.Background(), ParseLimits{})
+
+	for _, token := range []model.Token{int_token, bool_token} {
+		node, err := ParseRuleContext(ctx, *rule, []string{}, []model.Token{token, eof_token})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if node.Rules[0].Rules[0].Token.Type != token.Type {
+			t.Fatalf("Expected the %s token to be matched, but got the tree\n%s", token.Type, node.PrettyPrint())
+		}
+	}
+}
+
+// Returns a rule where every level tries its lower level twice at the same position, like Or(Seq(Lower, A), Seq(Lower, B))
+func nestedAlternatives(levels int, memoize bool) *model.Rule {
+	rule := RuleTokenType("Int", "TOKEN_INT")
+	for i := 0; i < levels; i++ {
+		if memoize {
+			rule = Memo(rule)
+		}
+		rule = Or("Level",
+			Seq("WithKeyword", rule, RuleTokenType("Keyword", "TOKEN_KEYWORD")),
+			Seq("WithBool", rule, RuleTokenType("Bool", "TOKEN_BOOL")))
+	}
+	return rule
+}
+
+func BenchmarkMemo(b *testing.B) {
+	tokens := []model.Token{int_token}
+	for i := 0; i < 12; i++ {
+		tokens = append(tokens, bool_token)
+	}
+	tokens = append(tokens, eof_token)
+
+	for _, memoize := range []bool{false, true} {
+		rule := Seq("Root", nestedAlternatives(12, memoize), RuleTokenType("EOF", "TOKEN_EOF"))
+		b.Run(fmt.Sprintf("memoize=%v", memoize), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := ParseRule(*rule, []string{}, tokens); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -1,11 +1,14 @@
 package parser
 
-import "github.com/jsanchesleao/grammatic/model"
+import (
+	"context"
+	"github.com/jsanchesleao/grammatic/model"
+)
 
 func OneOrMany(ruleType string, rule *model.Rule) *model.Rule {
-	return &model.Rule{
+	return withContext(&model.Rule{
 		Type: ruleType,
-		Check: func(tokens []model.Token) model.RuleResultIterator {
+		CheckContext: func(ctx context.Context, tokens []model.Token) model.RuleResultIterator {
 			stream := NewResultStream()
 
 			go func() {
@@ -24,7 +27,7 @@ func OneOrMany(ruleType string, rule *model.Rule) *model.Rule {
 					Token:    errorToken,
 				}
 				success := false
-				iterator := rule.Check(tokens)
+				iterator := checkRule(ctx, rule, tokens)
 			outer:
 				for {
 					result := iterator.Next()
@@ -34,6 +37,10 @@ func OneOrMany(ruleType string, rule *model.Rule) *model.Rule {
 					}
 
 					if result.Error != nil {
+						if result.Error.Fatal {
+							sendFatal(stream, tokens, result.Error, iterator)
+							return
+						}
 						if result.Error.Token.IsAfter(error.Token) {
 							error = result.Error
 						}
@@ -42,7 +49,7 @@ func OneOrMany(ruleType string, rule *model.Rule) *model.Rule {
 
 					success = true
 
-					nextIterator := Many(ruleType, rule).Check(result.RemainingTokens)
+					nextIterator := continueRule(ctx, Many(ruleType, rule), result.RemainingTokens)
 
 				inner:
 					for {
@@ -53,6 +60,10 @@ func OneOrMany(ruleType string, rule *model.Rule) *model.Rule {
 						}
 
 						if nextResult.Error != nil {
+							if nextResult.Error.Fatal {
+								sendFatal(stream, tokens, nextResult.Error, nextIterator, iterator)
+								return
+							}
 							continue inner
 						}
 
@@ -90,5 +101,5 @@ func OneOrMany(ruleType string, rule *model.Rule) *model.Rule {
 
 			return stream
 		},
-	}
+	})
 }
@@ -1,14 +1,15 @@
 package parser
 
 import (
+	"context"
 	"fmt"
 	"github.com/jsanchesleao/grammatic/model"
 )
 
 func OneOrManyWithSeparator(typeName string, rule *model.Rule, separator *model.Rule) *model.Rule {
-	return &model.Rule{
+	return withContext(&model.Rule{
 		Type: typeName,
-		Check: func(tokens []model.Token) model.RuleResultIterator {
+		CheckContext: func(ctx context.Context, tokens []model.Token) model.RuleResultIterator {
 
 			stream := NewResultStream()
 
@@ -21,7 +22,7 @@ func OneOrManyWithSeparator(typeName string, rule *model.Rule, separator *model.
 
 				success := false
 				var error model.RuleError
-				iterator := rule.Check(tokens)
+				iterator := checkRule(ctx, rule, tokens)
 				subrule := Many(fmt.Sprintf("%s:Tail", typeName), Seq(fmt.Sprintf("%s:TailItem", typeName), separator, rule))
 
 				for {
@@ -33,13 +34,17 @@ func OneOrManyWithSeparator(typeName string, rule *model.Rule, separator *model.
 					}
 
 					if result.Error != nil {
+						if result.Error.Fatal {
+							sendFatal(stream, tokens, result.Error, iterator)
+							return
+						}
 						if result.Error.Token.IsAfter(error.Token) {
 							error = *result.Error
 						}
 						continue
 					}
 
-					tailIterator := subrule.Check(result.RemainingTokens)
+					tailIterator := continueRule(ctx, subrule, result.RemainingTokens)
 				inner:
 					for {
 						tailResult := tailIterator.Next()
@@ -50,6 +55,10 @@ func OneOrManyWithSeparator(typeName string, rule *model.Rule, separator *model.
 						}
 
 						if tailResult.Error != nil {
+							if tailResult.Error.Fatal {
+								sendFatal(stream, tokens, tailResult.Error, tailIterator, iterator)
+								return
+							}
 							continue
 						}
 
@@ -100,5 +109,5 @@ func OneOrManyWithSeparator(typeName string, rule *model.Rule, separator *model.
 			return stream
 
 		},
-	}
+	})
 }
@@ -1,11 +1,14 @@
 package parser
 
-import "github.com/jsanchesleao/grammatic/model"
+import (
+	"context"
+	"github.com/jsanchesleao/grammatic/model"
+)
 
 func OneOrNone(ruleType string, rule *model.Rule) *model.Rule {
-	return &model.Rule{
+	return withContext(&model.Rule{
 		Type: ruleType,
-		Check: func(tokens []model.Token) model.RuleResultIterator {
+		CheckContext: func(ctx context.Context, tokens []model.Token) model.RuleResultIterator {
 			stream := NewResultStream()
 
 			go func() {
@@ -14,7 +17,7 @@ func OneOrNone(ruleType string, rule *model.Rule) *model.Rule {
 					return
 				}
 
-				iterator := rule.Check(tokens)
+				iterator := checkRule(ctx, rule, tokens)
 
 				for {
 					result := iterator.Next()
@@ -23,6 +26,10 @@ func OneOrNone(ruleType string, rule *model.Rule) *model.Rule {
 					}
 
 					if result.Error != nil {
+						if result.Error.Fatal {
+							sendFatal(stream, tokens, result.Error, iterator)
+							return
+						}
 						continue
 					}
 
@@ -57,5 +64,5 @@ func OneOrNone(ruleType string, rule *model.Rule) *model.Rule {
 
 			return stream
 		},
-	}
+	})
 }
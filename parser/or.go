@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"context"
 	"github.com/jsanchesleao/grammatic/model"
 )
 
@@ -8,9 +9,9 @@ func Or(ruleType string, rules ...*model.Rule) *model.Rule {
 	if len(rules) == 0 {
 		panic("Provide at least one rule to Or combinator")
 	}
-	return &model.Rule{
+	return withContext(&model.Rule{
 		Type: ruleType,
-		Check: func(tokens []model.Token) model.RuleResultIterator {
+		CheckContext: func(ctx context.Context, tokens []model.Token) model.RuleResultIterator {
 
 			stream := NewResultStream()
 
@@ -30,7 +31,7 @@ func Or(ruleType string, rules ...*model.Rule) *model.Rule {
 				}
 			loop:
 				for _, rule := range rules {
-					iterator := rule.Check(tokens)
+					iterator := checkRule(ctx, rule, tokens)
 					result := iterator.Next()
 					for result != nil {
 						if result.Error == nil {
@@ -40,6 +41,9 @@ func Or(ruleType string, rules ...*model.Rule) *model.Rule {
 								iterator.Done()
 								break loop
 							}
+						} else if result.Error.Fatal {
+							sendFatal(stream, tokens, result.Error, iterator)
+							return
 						} else if err == nil {
 							err = result.Error
 						} else if result.Error.Token.IsAfter(err.Token) {
@@ -65,6 +69,6 @@ func Or(ruleType string, rules ...*model.Rule) *model.Rule {
 			return stream
 
 		},
-	}
+	})
 
 }
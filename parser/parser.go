@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"context"
 	"fmt"
 	"github.com/jsanchesleao/grammatic/model"
 )
@@ -14,23 +15,61 @@ func shouldIgnore(ignoredTypes []string, token *model.Token) bool {
 	return false
 }
 
-func ParseRule(rootRule model.Rule, ignoredTokenTypes []string, tokens []model.Token) (*model.Node, error) {
-
+// Returns the tokens without the ones whose type is ignored
+func filterTokens(ignoredTypes []string, tokens []model.Token) []model.Token {
 	validTokens := []model.Token{}
 	for _, token := range tokens {
-		if !shouldIgnore(ignoredTokenTypes, &token) {
+		if !shouldIgnore(ignoredTypes, &token) {
 			validTokens = append(validTokens, token)
 		}
 	}
+	return validTokens
+}
+
+// Parses the tokens with the root rule and returns the first successful result,
+// or the furthest error found if the tokens cannot be parsed
+func ParseRule(rootRule model.Rule, ignoredTokenTypes []string, tokens []model.Token) (*model.Node, error) {
+	nodes, err := ParseAllRule(rootRule, ignoredTokenTypes, tokens, 1)
+	if err != nil {
+		return nil, err
+	}
+	return nodes[0], nil
+}
+
+// Parses the tokens with the root rule and returns up to limit distinct successful results, in the order they are found.
+// A limit of zero or less returns every result, which may never finish for grammars with too many possible parses.
+// When parsing stops with a fatal error, like a cut that fails or a context that is done, the results found
+// before it are returned along with the error
+func ParseAllRule(rootRule model.Rule, ignoredTokenTypes []string, tokens []model.Token, limit int) ([]*model.Node, error) {
+	return parseAll(context.Background(), rootRule, ignoredTokenTypes, tokens, limit)
+}
+
+// Works like ParseRule, but stops with an error as soon as the context is done.
+// Use WithLimits to also restrict the resources used by the parse
+func ParseRuleContext(ctx context.Context, rootRule model.Rule, ignoredTokenTypes []string, tokens []model.Token) (*model.Node, error) {
+	nodes, err := parseAll(ctx, rootRule, ignoredTokenTypes, tokens, 1)
+	if err != nil {
+		return nil, err
+	}
+	return nodes[0], nil
+}
+
+func parseAll(ctx context.Context, rootRule model.Rule, ignoredTokenTypes []string, tokens []model.Token, limit int) ([]*model.Node, error) {
 
-	iterator := rootRule.Check(validTokens)
+	ctx, finish := withParseState(ctx)
+	defer finish()
+	iterator := checkRule(ctx, &rootRule, filterTokens(ignoredTokenTypes, tokens))
 
+	nodes := []*model.Node{}
 	var ruleError *model.RuleError = nil
 	for {
 		result := iterator.Next()
 
 		if result == nil {
 			iterator.Done()
+			if len(nodes) > 0 {
+				return nodes, nil
+			}
 			if ruleError == nil {
 				return nil, fmt.Errorf("found an unexpected error during parsing")
 			} else {
@@ -39,14 +78,77 @@ func ParseRule(rootRule model.Rule, ignoredTokenTypes []string, tokens []model.T
 		}
 
 		if result.Error != nil {
+			if result.Error.Fatal {
+				iterator.Done()
+				return nodes, result.Error.GetError()
+			}
 			if ruleError == nil || result.Error.Token.IsAfter(ruleError.Token) {
 				ruleError = result.Error
 			}
 			continue
 		}
 
-		return result.Match, nil
+		if !containsNode(nodes, result.Match) {
+			nodes = append(nodes, result.Match)
+		}
+
+		if limit > 0 && len(nodes) >= limit {
+			iterator.Done()
+			return nodes, nil
+		}
+
+	}
+
+}
+
+func containsNode(nodes []*model.Node, node *model.Node) bool {
+	for _, other := range nodes {
+		if other.Equals(node) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parses a leading part of the tokens with the rule, without requiring all of them to be consumed.
+// Returns the longest match found, along with the tokens that were left unparsed
+func ParsePrefixRule(rule model.Rule, ignoredTokenTypes []string, tokens []model.Token) (*model.Node, []model.Token, error) {
+
+	ctx, finish := withParseState(context.Background())
+	defer finish()
+	iterator := checkRule(ctx, &rule, filterTokens(ignoredTokenTypes, tokens))
 
+	var best *model.RuleResult = nil
+	var ruleError *model.RuleError = nil
+	for {
+		result := iterator.Next()
+
+		if result == nil {
+			iterator.Done()
+			break
+		}
+
+		if result.Error != nil {
+			if result.Error.Fatal {
+				iterator.Done()
+				return nil, nil, result.Error.GetError()
+			}
+			if ruleError == nil || result.Error.Token.IsAfter(ruleError.Token) {
+				ruleError = result.Error
+			}
+			continue
+		}
+
+		if best == nil || len(result.RemainingTokens) < len(best.RemainingTokens) {
+			best = result
+		}
 	}
 
+	if best != nil {
+		return best.Match, best.RemainingTokens, nil
+	}
+	if ruleError == nil {
+		return nil, nil, fmt.Errorf("found an unexpected error during parsing")
+	}
+	return nil, nil, ruleError.GetError()
 }
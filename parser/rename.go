@@ -1,13 +1,14 @@
 package parser
 
 import (
+	"context"
 	"github.com/jsanchesleao/grammatic/model"
 )
 
 func Rename(ruleType string, rule *model.Rule) *model.Rule {
-	return &model.Rule{
+	return withContext(&model.Rule{
 		Type: ruleType,
-		Check: func(tokens []model.Token) model.RuleResultIterator {
+		CheckContext: func(ctx context.Context, tokens []model.Token) model.RuleResultIterator {
 			stream := NewResultStream()
 
 			go func() {
@@ -17,7 +18,7 @@ func Rename(ruleType string, rule *model.Rule) *model.Rule {
 					return
 				}
 
-				iterator := rule.Check(tokens)
+				iterator := checkRule(ctx, rule, tokens)
 				for {
 					result := iterator.Next()
 					if result == nil {
@@ -48,5 +49,5 @@ func Rename(ruleType string, rule *model.Rule) *model.Rule {
 
 			return stream
 		},
-	}
+	})
 }
@@ -0,0 +1,255 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"github.com/jsanchesleao/grammatic/model"
+)
+
+// Used as the max argument of Repeat and RepeatWithSeparator, to allow any number of repetitions
+const Unbounded = -1
+
+// Matches the rule repeatedly, at least min and at most max times. Longer matches are produced first
+func Repeat(ruleType string, rule *model.Rule, min, max int) *model.Rule {
+	return withContext(&model.Rule{
+		Type: ruleType,
+		CheckContext: func(ctx context.Context, tokens []model.Token) model.RuleResultIterator {
+			stream := NewResultStream()
+
+			go func() {
+
+				if !stream.Continue() {
+					stream.Done()
+					return
+				}
+
+				if max != 0 {
+					var error *model.RuleError = nil
+					success := false
+
+					iterator := checkRule(ctx, rule, tokens)
+					for {
+						result := iterator.Next()
+						if result == nil {
+							iterator.Done()
+							break
+						}
+
+						if result.Error != nil {
+							if result.Error.Fatal {
+								sendFatal(stream, tokens, result.Error, iterator)
+								return
+							}
+							if error == nil || result.Error.Token.IsAfter(error.Token) {
+								error = result.Error
+							}
+							continue
+						}
+
+						nextIterator := continueRule(ctx, Repeat(ruleType, rule, decreaseCount(min), decreaseCount(max)), result.RemainingTokens)
+
+					inner:
+						for {
+							nextResult := nextIterator.Next()
+							if nextResult == nil {
+								nextIterator.Done()
+								break inner
+							}
+
+							if nextResult.Error != nil {
+								if nextResult.Error.Fatal {
+									sendFatal(stream, tokens, nextResult.Error, nextIterator, iterator)
+									return
+								}
+								if error == nil || nextResult.Error.Token.IsAfter(error.Token) {
+									error = nextResult.Error
+								}
+								continue inner
+							}
+
+							success = true
+							stream.Send(&model.RuleResult{
+								Match: &model.Node{
+									Type:  ruleType,
+									Token: nil,
+									Rules: append([]model.Node{*result.Match}, nextResult.Match.Rules...),
+								},
+								RemainingTokens: nextResult.RemainingTokens,
+								Error:           nil,
+							})
+
+							if !stream.Continue() {
+								iterator.Done()
+								nextIterator.Done()
+								stream.Done()
+								return
+							}
+						}
+					}
+
+					if min > 0 {
+						if !success {
+							sendRepeatError(stream, ruleType, tokens, error)
+						}
+						stream.Done()
+						return
+					}
+				}
+
+				stream.Send(&model.RuleResult{
+					Match: &model.Node{
+						Type:  ruleType,
+						Token: nil,
+						Rules: []model.Node{},
+					},
+					RemainingTokens: tokens,
+					Error:           nil,
+				})
+				stream.Continue()
+				stream.Done()
+
+			}()
+
+			return stream
+		},
+	})
+}
+
+// Matches the rule repeatedly with the separator between the matches, at least min and at most max times.
+// The separators are kept in the produced node. Longer matches are produced first
+func RepeatWithSeparator(typeName string, rule *model.Rule, separator *model.Rule, min, max int) *model.Rule {
+	return withContext(&model.Rule{
+		Type: typeName,
+		CheckContext: func(ctx context.Context, tokens []model.Token) model.RuleResultIterator {
+
+			stream := NewResultStream()
+
+			go func() {
+
+				if !stream.Continue() {
+					stream.Done()
+					return
+				}
+
+				if max != 0 {
+					var error *model.RuleError = nil
+					success := false
+
+					iterator := checkRule(ctx, rule, tokens)
+					tailItemType := fmt.Sprintf("%s:TailItem", typeName)
+					subrule := Repeat(fmt.Sprintf("%s:Tail", typeName), Seq(tailItemType, separator, rule), decreaseCount(min), decreaseCount(max))
+
+					for {
+						result := iterator.Next()
+
+						if result == nil {
+							iterator.Done()
+							break
+						}
+
+						if result.Error != nil {
+							if result.Error.Fatal {
+								sendFatal(stream, tokens, result.Error, iterator)
+								return
+							}
+							if error == nil || result.Error.Token.IsAfter(error.Token) {
+								error = result.Error
+							}
+							continue
+						}
+
+						tailIterator := continueRule(ctx, subrule, result.RemainingTokens)
+					inner:
+						for {
+							tailResult := tailIterator.Next()
+
+							if tailResult == nil {
+								tailIterator.Done()
+								break inner
+							}
+
+							if tailResult.Error != nil {
+								if tailResult.Error.Fatal {
+									sendFatal(stream, tokens, tailResult.Error, tailIterator, iterator)
+									return
+								}
+								if error == nil || tailResult.Error.Token.IsAfter(error.Token) {
+									error = tailResult.Error
+								}
+								continue
+							}
+
+							nodes := []model.Node{*result.Match}
+							for _, node := range tailResult.Match.GetNodesWithType(tailItemType) {
+								nodes = append(nodes, node.Rules...)
+							}
+
+							success = true
+							stream.Send(&model.RuleResult{
+								Match: &model.Node{
+									Type:  typeName,
+									Token: nil,
+									Rules: nodes,
+								},
+								RemainingTokens: tailResult.RemainingTokens,
+								Error:           nil,
+							})
+							if !stream.Continue() {
+								iterator.Done()
+								tailIterator.Done()
+								stream.Done()
+								return
+							}
+						}
+					}
+
+					if min > 0 {
+						if !success {
+							sendRepeatError(stream, typeName, tokens, error)
+						}
+						stream.Done()
+						return
+					}
+				}
+
+				stream.Send(&model.RuleResult{
+					Match: &model.Node{
+						Type:  typeName,
+						Token: nil,
+						Rules: []model.Node{},
+					},
+					RemainingTokens: tokens,
+					Error:           nil,
+				})
+				stream.Continue()
+				stream.Done()
+
+			}()
+
+			return stream
+
+		},
+	})
+}
+
+func decreaseCount(count int) int {
+	if count <= 0 {
+		return count
+	}
+	return count - 1
+}
+
+func sendRepeatError(stream *ResultStream, ruleType string, tokens []model.Token, err *model.RuleError) {
+	if err == nil {
+		err = &model.RuleError{RuleType: ruleType}
+		if len(tokens) > 0 {
+			err.Token = tokens[0]
+		}
+	}
+	stream.Send(&model.RuleResult{
+		Match:           nil,
+		RemainingTokens: tokens,
+		Error:           err,
+	})
+	stream.Continue()
+}
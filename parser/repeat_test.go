@@ -0,0 +1,125 @@
+package parser
+
+import (
+	"github.com/jsanchesleao/grammatic/model"
+	"testing"
+)
+
+func TestRepeat(t *testing.T) {
+	rule := Repeat("Ints", RuleTokenType("Int", "TOKEN_INT"), 2, 3)
+	tokens := []model.Token{int_token, int_token, int_token, int_token, eof_token}
+
+	iterator := rule.Check(tokens)
+
+	results := []*model.RuleResult{
+		iterator.Next(),
+		iterator.Next(),
+		iterator.Next(),
+	}
+
+	if results[0] == nil || results[1] == nil {
+		t.Fatalf("Expected two results, but found %+v", results)
+	}
+	if results[2] != nil {
+		t.Fatalf("Expected third result to be nil, but it was %+v", results[2])
+	}
+
+	intNode := model.Node{Type: "Int", Token: &int_token}
+
+	model.AssertNodeEquals(t, model.Node{
+		Type:  "Ints",
+		Rules: []model.Node{intNode, intNode, intNode},
+	}, *results[0].Match)
+	model.AssertTokenList(t, []model.Token{int_token, eof_token}, results[0].RemainingTokens)
+
+	model.AssertNodeEquals(t, model.Node{
+		Type:  "Ints",
+		Rules: []model.Node{intNode, intNode},
+	}, *results[1].Match)
+	model.AssertTokenList(t, []model.Token{int_token, int_token, eof_token}, results[1].RemainingTokens)
+}
+
+func TestRepeatFail(t *testing.T) {
+	rule := Repeat("Ints", RuleTokenType("Int", "TOKEN_INT"), 2, Unbounded)
+	tokens := []model.Token{int_token, comma_token, eof_token}
+
+	iterator := rule.Check(tokens)
+	result := iterator.Next()
+
+	if result == nil || result.Error == nil {
+		t.Fatalf("Expected an error, but found %+v", result)
+	}
+	model.AssertTokenEquals(t, comma_token, result.Error.Token)
+
+	if next := iterator.Next(); next != nil {
+		t.Fatalf("Expected second result to be nil, but it was %+v", next)
+	}
+}
+
+func TestRepeatZero(t *testing.T) {
+	rule := Repeat("Ints", RuleTokenType("Int", "TOKEN_INT"), 0, 0)
+	tokens := []model.Token{int_token, eof_token}
+
+	iterator := rule.Check(tokens)
+	result := iterator.Next()
+
+	if result == nil || result.Error != nil {
+		t.Fatalf("Expected an empty match, but found %+v", result)
+	}
+	model.AssertNodeEquals(t, model.Node{Type: "Ints", Rules: []model.Node{}}, *result.Match)
+	model.AssertTokenList(t, tokens, result.RemainingTokens)
+}
+
+func TestRepeatWithSeparator(t *testing.T) {
+	rule := RepeatWithSeparator("IntByCommas",
+		RuleTokenType("Int", "TOKEN_INT"),
+		RuleTokenType("Comma", "TOKEN_COMMA"),
+		1, 2,
+	)
+	tokens := []model.Token{int_token, comma_token, int_token, comma_token, int_token, eof_token}
+
+	iterator := rule.Check(tokens)
+
+	results := []*model.RuleResult{
+		iterator.Next(),
+		iterator.Next(),
+		iterator.Next(),
+	}
+
+	if results[0] == nil || results[1] == nil {
+		t.Fatalf("Expected two results, but found %+v", results)
+	}
+	if results[2] != nil {
+		t.Fatalf("Expected third result to be nil, but it was %+v", results[2])
+	}
+
+	intNode := model.Node{Type: "Int", Token: &int_token}
+	commaNode := model.Node{Type: "Comma", Token: &comma_token}
+
+	model.AssertNodeEquals(t, model.Node{
+		Type:  "IntByCommas",
+		Rules: []model.Node{intNode, commaNode, intNode},
+	}, *results[0].Match)
+	model.AssertTokenList(t, []model.Token{comma_token, int_token, eof_token}, results[0].RemainingTokens)
+
+	model.AssertNodeEquals(t, model.Node{
+		Type:  "IntByCommas",
+		Rules: []model.Node{intNode},
+	}, *results[1].Match)
+}
+
+func TestRepeatWithSeparatorFail(t *testing.T) {
+	rule := RepeatWithSeparator("IntByCommas",
+		RuleTokenType("Int", "TOKEN_INT"),
+		RuleTokenType("Comma", "TOKEN_COMMA"),
+		2, Unbounded,
+	)
+	tokens := []model.Token{int_token, comma_token, keyword_token, eof_token}
+
+	result := rule.Check(tokens).Next()
+
+	if result == nil || result.Error == nil {
+		t.Fatalf("Expected an error, but found %+v", result)
+	}
+	model.AssertTokenEquals(t, keyword_token, result.Error.Token)
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"github.com/jsanchesleao/grammatic/model"
+	"sync"
 )
 
 const (
@@ -13,65 +14,82 @@ const (
 )
 
 type ResultStream struct {
-	feed    chan *model.RuleResult
 	control chan int
 	output  chan *model.RuleResult
-	done    bool
+	closed  chan struct{}
+	once    sync.Once
 
 	NodeMapper func(model.Node) model.Node
 }
 
 func NewResultStream() *ResultStream {
-	stream := ResultStream{
-		feed:       make(chan *model.RuleResult),
+	stream := &ResultStream{
 		control:    make(chan int),
 		output:     make(chan *model.RuleResult),
-		done:       false,
+		closed:     make(chan struct{}),
 		NodeMapper: func(node model.Node) model.Node { return node },
 	}
 
-	return &stream
+	return stream
 }
 
-func (s ResultStream) Continue() bool {
-	control := <-s.control
-	return control == CONTROL_CONTINUE
+func (s *ResultStream) Continue() bool {
+	select {
+	case control := <-s.control:
+		return control == CONTROL_CONTINUE
+	case <-s.closed:
+		return false
+	}
 }
 
-func (s ResultStream) Send(result *model.RuleResult) {
-	if s.done {
+func (s *ResultStream) isDone() bool {
+	select {
+	case <-s.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *ResultStream) Send(result *model.RuleResult) {
+	if s.isDone() {
 		return
 	}
 	if result != nil && result.Match != nil {
 		match := s.NodeMapper(*result.Match)
-		s.output <- &model.RuleResult{
+		result = &model.RuleResult{
 			Match:           &match,
 			RemainingTokens: result.RemainingTokens,
 			Error:           result.Error,
 		}
-	} else {
-		s.output <- result
+	}
+	select {
+	case s.output <- result:
+	case <-s.closed:
 	}
 }
 
 func (s *ResultStream) Next() *model.RuleResult {
-	if s.done {
+	if s.isDone() {
 		return nil
 	}
 
-	defer func() {
-		recover()
-	}()
+	select {
+	case s.control <- CONTROL_CONTINUE:
+	case <-s.closed:
+		return nil
+	}
 
-	s.control <- CONTROL_CONTINUE
-	value := <-s.output
-	return value
+	select {
+	case value := <-s.output:
+		return value
+	case <-s.closed:
+		return nil
+	}
 }
 
 func (s *ResultStream) Done() {
-	if !s.done {
-		s.done = true
-		close(s.output)
-		close(s.control)
-	}
+	s.once.Do(func() {
+		close(s.closed)
+	})
 }
@@ -1,14 +1,15 @@
 package parser
 
 import (
+	"context"
 	"fmt"
 	"github.com/jsanchesleao/grammatic/model"
 )
 
 func Seq(ruleType string, rules ...*model.Rule) *model.Rule {
-	return &model.Rule{
+	return withContext(&model.Rule{
 		Type: ruleType,
-		Check: func(tokens []model.Token) model.RuleResultIterator {
+		CheckContext: func(ctx context.Context, tokens []model.Token) model.RuleResultIterator {
 			stream := NewResultStream()
 
 			go func() {
@@ -31,10 +32,15 @@ func Seq(ruleType string, rules ...*model.Rule) *model.Rule {
 					return
 				}
 
+				if IsCut(rules[0]) {
+					checkCommitted(ctx, stream, ruleType, rules[1:], tokens)
+					return
+				}
+
 				headRule := rules[0]
 				tailRule := Seq(fmt.Sprintf("%s:Seq", ruleType), rules[1:]...)
 
-				headIterator := headRule.Check(tokens)
+				headIterator := checkRule(ctx, headRule, tokens)
 				var error *model.RuleError = nil
 				for {
 					headResult := headIterator.Next()
@@ -42,12 +48,16 @@ func Seq(ruleType string, rules ...*model.Rule) *model.Rule {
 						break
 					}
 					if headResult.Error != nil {
+						if headResult.Error.Fatal {
+							sendFatal(stream, tokens, headResult.Error, headIterator)
+							return
+						}
 						if error == nil || headResult.Error.Token.IsAfter(error.Token) {
 							error = headResult.Error
 						}
 						continue
 					}
-					tailIterator := tailRule.Check(headResult.RemainingTokens)
+					tailIterator := continueRule(ctx, tailRule, headResult.RemainingTokens)
 				tail:
 					for {
 						tailResult := tailIterator.Next()
@@ -56,6 +66,10 @@ func Seq(ruleType string, rules ...*model.Rule) *model.Rule {
 							break tail
 						}
 						if tailResult.Error != nil {
+							if tailResult.Error.Fatal {
+								sendFatal(stream, tokens, tailResult.Error, tailIterator, headIterator)
+								return
+							}
 							if error == nil || tailResult.Error.Token.IsAfter(error.Token) {
 								error = tailResult.Error
 							}
@@ -98,5 +112,5 @@ func Seq(ruleType string, rules ...*model.Rule) *model.Rule {
 
 			return stream
 		},
-	}
+	})
 }
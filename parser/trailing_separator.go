@@ -0,0 +1,122 @@
+package parser
+
+import (
+	"context"
+	"github.com/jsanchesleao/grammatic/model"
+)
+
+// Same as ManyWithSeparator, but also accepts an optional separator after the last item
+func ManyWithSeparatorTrailing(typeName string, rule *model.Rule, separator *model.Rule) *model.Rule {
+	return withTrailingSeparator(typeName, ManyWithSeparator(typeName, rule, separator), separator)
+}
+
+// Same as OneOrManyWithSeparator, but also accepts an optional separator after the last item
+func OneOrManyWithSeparatorTrailing(typeName string, rule *model.Rule, separator *model.Rule) *model.Rule {
+	return withTrailingSeparator(typeName, OneOrManyWithSeparator(typeName, rule, separator), separator)
+}
+
+// Same as RepeatWithSeparator, but also accepts an optional separator after the last item
+func RepeatWithSeparatorTrailing(typeName string, rule *model.Rule, separator *model.Rule, min, max int) *model.Rule {
+	return withTrailingSeparator(typeName, RepeatWithSeparator(typeName, rule, separator, min, max), separator)
+}
+
+// Extends every non empty match of a separated list with an optional trailing separator.
+// The trailing separator is kept as the last node of the match, and matches including it are produced first
+func withTrailingSeparator(typeName string, list *model.Rule, separator *model.Rule) *model.Rule {
+	return withContext(&model.Rule{
+		Type: typeName,
+		CheckContext: func(ctx context.Context, tokens []model.Token) model.RuleResultIterator {
+
+			stream := NewResultStream()
+
+			go func() {
+
+				if !stream.Continue() {
+					stream.Done()
+					return
+				}
+
+				success := false
+				var error *model.RuleError = nil
+				iterator := checkRule(ctx, list, tokens)
+
+				for {
+					result := iterator.Next()
+
+					if result == nil {
+						iterator.Done()
+						break
+					}
+
+					if result.Error != nil {
+						if result.Error.Fatal {
+							sendFatal(stream, tokens, result.Error, iterator)
+							return
+						}
+						if error == nil || result.Error.Token.IsAfter(error.Token) {
+							error = result.Error
+						}
+						continue
+					}
+
+					success = true
+
+					if len(result.Match.Rules) > 0 {
+						separatorIterator := checkRule(ctx, separator, result.RemainingTokens)
+					inner:
+						for {
+							separatorResult := separatorIterator.Next()
+
+							if separatorResult == nil {
+								separatorIterator.Done()
+								break inner
+							}
+
+							if separatorResult.Error != nil {
+								if separatorResult.Error.Fatal {
+									sendFatal(stream, tokens, separatorResult.Error, separatorIterator, iterator)
+									return
+								}
+								continue inner
+							}
+
+							nodes := append([]model.Node{}, result.Match.Rules...)
+							stream.Send(&model.RuleResult{
+								Match: &model.Node{
+									Type:  typeName,
+									Token: nil,
+									Rules: append(nodes, *separatorResult.Match),
+								},
+								RemainingTokens: separatorResult.RemainingTokens,
+								Error:           nil,
+							})
+							if !stream.Continue() {
+								separatorIterator.Done()
+								iterator.Done()
+								stream.Done()
+								return
+							}
+						}
+					}
+
+					stream.Send(result)
+					if !stream.Continue() {
+						iterator.Done()
+						stream.Done()
+						return
+					}
+				}
+
+				if !success {
+					sendRepeatError(stream, typeName, tokens, error)
+				}
+
+				stream.Done()
+
+			}()
+
+			return stream
+
+		},
+	})
+}
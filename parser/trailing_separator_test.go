@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"github.com/jsanchesleao/grammatic/model"
+	"testing"
+)
+
+func TestManyWithSeparatorTrailing(t *testing.T) {
+	rule := ManyWithSeparatorTrailing("IntByCommas",
+		RuleTokenType("IntRule", "TOKEN_INT"),
+		RuleTokenType("Comma", "TOKEN_COMMA"),
+	)
+	tokens := []model.Token{int_token, comma_token, int_token, comma_token, eof_token}
+
+	iterator := rule.Check(tokens)
+	result := iterator.Next()
+
+	if result == nil || result.Error != nil {
+		t.Fatalf("Expected first result to be a match, but it was %+v", result)
+	}
+
+	intNode := model.Node{Type: "IntRule", Token: &int_token}
+	commaNode := model.Node{Type: "Comma", Token: &comma_token}
+
+	model.AssertNodeEquals(t, model.Node{
+		Type:  "IntByCommas",
+		Rules: []model.Node{intNode, commaNode, intNode, commaNode},
+	}, *result.Match)
+	model.AssertTokenList(t, []model.Token{eof_token}, result.RemainingTokens)
+
+	result = iterator.Next()
+	if result == nil || result.Error != nil {
+		t.Fatalf("Expected second result to be a match, but it was %+v", result)
+	}
+	model.AssertNodeEquals(t, model.Node{
+		Type:  "IntByCommas",
+		Rules: []model.Node{intNode, commaNode, intNode},
+	}, *result.Match)
+	model.AssertTokenList(t, []model.Token{comma_token, eof_token}, result.RemainingTokens)
+
+	iterator.Done()
+}
+
+func TestManyWithSeparatorTrailingEmpty(t *testing.T) {
+	rule := ManyWithSeparatorTrailing("IntByCommas",
+		RuleTokenType("IntRule", "TOKEN_INT"),
+		RuleTokenType("Comma", "TOKEN_COMMA"),
+	)
+	tokens := []model.Token{comma_token, eof_token}
+
+	iterator := rule.Check(tokens)
+	result := iterator.Next()
+
+	if result == nil || result.Error != nil {
+		t.Fatalf("Expected an empty match, but it was %+v", result)
+	}
+	model.AssertNodeEquals(t, model.Node{Type: "IntByCommas", Rules: []model.Node{}}, *result.Match)
+	model.AssertTokenList(t, tokens, result.RemainingTokens)
+
+	if next := iterator.Next(); next != nil {
+		t.Fatalf("Expected a lone separator to not be matched, but found %+v", next)
+	}
+}
+
+func TestOneOrManyWithSeparatorTrailingFail(t *testing.T) {
+	rule := OneOrManyWithSeparatorTrailing("IntByCommas",
+		RuleTokenType("IntRule", "TOKEN_INT"),
+		RuleTokenType("Comma", "TOKEN_COMMA"),
+	)
+	tokens := []model.Token{comma_token, eof_token}
+
+	result := rule.Check(tokens).Next()
+
+	if result == nil || result.Error == nil {
+		t.Fatalf("Expected an error, but found %+v", result)
+	}
+}
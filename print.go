@@ -0,0 +1,288 @@
+package grammatic
+
+import (
+	"fmt"
+	"github.com/jsanchesleao/grammatic/model"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Or rules longer than this are written with one alternative per line
+const printLineWidth = 80
+
+var printableRuleName = regexp.MustCompile(`^(?i)_*[a-z][-_\w]*(\._*[a-z][-_\w]*)*$`)
+
+// Returns the grammar written in the grammar language, as done by WriteTo
+func (g *Grammar) String() string {
+	builder := &strings.Builder{}
+	g.WriteTo(builder)
+	return builder.String()
+}
+
+// Writes the grammar in the grammar language, so it can be shared as text or loaded again with Compile.
+// Production rules come first, then templates, token rules in the order the lexer tries them, and virtual tokens.
+// Anonymous groups and literals are written where they are used. Rules that the language cannot express,
+// like the ones created by custom combinators or tokens whose pattern does not start with ^, are written as # comments instead.
+// Actions and token reducers are Go functions, so they are left out
+func (g *Grammar) WriteTo(w io.Writer) (int64, error) {
+	sections := []string{}
+	notes := []string{}
+
+	productions := []string{}
+	tokens := []string{}
+	virtuals := []string{}
+	for _, name := range g.ruleOrder {
+		definition := g.definitions[name]
+		switch {
+		case isLiteralName(name) || isGroupName(name) || g.isTemplateRule(name):
+			continue
+		case !printableRuleName.MatchString(name):
+			notes = append(notes, fmt.Sprintf("# %s cannot be written, because its name is not valid in the grammar language", name))
+		case definition.kind == kindVirtualToken:
+			virtuals = append(virtuals, name)
+		case definition.kind == kindToken && !strings.HasPrefix(definition.pattern, "^"):
+			// the grammar language anchors every pattern, which would change what the lexer matches
+			notes = append(notes, fmt.Sprintf("# %s cannot be written, because its pattern %q does not start with ^", name, definition.pattern))
+		case definition.kind == kindToken:
+			tokens = append(tokens, name)
+		case definition.kind == "":
+			notes = append(notes, fmt.Sprintf("# %s cannot be written, because it was created by a custom combinator", name))
+		default:
+			if g.droppedRules[name] {
+				notes = append(notes, fmt.Sprintf("# %s is dropped from the tree, which the grammar language can only do for tokens", name))
+			}
+			text, err := g.printExpression(definition, true)
+			if err != nil {
+				notes = append(notes, fmt.Sprintf("# %s cannot be written, because %v", name, err))
+				continue
+			}
+			productions = append(productions, g.printProduction(name, text, definition))
+		}
+	}
+	sections = append(sections, productions...)
+
+	templates := []string{}
+	for name := range g.templates {
+		templates = append(templates, name)
+	}
+	sort.Strings(templates)
+	for _, name := range templates {
+		sections = append(sections, joinTokens(leafTokens(g.templates[name].definition)))
+	}
+
+	if len(tokens) > 0 {
+		sections = append(sections, g.printTokens(tokens))
+	}
+	if len(virtuals) > 0 {
+		sections = append(sections, ":virtual: "+strings.Join(virtuals, " "))
+	}
+	if len(notes) > 0 {
+		sections = append(sections, strings.Join(notes, "\n"))
+	}
+
+	written, err := io.WriteString(w, strings.Join(sections, "\n\n")+"\n")
+	return int64(written), err
+}
+
+// Same as Grammar.String
+func (c *Compiled) String() string {
+	return c.grammar.String()
+}
+
+// Same as Grammar.WriteTo
+func (c *Compiled) WriteTo(w io.Writer) (int64, error) {
+	return c.grammar.WriteTo(w)
+}
+
+func isGroupName(name string) bool {
+	return strings.HasPrefix(name, "(group ")
+}
+
+// Checks if the rule was created for an instance of a template, like List<Item> or List<Item>.Items,
+// which are created again from the template definition
+func (g *Grammar) isTemplateRule(name string) bool {
+	for _, template := range g.templates {
+		for instance := range template.instances {
+			if name == instance || strings.HasPrefix(name, instance+NamespaceSeparator) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Returns the tokens of the leaves of the node, in order
+func leafTokens(node *model.Node) []model.Token {
+	if node.Token != nil {
+		return []model.Token{*node.Token}
+	}
+	tokens := []model.Token{}
+	for i := range node.Rules {
+		tokens = append(tokens, leafTokens(&node.Rules[i])...)
+	}
+	return tokens
+}
+
+func (g *Grammar) printProduction(name, text string, definition ruleDefinition) string {
+	flags := ""
+	if g.inlineRules[name] {
+		flags += " (inline)"
+	}
+	if g.collapsedRules[name] {
+		flags += " (collapse)"
+	}
+
+	head := name + " := "
+	if definition.kind == kindOr && len(head)+len(text)+len(flags) > printLineWidth {
+		alternatives := []string{}
+		for _, rule := range definition.rules {
+			alternatives = append(alternatives, g.printReference(rule, false))
+		}
+		indent := strings.Repeat(" ", len(head)-2)
+		text = strings.Join(alternatives, "\n"+indent+"| ")
+	}
+	return head + text + flags
+}
+
+// Returns the token rules with their := aligned, each with its pattern between slashes
+func (g *Grammar) printTokens(names []string) string {
+	// the lexer tries the tokens in the order of TokenDefs, so they are written in that order
+	position := map[string]int{}
+	for i, def := range g.TokenDefs {
+		position[def.Type] = i
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return position[names[i]] < position[names[j]]
+	})
+
+	width := 0
+	for _, name := range names {
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+
+	lines := []string{}
+	for _, name := range names {
+		definition := g.definitions[name]
+		pattern := strings.TrimPrefix(definition.pattern, "^")
+		line := fmt.Sprintf("%-*s := /%s/", width, name, strings.ReplaceAll(escapeControls(pattern), "/", "\\/"))
+		if definition.ignored {
+			line += " (ignore)"
+		} else if g.droppedRules[name] {
+			line += " (drop)"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Returns the regular expression with its control characters written as escapes, like \n or \x{1B},
+// which match the same characters and keep the pattern on a single line
+func escapeControls(pattern string) string {
+	builder := strings.Builder{}
+	for _, char := range pattern {
+		switch {
+		case char == '\t':
+			builder.WriteString("\\t")
+		case char == '\n':
+			builder.WriteString("\\n")
+		case char == '\r':
+			builder.WriteString("\\r")
+		case char == ' ' || unicode.IsPrint(char):
+			builder.WriteRune(char)
+		default:
+			fmt.Fprintf(&builder, "\\x{%X}", char)
+		}
+	}
+	return builder.String()
+}
+
+// Returns the expression of the grammar language that builds the definition.
+// At the top of a rule, a rename of a group is written as the expression of the group
+func (g *Grammar) printExpression(definition ruleDefinition, top bool) (string, error) {
+	item := func() string {
+		return g.printReference(definition.rules[0], true)
+	}
+	separator := func(trailing bool) string {
+		text := g.printReference(definition.separator, true)
+		if trailing {
+			text += ","
+		}
+		return "[" + text + "]"
+	}
+
+	switch definition.kind {
+	case kindSeq:
+		if len(definition.rules) < 2 && top {
+			return "", fmt.Errorf("the grammar language has no sequences of %d rules", len(definition.rules))
+		}
+		items := []string{}
+		for _, rule := range definition.rules {
+			items = append(items, g.printReference(rule, false))
+		}
+		return strings.Join(items, " "), nil
+	case kindOr:
+		if len(definition.rules) < 2 {
+			return "", fmt.Errorf("the grammar language has no alternatives of %d rules", len(definition.rules))
+		}
+		alternatives := []string{}
+		for _, rule := range definition.rules {
+			alternatives = append(alternatives, g.printReference(rule, false))
+		}
+		return strings.Join(alternatives, " | "), nil
+	case kindRename:
+		if groupDefinition, ok := g.definitions[definition.rules[0]]; ok && isGroupName(definition.rules[0]) {
+			return g.printExpression(groupDefinition, top)
+		}
+		return g.printReference(definition.rules[0], false), nil
+	case kindOneOrNone:
+		return item() + "?", nil
+	case kindMany:
+		return item() + "*", nil
+	case kindOneOrMany:
+		return item() + "+", nil
+	case kindManyWithSeparator:
+		return item() + separator(definition.trailing) + "*", nil
+	case kindOneOrManyWithSeparator:
+		return item() + separator(definition.trailing) + "+", nil
+	case kindRepeat:
+		return item() + printCount(definition.min, definition.max), nil
+	case kindRepeatWithSeparator:
+		return item() + separator(definition.trailing) + printCount(definition.min, definition.max), nil
+	}
+	return "", fmt.Errorf("rules of kind %q have no expression", definition.kind)
+}
+
+// Returns the text that refers to the rule inside an expression. Groups are written in parentheses,
+// which can be left out for repetitions in sequences and alternatives, unless the reference is itself repeated
+func (g *Grammar) printReference(name string, repeated bool) string {
+	definition, ok := g.definitions[name]
+	if !ok || !isGroupName(name) {
+		return name
+	}
+	text, err := g.printExpression(definition, false)
+	if err != nil {
+		return name
+	}
+	if definition.kind == kindRename && !isGroupName(definition.rules[0]) {
+		return text
+	}
+	if !repeated && (isRepetitionKind(definition.kind) || definition.kind == kindOneOrNone) {
+		return text
+	}
+	return "(" + text + ")"
+}
+
+func printCount(min, max int) string {
+	switch {
+	case min == max:
+		return fmt.Sprintf("{%d}", min)
+	case max < 0:
+		return fmt.Sprintf("{%d,}", min)
+	}
+	return fmt.Sprintf("{%d,%d}", min, max)
+}
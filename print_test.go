@@ -0,0 +1,306 @@
+package grammatic
+
+import (
+	"fmt"
+	"github.com/jsanchesleao/grammatic/model"
+	"github.com/jsanchesleao/grammatic/parser"
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGrammarString(t *testing.T) {
+	grammar := Compile(`
+Program := Statement[';',]* (collapse)
+Statement := ('let'i ^ Name '=' Value)
+           | (Value Value?)
+           | Delimited<'(', Value, ')'>
+Value := Number{1,3} | (Name | Number)[',']{2,}
+_Item := Name
+Delimited<Open, Item, Close> := Open Item* as Items Close
+Name := /[a-z]\/\w*/
+Number := $DigitsFormat (drop)
+Space := $EmptySpaceFormat (ignore)
+:virtual: Indent Dedent`)
+	grammar.DefineRule("Custom", GrammarCombinator{
+		Create: func(ruleType string) *model.Rule {
+			return parser.RuleTokenType(ruleType, "Name")
+		},
+	})
+
+	expected := `Program := Statement[';',]* (collapse)
+
+Statement := ('let'i ^ Name '=' Value)
+           | (Value Value?)
+           | Delimited<'(', Value, ')'>
+
+Value := Number{1,3} | (Name | Number)[',']{2,}
+
+_Item := Name
+
+Delimited<Open, Item, Close> := Open Item* as Items Close
+
+Name   := /[a-z]\/\w*/
+Number := /\d+/ (drop)
+Space  := /\s+/ (ignore)
+
+:virtual: Indent Dedent
+
+# Custom cannot be written, because it was created by a custom combinator
+`
+	if text := grammar.String(); text != expected {
+		t.Fatalf("Unexpected grammar text\n%s", text)
+	}
+	if text := grammar.Freeze().String(); text != expected {
+		t.Fatalf("Expected the same text for the frozen grammar, got\n%s", text)
+	}
+}
+
+func TestGrammarStringSplitsLongAlternatives(t *testing.T) {
+	grammar := NewGrammar()
+	grammar.DefineRule("Statement", grammar.Or("SelectStatement", "InsertStatement", "UpdateStatement", "DeleteStatement"))
+
+	expected := `Statement := SelectStatement
+           | InsertStatement
+           | UpdateStatement
+           | DeleteStatement
+`
+	if text := grammar.String(); text != expected {
+		t.Fatalf("Unexpected grammar text\n%s", text)
+	}
+}
+
+func TestGrammarStringEscapesControlCharacters(t *testing.T) {
+	grammar := NewGrammar()
+	grammar.DefineToken("Newline", "^\r?\n")
+	grammar.DefineToken("Indent", "^\t+")
+	grammar.DefineToken("Bell", "^\a")
+	grammar.DefineRule("Line", grammar.Seq("Indent", "Bell", "Newline"))
+
+	expected := `Line := Indent Bell Newline
+
+Newline := /\r?\n/
+Indent  := /\t+/
+Bell    := /\x{7}/
+`
+	text := grammar.String()
+	if text != expected {
+		t.Fatalf("Unexpected grammar text\n%s", text)
+	}
+
+	loaded := NewGrammar()
+	if err := loaded.Load(text); err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range []*Grammar{&grammar, &loaded} {
+		tree, err := g.Parse("Line", "\t\t\a\r\n")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tree.GetNodeWithType("Line").GetNodeWithType("Newline").Token.Value != "\r\n" {
+			t.Fatalf("Unexpected tree:\n%s", tree.PrettyPrint())
+		}
+	}
+}
+
+func TestGrammarStringUnanchoredToken(t *testing.T) {
+	grammar := NewGrammar()
+	grammar.DefineToken("Word", "^[a-z]+")
+	grammar.DefineToken("Number", "\\d+")
+	grammar.DefineRule("Pair", grammar.Seq("Word", "Number"))
+
+	expected := `Pair := Word Number
+
+Word := /[a-z]+/
+
+# Number cannot be written, because its pattern "\\d+" does not start with ^
+`
+	if text := grammar.String(); text != expected {
+		t.Fatalf("Unexpected grammar text\n%s", text)
+	}
+}
+
+func TestGrammarStringRoundTrip(t *testing.T) {
+	grammars := map[string]*Grammar{}
+	parsingGrammar := GrammarParsingGrammar()
+	grammars["GrammarParsingGrammar"] = &parsingGrammar
+	for name, text := range repositoryGrammars(t) {
+		grammar := NewGrammar()
+		if err := grammar.Load(text); err != nil {
+			t.Fatalf("%s: the grammar does not load: %v", name, err)
+		}
+		grammars[name] = &grammar
+	}
+	if len(grammars) < 10 {
+		t.Fatalf("Expected to find the grammars of the repository, found %d", len(grammars))
+	}
+
+	for name, original := range grammars {
+		text := original.String()
+		loaded := NewGrammar()
+		if err := loaded.Load(text); err != nil {
+			t.Fatalf("%s: the written grammar does not load: %v\n%s", name, err, text)
+		}
+		if difference := compareGrammars(original, &loaded); difference != "" {
+			t.Fatalf("%s: the written grammar is not equivalent, %s\n%s", name, difference, text)
+		}
+		if again := loaded.String(); again != text {
+			t.Fatalf("%s: writing the loaded grammar gave a different text\n%s", name, again)
+		}
+	}
+}
+
+// Returns the grammar files and the grammar texts given to Compile in the Go files of the repository, by where they were found.
+// Texts are found when they are a string literal in the call, or a constant or variable of the same file
+func repositoryGrammars(t *testing.T) map[string]string {
+	texts := map[string]string{}
+	err := filepath.WalkDir(".", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		switch filepath.Ext(path) {
+		case ".grammar":
+			text, err := os.ReadFile(path)
+			texts[path] = string(text)
+			return err
+		case ".go":
+			fileSet := token.NewFileSet()
+			file, err := goparser.ParseFile(fileSet, path, nil, 0)
+			if err != nil {
+				return err
+			}
+
+			declared := map[string]*ast.BasicLit{}
+			ast.Inspect(file, func(node ast.Node) bool {
+				if spec, ok := node.(*ast.ValueSpec); ok {
+					for i, name := range spec.Names {
+						if i < len(spec.Values) {
+							if literal, ok := spec.Values[i].(*ast.BasicLit); ok {
+								declared[name.Name] = literal
+							}
+						}
+					}
+				}
+				return true
+			})
+
+			ast.Inspect(file, func(node ast.Node) bool {
+				call, ok := node.(*ast.CallExpr)
+				if !ok || len(call.Args) != 1 || !isCompileCall(call) {
+					return true
+				}
+				literal, ok := call.Args[0].(*ast.BasicLit)
+				if ident, isIdent := call.Args[0].(*ast.Ident); isIdent {
+					literal, ok = declared[ident.Name]
+				}
+				if ok && literal.Kind == token.STRING {
+					if text, err := strconv.Unquote(literal.Value); err == nil {
+						texts[fileSet.Position(literal.Pos()).String()] = text
+					}
+				}
+				return true
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return texts
+}
+
+// Checks if the call is Compile or grammatic.Compile, and not the Compile of another package like regexp
+func isCompileCall(call *ast.CallExpr) bool {
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		return fun.Name == "Compile"
+	case *ast.SelectorExpr:
+		pkg, ok := fun.X.(*ast.Ident)
+		return ok && pkg.Name == "grammatic" && fun.Sel.Name == "Compile"
+	}
+	return false
+}
+
+// Returns how the grammars differ, or an empty string if they have the same rules, tokens and settings.
+// Anonymous groups are compared by their definitions, and Memo is ignored, since it doesn't change the results
+func compareGrammars(first, second *Grammar) string {
+	names := func(g *Grammar) []string {
+		result := []string{}
+		for _, name := range g.ruleOrder {
+			if !isGroupName(name) {
+				result = append(result, name)
+			}
+		}
+		sort.Strings(result)
+		return result
+	}
+	if !reflect.DeepEqual(names(first), names(second)) {
+		return fmt.Sprintf("the rules are %v and %v", names(first), names(second))
+	}
+	for _, name := range names(first) {
+		if canonicalRule(first, name) != canonicalRule(second, name) {
+			return fmt.Sprintf("rule %s is %s and %s", name, canonicalRule(first, name), canonicalRule(second, name))
+		}
+	}
+
+	tokenTypes := func(g *Grammar) []string {
+		result := []string{}
+		for _, def := range g.TokenDefs {
+			result = append(result, def.Type)
+		}
+		return result
+	}
+	if !reflect.DeepEqual(tokenTypes(first), tokenTypes(second)) {
+		return fmt.Sprintf("the tokens are %v and %v", tokenTypes(first), tokenTypes(second))
+	}
+
+	settings := func(g *Grammar) string {
+		names := func(set map[string]bool) []string {
+			result := []string{}
+			for name := range set {
+				if !isGroupName(name) {
+					result = append(result, name)
+				}
+			}
+			sort.Strings(result)
+			return result
+		}
+		ignored := append([]string{}, g.IgnoredTokenTypes...)
+		sort.Strings(ignored)
+		return fmt.Sprint(names(g.inlineRules), names(g.droppedRules), names(g.collapsedRules), ignored)
+	}
+	if settings(first) != settings(second) {
+		return fmt.Sprintf("the settings are %s and %s", settings(first), settings(second))
+	}
+	return ""
+}
+
+func canonicalRule(g *Grammar, name string) string {
+	definition, ok := g.definitions[name]
+	if !ok {
+		return name
+	}
+	if definition.kind == kindRename && isGroupName(definition.rules[0]) {
+		return canonicalRule(g, definition.rules[0])
+	}
+	reference := func(rule string) string {
+		if isGroupName(rule) {
+			return canonicalRule(g, rule)
+		}
+		return rule
+	}
+	rules := []string{}
+	for _, rule := range definition.rules {
+		rules = append(rules, reference(rule))
+	}
+	return fmt.Sprintf("%s(%s [%s] %d %d %v %q %v %q %v)", definition.kind, strings.Join(rules, " "), reference(definition.separator),
+		definition.min, definition.max, definition.trailing, definition.pattern, definition.ignored, definition.value, definition.ignoreCase)
+}
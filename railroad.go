@@ -0,0 +1,446 @@
+package grammatic
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Sizes of the railroad diagrams, in pixels
+const (
+	railroadArc       = 10
+	railroadGap       = 10
+	railroadPadding   = 20
+	railroadBoxHeight = 22
+	railroadCharWidth = 8
+	railroadNoteSpace = 14
+)
+
+const railroadStyle = `svg.railroad { background: #fff; }
+svg.railroad path { stroke: #333; stroke-width: 1.5; fill: none; }
+svg.railroad rect { stroke: #333; stroke-width: 1.5; fill: #eef2ff; }
+svg.railroad rect.terminal { fill: #eaf6ea; }
+svg.railroad text { font: 13px monospace; text-anchor: middle; fill: #000; }
+svg.railroad a text { fill: #0550ae; }
+svg.railroad text.comment, svg.railroad text.note { font: italic 12px sans-serif; }`
+
+// The kinds of the parts of a railroad diagram
+const (
+	railroadTerminal = iota
+	railroadNonTerminal
+	railroadComment
+	railroadSkip
+	railroadSequence
+	railroadChoice
+	railroadOptional
+	railroadLoop
+)
+
+// A part of a railroad diagram. Its width, and how far it reaches above and below the line that goes through it,
+// are set by measure before it is drawn
+type railroadNode struct {
+	kind      int
+	label     string
+	link      string
+	items     []*railroadNode
+	separator *railroadNode
+	note      string
+
+	width   int
+	up      int
+	down    int
+	offsets []int
+}
+
+// Returns the name of the SVG file written for the rule by the grammatic command,
+// which is also where the standalone diagrams of WriteRailroadSVG link to
+func RailroadFileName(rule string) string {
+	return url.PathEscape(rule) + ".svg"
+}
+
+// Writes a standalone SVG railroad diagram of the rule. Rules it refers to are links to their own SVG files,
+// named by RailroadFileName
+func (g *Grammar) WriteRailroadSVG(w io.Writer, rule string) error {
+	if _, ok := g.definitions[rule]; !ok {
+		return fmt.Errorf("Undefined rule %q", rule)
+	}
+	builder := &railroadBuilder{grammar: g, link: RailroadFileName}
+	_, err := io.WriteString(w, builder.diagram(rule, true))
+	return err
+}
+
+// Writes an HTML page with the railroad diagram of every rule, except anonymous groups and literals,
+// in the order they were defined. Rules that are used in a diagram link to their own diagrams
+func (g *Grammar) WriteRailroadHTML(w io.Writer, title string) error {
+	builder := &railroadBuilder{grammar: g, link: func(rule string) string {
+		return "#" + railroadID(rule)
+	}}
+
+	page := &strings.Builder{}
+	fmt.Fprintf(page, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n", html.EscapeString(title))
+	fmt.Fprintf(page, "<style>\nbody { font-family: sans-serif; margin: 2em; }\nh2 { font: bold 15px monospace; margin: 1.5em 0 0.5em; }\n%s\n</style>\n", railroadStyle)
+	fmt.Fprintf(page, "</head>\n<body>\n<h1>%s</h1>\n", html.EscapeString(title))
+	for _, rule := range g.ruleOrder {
+		if isLiteralName(rule) || isGroupName(rule) {
+			continue
+		}
+		fmt.Fprintf(page, "<section id=\"%s\">\n<h2>%s</h2>\n%s</section>\n", html.EscapeString(railroadID(rule)), html.EscapeString(rule), builder.diagram(rule, false))
+	}
+	page.WriteString("</body>\n</html>\n")
+
+	_, err := io.WriteString(w, page.String())
+	return err
+}
+
+func railroadID(rule string) string {
+	return "rule-" + strings.Map(func(char rune) rune {
+		if unicode.IsSpace(char) {
+			return '_'
+		}
+		return char
+	}, rule)
+}
+
+// Builds the diagrams of the rules of a grammar, linking rule references with the link function
+type railroadBuilder struct {
+	grammar *Grammar
+	link    func(string) string
+}
+
+// Returns the SVG element with the diagram of the rule, with its own style when it is standalone
+func (b *railroadBuilder) diagram(rule string, standalone bool) string {
+	node := b.rule(rule)
+	measure(node)
+
+	width := node.width + 2*railroadGap + 2*railroadPadding
+	height := node.up + node.down + 2*railroadPadding
+	y := railroadPadding + node.up
+
+	svg := &strings.Builder{}
+	fmt.Fprintf(svg, "<svg xmlns=\"http://www.w3.org/2000/svg\" class=\"railroad\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n", width, height, width, height)
+	if standalone {
+		fmt.Fprintf(svg, "<style>\n%s\n</style>\n", railroadStyle)
+	}
+
+	// the diagram starts and ends with a short vertical bar
+	start := railroadPadding
+	end := railroadPadding + 2*railroadGap + node.width
+	fmt.Fprintf(svg, "<path d=\"M%d %dV%dM%d %dH%d\"/>\n", start, y-railroadArc, y+railroadArc, start, y, start+railroadGap)
+	draw(svg, node, start+railroadGap, y)
+	fmt.Fprintf(svg, "<path d=\"M%d %dH%dM%d %dV%d\"/>\n", end-railroadGap, y, end, end, y-railroadArc, y+railroadArc)
+	svg.WriteString("</svg>\n")
+	return svg.String()
+}
+
+// Returns the diagram of the body of a rule
+func (b *railroadBuilder) rule(name string) *railroadNode {
+	definition := b.grammar.definitions[name]
+	switch definition.kind {
+	case "":
+		return &railroadNode{kind: railroadComment, label: "defined by a custom combinator"}
+	case kindVirtualToken:
+		return &railroadNode{kind: railroadComment, label: "virtual token, produced by a token reducer"}
+	case kindToken:
+		exporter := &grammarExporter{grammar: b.grammar}
+		if expression, ok := exporter.patternExpression(definition.pattern); ok {
+			return b.expression(expression)
+		}
+		return &railroadNode{kind: railroadTerminal, label: "/" + strings.TrimPrefix(definition.pattern, "^") + "/"}
+	}
+	return b.definition(definition)
+}
+
+func (b *railroadBuilder) definition(definition ruleDefinition) *railroadNode {
+	items := []*railroadNode{}
+	for _, rule := range definition.rules {
+		if rule != CutMarker {
+			items = append(items, b.reference(rule))
+		}
+	}
+	var separator *railroadNode
+	if definition.separator != "" {
+		separator = b.reference(definition.separator)
+	}
+
+	switch definition.kind {
+	case kindLiteral:
+		return &railroadNode{kind: railroadTerminal, label: literalName(definition.value, definition.ignoreCase)}
+	case kindSeq:
+		return &railroadNode{kind: railroadSequence, items: items}
+	case kindOr:
+		return &railroadNode{kind: railroadChoice, items: items}
+	case kindRename:
+		return items[0]
+	case kindOneOrNone:
+		return repetitionNode(items[0], nil, 0, 1, false)
+	case kindMany:
+		return repetitionNode(items[0], nil, 0, -1, false)
+	case kindOneOrMany:
+		return repetitionNode(items[0], nil, 1, -1, false)
+	case kindRepeat:
+		return repetitionNode(items[0], nil, definition.min, definition.max, false)
+	case kindManyWithSeparator:
+		return repetitionNode(items[0], separator, 0, -1, definition.trailing)
+	case kindOneOrManyWithSeparator:
+		return repetitionNode(items[0], separator, 1, -1, definition.trailing)
+	case kindRepeatWithSeparator:
+		return repetitionNode(items[0], separator, definition.min, definition.max, definition.trailing)
+	}
+	return &railroadNode{kind: railroadComment, label: "rule of kind " + definition.kind}
+}
+
+// Returns the box for a rule used by another one. Literals and anonymous groups are drawn in place,
+// and tokens are drawn as terminals
+func (b *railroadBuilder) reference(name string) *railroadNode {
+	definition, ok := b.grammar.definitions[name]
+	switch {
+	case !ok:
+		return &railroadNode{kind: railroadNonTerminal, label: name}
+	case isLiteralName(name) || isGroupName(name):
+		return b.definition(definition)
+	case definition.kind == kindToken || definition.kind == kindVirtualToken:
+		return &railroadNode{kind: railroadTerminal, label: name, link: b.link(name)}
+	}
+	return &railroadNode{kind: railroadNonTerminal, label: name, link: b.link(name)}
+}
+
+// Returns the diagram of a translated token pattern
+func (b *railroadBuilder) expression(expression exportExpression) *railroadNode {
+	items := []*railroadNode{}
+	for _, item := range expression.items {
+		items = append(items, b.expression(item))
+	}
+
+	switch expression.op {
+	case exportTerminal:
+		label := strconv.Quote(expression.text)
+		if expression.ignoreCase {
+			label += "i"
+		}
+		return &railroadNode{kind: railroadTerminal, label: label}
+	case exportClass:
+		label := classText(expression.ranges)
+		if len(expression.ranges) == 2 && expression.ranges[0] == 0 && expression.ranges[1] == unicode.MaxRune {
+			label = "any character"
+		}
+		return &railroadNode{kind: railroadTerminal, label: label}
+	case exportSequence:
+		return &railroadNode{kind: railroadSequence, items: items}
+	case exportAlternatives:
+		return &railroadNode{kind: railroadChoice, items: items}
+	case exportRepeat:
+		return repetitionNode(items[0], nil, expression.min, expression.max, false)
+	}
+	return &railroadNode{kind: railroadComment, label: expression.text}
+}
+
+// Returns the diagram for min to max items, with the separator drawn on the way back of the loop
+func repetitionNode(item, separator *railroadNode, min, max int, trailing bool) *railroadNode {
+	var node *railroadNode
+	switch {
+	case max == 0:
+		node = &railroadNode{kind: railroadSkip}
+	case max == 1:
+		node = item
+	default:
+		loop := &railroadNode{kind: railroadLoop, items: []*railroadNode{item}, separator: separator}
+		switch {
+		case min > 1 && max < 0:
+			loop.note = fmt.Sprintf("at least %d times", min)
+		case min == max:
+			loop.note = fmt.Sprintf("%d times", min)
+		case min <= 1 && max > 0:
+			loop.note = fmt.Sprintf("at most %d times", max)
+		case max > 0:
+			loop.note = fmt.Sprintf("%d to %d times", min, max)
+		}
+		node = loop
+	}
+
+	if trailing && separator != nil {
+		node = &railroadNode{kind: railroadSequence, items: []*railroadNode{node, {kind: railroadOptional, items: []*railroadNode{separator}}}}
+	}
+	if min == 0 && max != 0 {
+		node = &railroadNode{kind: railroadOptional, items: []*railroadNode{node}}
+	}
+	return node
+}
+
+func textWidth(text string) int {
+	return utf8.RuneCountInString(text) * railroadCharWidth
+}
+
+// Sets the sizes of the node and of its parts
+func measure(node *railroadNode) {
+	for _, item := range node.items {
+		measure(item)
+	}
+	if node.separator != nil {
+		measure(node.separator)
+	}
+
+	switch node.kind {
+	case railroadTerminal, railroadNonTerminal:
+		node.width = textWidth(node.label) + 2*railroadGap
+		node.up, node.down = railroadBoxHeight/2, railroadBoxHeight/2
+	case railroadComment:
+		node.width = textWidth(node.label) + railroadGap
+		node.up, node.down = railroadBoxHeight/2, railroadBoxHeight/2
+	case railroadSkip:
+		node.width, node.up, node.down = 0, 0, 0
+	case railroadSequence:
+		node.width, node.up, node.down = 0, 0, 0
+		for i, item := range node.items {
+			if i > 0 {
+				node.width += railroadGap
+			}
+			node.width += item.width
+			node.up = maxInt(node.up, item.up)
+			node.down = maxInt(node.down, item.down)
+		}
+	case railroadChoice:
+		// the first alternative is on the line, and the others are stacked below it
+		inner := 0
+		node.offsets = make([]int, len(node.items))
+		for i, item := range node.items {
+			inner = maxInt(inner, item.width)
+			if i > 0 {
+				previous := node.items[i-1]
+				node.offsets[i] = maxInt(node.offsets[i-1]+previous.down+railroadGap+item.up, node.offsets[i-1]+2*railroadArc)
+			}
+		}
+		node.width = inner + 4*railroadArc
+		if len(node.items) > 0 {
+			last := len(node.items) - 1
+			node.up = node.items[0].up
+			node.down = node.offsets[last] + node.items[last].down
+		}
+	case railroadOptional:
+		// the way around the item goes above it
+		item := node.items[0]
+		node.width = item.width + 4*railroadArc
+		node.up = maxInt(item.up+railroadGap, 2*railroadArc)
+		node.down = item.down
+	case railroadLoop:
+		// the way back goes below the item, through the separator
+		item := node.items[0]
+		inner := maxInt(item.width, textWidth(node.note))
+		back := maxInt(item.down+railroadGap, 2*railroadArc)
+		node.down = back
+		if node.separator != nil {
+			inner = maxInt(inner, node.separator.width)
+			back = maxInt(item.down+railroadGap+node.separator.up, 2*railroadArc)
+			node.down = back + node.separator.down
+		}
+		if node.note != "" {
+			node.down += railroadNoteSpace
+		}
+		node.offsets = []int{back}
+		node.width = inner + 2*railroadArc
+		node.up = item.up
+	}
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Writes the SVG elements of the node, which starts at x on the line at y
+func draw(svg *strings.Builder, node *railroadNode, x, y int) {
+	r := railroadArc
+	switch node.kind {
+	case railroadTerminal, railroadNonTerminal:
+		class, radius := "nonterminal", 0
+		if node.kind == railroadTerminal {
+			class, radius = "terminal", railroadBoxHeight/2
+		}
+		box := fmt.Sprintf("<rect class=\"%s\" x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" rx=\"%d\"/><text x=\"%d\" y=\"%d\">%s</text>",
+			class, x, y-node.up, node.width, railroadBoxHeight, radius, x+node.width/2, y+4, html.EscapeString(node.label))
+		if node.link != "" {
+			box = fmt.Sprintf("<a href=\"%s\">%s</a>", html.EscapeString(node.link), box)
+		}
+		svg.WriteString(box + "\n")
+
+	case railroadComment:
+		fmt.Fprintf(svg, "<text class=\"comment\" x=\"%d\" y=\"%d\">%s</text>\n", x+node.width/2, y-4, html.EscapeString(node.label))
+		line(svg, x, x+node.width, y)
+
+	case railroadSequence:
+		for i, item := range node.items {
+			if i > 0 {
+				line(svg, x, x+railroadGap, y)
+				x += railroadGap
+			}
+			draw(svg, item, x, y)
+			x += item.width
+		}
+
+	case railroadChoice:
+		inner := node.width - 4*r
+		for i, item := range node.items {
+			itemY := y + node.offsets[i]
+			if i == 0 {
+				line(svg, x, x+2*r, y)
+			} else {
+				fmt.Fprintf(svg, "<path d=\"M%d %dA%d %d 0 0 1 %d %dV%dA%d %d 0 0 0 %d %d\"/>\n",
+					x, y, r, r, x+r, y+r, itemY-r, r, r, x+2*r, itemY)
+			}
+			draw(svg, item, x+2*r, itemY)
+			line(svg, x+2*r+item.width, x+2*r+inner, itemY)
+			if i == 0 {
+				line(svg, x+2*r+inner, x+node.width, y)
+			} else {
+				fmt.Fprintf(svg, "<path d=\"M%d %dA%d %d 0 0 0 %d %dV%dA%d %d 0 0 1 %d %d\"/>\n",
+					x+2*r+inner, itemY, r, r, x+3*r+inner, itemY-r, y+r, r, r, x+node.width, y)
+			}
+		}
+
+	case railroadOptional:
+		item := node.items[0]
+		top := y - node.up
+		line(svg, x, x+2*r, y)
+		draw(svg, item, x+2*r, y)
+		line(svg, x+2*r+item.width, x+node.width, y)
+		fmt.Fprintf(svg, "<path d=\"M%d %dA%d %d 0 0 0 %d %dV%dA%d %d 0 0 1 %d %dH%dA%d %d 0 0 1 %d %dV%dA%d %d 0 0 0 %d %d\"/>\n",
+			x, y, r, r, x+r, y-r, top+r, r, r, x+2*r, top, x+2*r+item.width, r, r, x+3*r+item.width, top+r, y-r, r, r, x+node.width, y)
+
+	case railroadLoop:
+		item := node.items[0]
+		inner := node.width - 2*r
+		back := y + node.offsets[0]
+		line(svg, x, x+r, y)
+		draw(svg, item, x+r, y)
+		line(svg, x+r+item.width, x+node.width, y)
+		fmt.Fprintf(svg, "<path d=\"M%d %dA%d %d 0 0 1 %d %dV%dA%d %d 0 0 1 %d %d\"/>\n",
+			x+r+inner, y, r, r, x+2*r+inner, y+r, back-r, r, r, x+r+inner, back)
+		if node.separator != nil {
+			separatorX := x + r + (inner-node.separator.width)/2
+			line(svg, separatorX+node.separator.width, x+r+inner, back)
+			draw(svg, node.separator, separatorX, back)
+			line(svg, x+r, separatorX, back)
+		} else {
+			line(svg, x+r, x+r+inner, back)
+		}
+		fmt.Fprintf(svg, "<path d=\"M%d %dA%d %d 0 0 1 %d %dV%dA%d %d 0 0 1 %d %d\"/>\n",
+			x+r, back, r, r, x, back-r, y+r, r, r, x+r, y)
+		if node.note != "" {
+			fmt.Fprintf(svg, "<text class=\"note\" x=\"%d\" y=\"%d\">%s</text>\n", x+r+inner/2, y+node.down-3, html.EscapeString(node.note))
+		}
+
+	case railroadSkip:
+	}
+}
+
+func line(svg *strings.Builder, from, to, y int) {
+	if to > from {
+		fmt.Fprintf(svg, "<path d=\"M%d %dH%d\"/>\n", from, y, to)
+	}
+}
@@ -0,0 +1,136 @@
+package grammatic
+
+import (
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const railroadGrammar = `
+Program := Statement[';',]*
+Statement := ('let'i Name '=' Value) | Call | Value?
+Call := Name '(' Value[',']{2,4} ')'
+Value := Number+ | Name
+Number := /\d+/
+Name := /[a-z]\w*\b/
+Space := /\s+/ (ignore)`
+
+// Checks that the text is well formed XML and returns the labels of its boxes, in order
+func railroadLabels(t *testing.T, svg string) []string {
+	t.Helper()
+	decoder := xml.NewDecoder(strings.NewReader(svg))
+	labels := []string{}
+	inText := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return labels
+		}
+		if err != nil {
+			t.Fatalf("Expected valid XML, got %v in %s", err, svg)
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			inText = token.Name.Local == "text"
+		case xml.CharData:
+			if inText {
+				labels = append(labels, string(token))
+			}
+		case xml.EndElement:
+			inText = false
+		}
+	}
+}
+
+func railroadSVG(t *testing.T, grammar Grammar, rule string) string {
+	t.Helper()
+	builder := &strings.Builder{}
+	if err := grammar.WriteRailroadSVG(builder, rule); err != nil {
+		t.Fatal(err)
+	}
+	return builder.String()
+}
+
+func TestRailroadSVG(t *testing.T) {
+	grammar := Compile(railroadGrammar)
+
+	tests := []struct {
+		rule   string
+		labels []string
+	}{
+		{"Program", []string{"Statement", "';'", "';'"}},
+		{"Statement", []string{"'let'i", "Name", "'='", "Value", "Call", "Value"}},
+		{"Call", []string{"Name", "'('", "Value", "','", "2 to 4 times", "')'"}},
+		{"Value", []string{"Number", "Name"}},
+		{"Number", []string{"[0-9]"}},
+		{"Name", []string{`/[a-z]\w*\b/`}},
+	}
+	for _, test := range tests {
+		svg := railroadSVG(t, grammar, test.rule)
+		if !strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" class="railroad"`) || !strings.Contains(svg, "<style>") {
+			t.Errorf("Expected a standalone SVG document for %s, got %s", test.rule, svg)
+		}
+		labels := railroadLabels(t, svg)
+		if strings.Join(labels, " ") != strings.Join(test.labels, " ") {
+			t.Errorf("Expected the diagram of %s to have %q, got %q", test.rule, test.labels, labels)
+		}
+	}
+
+	svg := railroadSVG(t, grammar, "Statement")
+	for _, link := range []string{`<a href="Name.svg">`, `<a href="Value.svg">`, `<a href="Call.svg">`} {
+		if !strings.Contains(svg, link) {
+			t.Errorf("Expected the diagram of Statement to have the link %s", link)
+		}
+	}
+	if !strings.Contains(svg, `<rect class="terminal"`) || !strings.Contains(svg, `<rect class="nonterminal"`) {
+		t.Errorf("Expected tokens and literals to be terminals, and rules to be nonterminals")
+	}
+
+	if err := grammar.WriteRailroadSVG(io.Discard, "Missing"); err == nil || err.Error() != `Undefined rule "Missing"` {
+		t.Fatalf("Expected an error for an undefined rule, got %v", err)
+	}
+}
+
+// Checks that the separator of a repetition is drawn on the way back of its loop, below the item
+func TestRailroadSVGSeparator(t *testing.T) {
+	svg := railroadSVG(t, Compile(railroadGrammar), "Call")
+	position := regexp.MustCompile(`<rect class="(?:non)?terminal" x="\d+" y="(\d+)"[^>]*/><text[^>]*>([^<]*)</text>`)
+	rows := map[string]int{}
+	for _, match := range position.FindAllStringSubmatch(svg, -1) {
+		rows[match[2]], _ = strconv.Atoi(match[1])
+	}
+	if rows["Value"] == 0 || rows["Value"] != rows["Name"] || rows["&#39;,&#39;"] <= rows["Value"] {
+		t.Fatalf("Expected the separator below the items, got the rows %v", rows)
+	}
+}
+
+func TestRailroadHTML(t *testing.T) {
+	grammar := Compile(railroadGrammar)
+	builder := &strings.Builder{}
+	if err := grammar.WriteRailroadHTML(builder, "Statements & calls"); err != nil {
+		t.Fatal(err)
+	}
+	page := builder.String()
+
+	if !strings.HasPrefix(page, "<!DOCTYPE html>") || !strings.Contains(page, "<title>Statements &amp; calls</title>") {
+		t.Fatalf("Expected an HTML page with the title, got %s", page)
+	}
+	sections := regexp.MustCompile(`<section id="([^"]+)">`).FindAllStringSubmatch(page, -1)
+	ids := []string{}
+	for _, section := range sections {
+		ids = append(ids, section[1])
+	}
+	expected := "rule-Program rule-Statement rule-Call rule-Value rule-Number rule-Name rule-Space"
+	if strings.Join(ids, " ") != expected {
+		t.Fatalf("Expected the sections %s, got %s", expected, strings.Join(ids, " "))
+	}
+	if !strings.Contains(page, `<a href="#rule-Statement">`) || strings.Contains(page, ".svg\"") {
+		t.Fatalf("Expected the diagrams to link to the sections of the page")
+	}
+	for _, svg := range regexp.MustCompile(`(?s)<svg.*?</svg>`).FindAllString(page, -1) {
+		railroadLabels(t, svg)
+	}
+}
@@ -0,0 +1,379 @@
+package grammatic
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp/syntax"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Options of Generate. Zero values are replaced by the defaults
+type GenerateOptions struct {
+	// Rules nested deeper than this take their shortest way out, with the fewest repetitions. The default is 12
+	MaxDepth int
+	// Repetitions like * and + have at most this many items more than their minimum. The default is 3
+	MaxRepeat int
+	// Written between tokens. By default it is a space when an ignored token matches one, and nothing otherwise
+	Separator string
+	// Parses each sentence with the rule, generating another one when it doesn't match
+	Verify bool
+	// Number of sentences tried in Verify mode before returning an error. The default is 20
+	Attempts int
+}
+
+// Returns a random sentence that matches the rule, built by choosing among its alternatives and repetition counts,
+// and by writing a random match for the pattern of each token. Literals of case-insensitive rules get random cases.
+// Sentences can fail to match when token patterns overlap, like a name that the lexer reads as a keyword,
+// which the Verify option avoids by parsing each one. A nil rng is seeded with the current time.
+// Rules created by custom combinators and virtual tokens cannot be generated, and return an error
+func (g *Grammar) Generate(rule string, rng *rand.Rand, opts GenerateOptions) (string, error) {
+	if _, ok := g.definitions[rule]; !ok {
+		return "", fmt.Errorf("Undefined rule %q", rule)
+	}
+	if rng == nil {
+		rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	generator := newSentenceGenerator(g, rng, opts)
+	if generator.heights[rule] == math.MaxInt32 {
+		return "", fmt.Errorf("Rule %q cannot be generated, because it always uses virtual tokens, rules created by custom combinators or itself", rule)
+	}
+
+	attempts := 1
+	if generator.opts.Verify {
+		attempts = generator.opts.Attempts
+	}
+	var parseError error
+	for i := 0; i < attempts; i++ {
+		tokens := []string{}
+		if err := generator.generate(rule, 0, &tokens); err != nil {
+			return "", err
+		}
+		sentence := strings.Join(tokens, generator.opts.Separator)
+		if !generator.opts.Verify {
+			return sentence, nil
+		}
+		if _, parseError = g.Parse(rule, sentence); parseError == nil {
+			return sentence, nil
+		}
+	}
+	return "", fmt.Errorf("No sentence of rule %q matched it after %d attempts, the last one failed with: %w", rule, attempts, parseError)
+}
+
+// Same as Grammar.Generate
+func (c *Compiled) Generate(rule string, rng *rand.Rand, opts GenerateOptions) (string, error) {
+	return c.grammar.Generate(rule, rng, opts)
+}
+
+// Receives the seeds of a fuzz test, which *testing.F does
+type FuzzSeeder interface {
+	Add(args ...any)
+}
+
+// Adds count sentences of the rule to the seed corpus of a fuzz test, like f.Add(sentence)
+func (g *Grammar) AddFuzzSeeds(f FuzzSeeder, rule string, count int, rng *rand.Rand, opts GenerateOptions) error {
+	for i := 0; i < count; i++ {
+		sentence, err := g.Generate(rule, rng, opts)
+		if err != nil {
+			return err
+		}
+		f.Add(sentence)
+	}
+	return nil
+}
+
+// Same as Grammar.AddFuzzSeeds
+func (c *Compiled) AddFuzzSeeds(f FuzzSeeder, rule string, count int, rng *rand.Rand, opts GenerateOptions) error {
+	return c.grammar.AddFuzzSeeds(f, rule, count, rng, opts)
+}
+
+// Writes the inputs as a seed corpus of a fuzz test with a single string argument, in the format that go test reads
+// from testdata/fuzz/FuzzName directories. Each file is named after the hash of its input, so writing again is harmless
+func WriteFuzzCorpus(dir string, inputs []string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, input := range inputs {
+		contents := fmt.Sprintf("go test fuzz v1\nstring(%q)\n", input)
+		name := fmt.Sprintf("%x", sha256.Sum256([]byte(contents)))[:16]
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type sentenceGenerator struct {
+	grammar *Grammar
+	rng     *rand.Rand
+	opts    GenerateOptions
+	// the fewest levels of rules needed to finish a sentence of each rule
+	heights map[string]int
+}
+
+func newSentenceGenerator(g *Grammar, rng *rand.Rand, opts GenerateOptions) *sentenceGenerator {
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = 12
+	}
+	if opts.MaxRepeat <= 0 {
+		opts.MaxRepeat = 3
+	}
+	if opts.Attempts <= 0 {
+		opts.Attempts = 20
+	}
+	if opts.Separator == "" {
+		for _, def := range g.TokenDefs {
+			if g.definitions[def.Type].ignored && def.Pattern.FindString(" ") == " " {
+				opts.Separator = " "
+				break
+			}
+		}
+	}
+	return &sentenceGenerator{grammar: g, rng: rng, opts: opts, heights: ruleHeights(g)}
+}
+
+// Returns the fewest levels of rules needed to finish a sentence of each rule,
+// with math.MaxInt32 for the rules that can never finish one
+func ruleHeights(g *Grammar) map[string]int {
+	heights := map[string]int{}
+	for name := range g.definitions {
+		heights[name] = math.MaxInt32
+	}
+	height := func(rule string) int {
+		if rule == CutMarker {
+			return 0
+		}
+		if height, ok := heights[rule]; ok {
+			return height
+		}
+		return math.MaxInt32
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for name, definition := range g.definitions {
+			result := math.MaxInt32
+			switch definition.kind {
+			case kindToken, kindLiteral:
+				result = 1
+			case kindSeq:
+				result = 0
+				for _, rule := range definition.rules {
+					if height(rule) > result {
+						result = height(rule)
+					}
+				}
+			case kindOr:
+				for _, rule := range definition.rules {
+					if height(rule) < result {
+						result = height(rule)
+					}
+				}
+			case kindOneOrNone, kindMany, kindManyWithSeparator:
+				result = 0
+			case kindRename, kindOneOrMany, kindOneOrManyWithSeparator:
+				result = height(definition.rules[0])
+			case kindRepeat, kindRepeatWithSeparator:
+				result = 0
+				if definition.min > 0 {
+					result = height(definition.rules[0])
+					if definition.min > 1 && height(definition.separator) > result {
+						result = height(definition.separator)
+					}
+				}
+			}
+			if result < math.MaxInt32 {
+				result++
+			}
+			if result < heights[name] {
+				heights[name] = result
+				changed = true
+			}
+		}
+	}
+	return heights
+}
+
+// Appends the tokens of a random sentence of the rule, which is nested in depth other rules
+func (s *sentenceGenerator) generate(rule string, depth int, tokens *[]string) error {
+	if rule == CutMarker {
+		return nil
+	}
+	definition, ok := s.grammar.definitions[rule]
+	if !ok {
+		return fmt.Errorf("Undefined rule %q", rule)
+	}
+	if s.heights[rule] == math.MaxInt32 {
+		return fmt.Errorf("Rule %q cannot be generated, because it always uses virtual tokens, rules created by custom combinators or itself", rule)
+	}
+
+	switch definition.kind {
+	case kindToken:
+		token, err := s.pattern(definition.pattern)
+		if err != nil {
+			return fmt.Errorf("Cannot generate token %q: %w", rule, err)
+		}
+		*tokens = append(*tokens, token)
+		return nil
+	case kindLiteral:
+		value := definition.value
+		if definition.ignoreCase {
+			value = s.randomCase(value)
+		}
+		*tokens = append(*tokens, value)
+		return nil
+	case kindSeq:
+		for _, item := range definition.rules {
+			if err := s.generate(item, depth+1, tokens); err != nil {
+				return err
+			}
+		}
+		return nil
+	case kindOr:
+		return s.generate(s.choose(definition.rules, depth), depth+1, tokens)
+	case kindRename:
+		return s.generate(definition.rules[0], depth+1, tokens)
+	}
+
+	min, max := 0, -1
+	switch definition.kind {
+	case kindOneOrNone:
+		max = 1
+	case kindOneOrMany, kindOneOrManyWithSeparator:
+		min = 1
+	case kindRepeat, kindRepeatWithSeparator:
+		min, max = definition.min, definition.max
+	}
+	count := min
+	if depth < s.opts.MaxDepth {
+		upper := min + s.opts.MaxRepeat
+		if max >= 0 && max < upper {
+			upper = max
+		}
+		count = min + s.rng.Intn(upper-min+1)
+	}
+	for i := 0; i < count; i++ {
+		if i > 0 && definition.separator != "" {
+			if err := s.generate(definition.separator, depth+1, tokens); err != nil {
+				return err
+			}
+		}
+		if err := s.generate(definition.rules[0], depth+1, tokens); err != nil {
+			return err
+		}
+	}
+	if count > 0 && definition.trailing && s.rng.Intn(2) == 0 {
+		return s.generate(definition.separator, depth+1, tokens)
+	}
+	return nil
+}
+
+// Returns a random alternative that can finish within the depth limit, or the shortest one when none can
+func (s *sentenceGenerator) choose(alternatives []string, depth int) string {
+	candidates := []string{}
+	shortest := alternatives[0]
+	for _, alternative := range alternatives {
+		if s.heights[alternative] < s.heights[shortest] {
+			shortest = alternative
+		}
+		if s.heights[alternative] < math.MaxInt32 && depth+s.heights[alternative] <= s.opts.MaxDepth {
+			candidates = append(candidates, alternative)
+		}
+	}
+	if len(candidates) == 0 {
+		return shortest
+	}
+	return candidates[s.rng.Intn(len(candidates))]
+}
+
+func (s *sentenceGenerator) randomCase(value string) string {
+	return strings.Map(func(char rune) rune {
+		if s.rng.Intn(2) == 0 {
+			return unicode.ToUpper(char)
+		}
+		return unicode.ToLower(char)
+	}, value)
+}
+
+// Returns a random match for a token pattern
+func (s *sentenceGenerator) pattern(pattern string) (string, error) {
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", err
+	}
+	builder := &strings.Builder{}
+	s.regexp(parsed.Simplify(), builder)
+	return builder.String(), nil
+}
+
+func (s *sentenceGenerator) regexp(re *syntax.Regexp, builder *strings.Builder) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		for _, char := range re.Rune {
+			if re.Flags&syntax.FoldCase != 0 && s.rng.Intn(2) == 0 {
+				char = unicode.SimpleFold(char)
+			}
+			builder.WriteRune(char)
+		}
+	case syntax.OpCharClass:
+		builder.WriteRune(s.classRune(re.Rune))
+	case syntax.OpAnyCharNotNL, syntax.OpAnyChar:
+		builder.WriteRune(rune(' ' + s.rng.Intn('~'-' '+1)))
+	case syntax.OpCapture:
+		s.regexp(re.Sub[0], builder)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			s.regexp(sub, builder)
+		}
+	case syntax.OpAlternate:
+		s.regexp(re.Sub[s.rng.Intn(len(re.Sub))], builder)
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		min, max := re.Min, re.Max
+		switch re.Op {
+		case syntax.OpStar:
+			min, max = 0, -1
+		case syntax.OpPlus:
+			min, max = 1, -1
+		case syntax.OpQuest:
+			min, max = 0, 1
+		}
+		upper := min + s.opts.MaxRepeat
+		if max >= 0 && max < upper {
+			upper = max
+		}
+		count := min + s.rng.Intn(upper-min+1)
+		for i := 0; i < count; i++ {
+			s.regexp(re.Sub[0], builder)
+		}
+	}
+	// anchors, word boundaries and empty matches don't write anything
+}
+
+// Returns a random character of the class, preferring printable ASCII characters when it has them
+func (s *sentenceGenerator) classRune(ranges []rune) rune {
+	printable := []rune{}
+	for i := 0; i+1 < len(ranges); i += 2 {
+		for char := ranges[i]; char <= ranges[i+1] && char <= '~'; char++ {
+			if char >= ' ' || char == '\t' || char == '\n' {
+				printable = append(printable, char)
+			}
+		}
+	}
+	if len(printable) > 0 {
+		return printable[s.rng.Intn(len(printable))]
+	}
+
+	for {
+		i := s.rng.Intn(len(ranges)/2) * 2
+		char := ranges[i] + rune(s.rng.Intn(int(ranges[i+1]-ranges[i])+1))
+		if utf8.ValidRune(char) {
+			return char
+		}
+	}
+}
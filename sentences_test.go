@@ -0,0 +1,192 @@
+package grammatic
+
+import (
+	"errors"
+	"github.com/jsanchesleao/grammatic/model"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const sentencesGrammar = `
+Program := Statement+
+Statement := ('let'i Name '=' Expr ';') | (Expr ';')
+Expr := Term[AddOp]+
+Term := Number | Name | ('(' Expr ')')
+AddOp := /[-+]/
+Number := /[1-9]\d{0,3}/
+Name := /[a-zA-Z]{2,5}/
+Space := /\s+/ (ignore)`
+
+func TestGenerate(t *testing.T) {
+	grammar := Compile(sentencesGrammar)
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 50; i++ {
+		sentence, err := grammar.Generate("Program", rng, GenerateOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := grammar.Parse("Program", sentence); err != nil {
+			t.Fatalf("Expected %q to match the grammar, got %v", sentence, err)
+		}
+	}
+
+	first, _ := grammar.Generate("Program", rand.New(rand.NewSource(7)), GenerateOptions{})
+	second, _ := grammar.Generate("Program", rand.New(rand.NewSource(7)), GenerateOptions{})
+	if first != second {
+		t.Fatalf("Expected the same sentence for the same seed, got %q and %q", first, second)
+	}
+}
+
+func TestGenerateTokens(t *testing.T) {
+	grammar := Compile(`
+Row := 'select'i Code
+Code := /[a-f]{2}-\d{3}(x|yz)?/`)
+	rng := rand.New(rand.NewSource(2))
+	code := regexp.MustCompile(`^[a-f]{2}-\d{3}(x|yz)?$`)
+
+	cases := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		sentence, err := grammar.Generate("Row", rng, GenerateOptions{Separator: " "})
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts := strings.Split(sentence, " ")
+		if len(parts) != 2 || !strings.EqualFold(parts[0], "select") || !code.MatchString(parts[1]) {
+			t.Fatalf("Expected a keyword and a code, got %q", sentence)
+		}
+		cases[parts[0]] = true
+	}
+	if len(cases) < 2 {
+		t.Fatalf("Expected the keyword to be written with different cases, got %v", cases)
+	}
+}
+
+func TestGenerateDepthLimit(t *testing.T) {
+	grammar := Compile(`
+Expr := Number | ('(' Expr ')')
+Number := /\d/`)
+	rng := rand.New(rand.NewSource(3))
+
+	deepest := 0
+	for i := 0; i < 100; i++ {
+		sentence, err := grammar.Generate("Expr", rng, GenerateOptions{MaxDepth: 8})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if depth := strings.Count(sentence, "("); depth > deepest {
+			deepest = depth
+		}
+	}
+	if deepest == 0 || deepest > 4 {
+		t.Fatalf("Expected nested expressions up to the depth limit, got a nesting of %d", deepest)
+	}
+}
+
+func TestGenerateVerify(t *testing.T) {
+	grammar := Compile(sentencesGrammar)
+	rng := rand.New(rand.NewSource(4))
+	for i := 0; i < 20; i++ {
+		sentence, err := grammar.Generate("Program", rng, GenerateOptions{Verify: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := grammar.Parse("Program", sentence); err != nil {
+			t.Fatalf("Expected a verified sentence to match, got %q and %v", sentence, err)
+		}
+	}
+
+	// the lexer always reads the a of B as the longer A
+	overlapping := Compile(`
+Pair := A B
+A := /a+/
+B := /a/
+Space := /\s+/ (ignore)`)
+	_, err := overlapping.Generate("Pair", rng, GenerateOptions{Verify: true, Attempts: 5})
+	var syntaxError *model.SyntaxError
+	if !errors.As(err, &syntaxError) || !strings.HasPrefix(err.Error(), `No sentence of rule "Pair" matched it after 5 attempts`) {
+		t.Fatalf("Expected the verification to fail, got %v", err)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	grammar := Compile(`
+Block := Indent Name
+Name := /[a-z]+/
+:virtual: Indent`)
+
+	if _, err := grammar.Generate("Missing", nil, GenerateOptions{}); err == nil || err.Error() != `Undefined rule "Missing"` {
+		t.Fatalf("Expected an error for an undefined rule, got %v", err)
+	}
+	_, err := grammar.Generate("Block", nil, GenerateOptions{})
+	if err == nil || !strings.Contains(err.Error(), `Rule "Block" cannot be generated`) {
+		t.Fatalf("Expected an error for a rule that needs a virtual token, got %v", err)
+	}
+}
+
+type seedRecorder struct {
+	seeds []any
+}
+
+func (r *seedRecorder) Add(args ...any) {
+	r.seeds = append(r.seeds, args...)
+}
+
+func TestAddFuzzSeeds(t *testing.T) {
+	source := Compile(sentencesGrammar)
+	grammar := source.Freeze()
+	recorder := &seedRecorder{}
+	if err := grammar.AddFuzzSeeds(recorder, "Expr", 5, rand.New(rand.NewSource(5)), GenerateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(recorder.seeds) != 5 {
+		t.Fatalf("Expected 5 seeds, got %v", recorder.seeds)
+	}
+	for _, seed := range recorder.seeds {
+		if _, err := grammar.Parse("Expr", seed.(string)); err != nil {
+			t.Fatalf("Expected the seed %q to match, got %v", seed, err)
+		}
+	}
+}
+
+func TestWriteFuzzCorpus(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "testdata", "fuzz", "FuzzParse")
+	if err := WriteFuzzCorpus(dir, []string{"1 + a;", "let x = \"\n\";", "1 + a;"}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := []string{}
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents = append(contents, string(data))
+	}
+	expected := map[string]bool{
+		"go test fuzz v1\nstring(\"1 + a;\")\n":               true,
+		"go test fuzz v1\nstring(\"let x = \\\"\\n\\\";\")\n": true,
+	}
+	if len(contents) != 2 || !expected[contents[0]] || !expected[contents[1]] {
+		t.Fatalf("Expected one file per distinct input, got %q", contents)
+	}
+}
+
+func FuzzJSONGrammar(f *testing.F) {
+	source := Compile(JSONGrammar)
+	grammar := source.Freeze()
+	if err := grammar.AddFuzzSeeds(f, "Value", 20, rand.New(rand.NewSource(6)), GenerateOptions{Verify: true}); err != nil {
+		f.Fatal(err)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		// any input either matches or returns an error, without panicking
+		grammar.Parse("Value", input)
+	})
+}
@@ -0,0 +1,103 @@
+package grammatic
+
+import (
+	"fmt"
+	"github.com/jsanchesleao/grammatic/model"
+	"strings"
+)
+
+// Rules whose name starts with this prefix are inlined, as if Inline was called for them.
+// For rules with a namespace, like C._Digits, the prefix is checked after the namespace
+const InlinePrefix = "_"
+
+// Makes the nodes of the rules be replaced by their children in the parse tree
+func (g *Grammar) Inline(ruleNames ...string) error {
+	if g.frozen {
+		return ErrFrozenGrammar
+	}
+	g.inlineRules = addNames(g.inlineRules, ruleNames)
+	return nil
+}
+
+// Makes the nodes of the rules, usually punctuation tokens, be left out of the parse tree.
+// The rules are still matched as usual
+func (g *Grammar) Drop(ruleNames ...string) error {
+	if g.frozen {
+		return ErrFrozenGrammar
+	}
+	g.droppedRules = addNames(g.droppedRules, ruleNames)
+	return nil
+}
+
+// Makes the nodes of the rules be replaced by their child when they have only one.
+// This removes the wrapper nodes of Or rules, which always have a single child
+func (g *Grammar) Collapse(ruleNames ...string) error {
+	if g.frozen {
+		return ErrFrozenGrammar
+	}
+	g.collapsedRules = addNames(g.collapsedRules, ruleNames)
+	return nil
+}
+
+func addNames(names map[string]bool, newNames []string) map[string]bool {
+	if names == nil {
+		names = map[string]bool{}
+	}
+	for _, name := range newNames {
+		names[name] = true
+	}
+	return names
+}
+
+func copyNames(names map[string]bool) map[string]bool {
+	result := make(map[string]bool, len(names))
+	for name := range names {
+		result[name] = true
+	}
+	return result
+}
+
+// Defines an anonymous group rule, whose nodes are replaced by their children in the parse tree, and returns its name
+func (g *Grammar) defineGroup(combinator GrammarCombinator) string {
+	g.groupCount++
+	name := fmt.Sprintf("(group %d)", g.groupCount)
+	g.DefineRule(name, combinator)
+	g.Inline(name)
+	return name
+}
+
+func (g *Grammar) isInline(ruleType string) bool {
+	name := ruleType[strings.LastIndex(ruleType, NamespaceSeparator)+1:]
+	return g.inlineRules[ruleType] || strings.HasPrefix(name, InlinePrefix)
+}
+
+// Changes the children of the node, at any depth, following the Inline, Drop and Collapse settings of their rules
+func (g *Grammar) shapeTree(node *model.Node) *model.Node {
+	if node == nil {
+		return node
+	}
+	node.Rules = g.shapeChildren(node.Rules)
+	return node
+}
+
+func (g *Grammar) shapeChildren(nodes []model.Node) []model.Node {
+	if nodes == nil {
+		return nil
+	}
+	result := []model.Node{}
+	for _, child := range nodes {
+		child.Rules = g.shapeChildren(child.Rules)
+		if g.droppedRules[child.Type] {
+			continue
+		}
+		if child.Token == nil && g.isInline(child.Type) {
+			result = append(result, child.Rules...)
+			continue
+		}
+		if g.collapsedRules[child.Type] && len(child.Rules) == 1 {
+			child = child.Rules[0]
+		}
+		result = append(result, child)
+	}
+	return result
+}
@@ -0,0 +1,118 @@
+package grammatic
+
+import (
+	"fmt"
+	"github.com/jsanchesleao/grammatic/model"
+	"strings"
+)
+
+// A rule of the grammar language with parameters, like Delimited<Open, Item, Close>, which is
+// turned into a regular rule for every distinct list of arguments it is used with
+type ruleTemplate struct {
+	params     []string
+	definition *model.Node
+	instances  map[string]bool
+}
+
+// Keeps the definition of a grammar rule that has template parameters, so it can be instantiated later
+func processTemplateDefinition(grammar *Grammar, node *model.Node) {
+	parameterList := node.GetNodeWithType("TemplateParameters").GetNodeWithType("TemplateParameterList")
+	if parameterList == nil || isAlternativesRule(node) {
+		return
+	}
+
+	name := node.GetNodeWithType("RuleName").Token.Value
+	params := []string{}
+	for _, param := range parameterList.GetNodeWithType("TemplateParameterNames").GetNodesWithType("RuleName") {
+		params = append(params, param.Token.Value)
+	}
+
+	if grammar.templates == nil {
+		grammar.templates = map[string]*ruleTemplate{}
+	}
+	if grammar.templates[name] != nil {
+		panic(fmt.Errorf("Template %q is defined more than once", name))
+	}
+	grammar.templates[name] = &ruleTemplate{
+		params:     params,
+		definition: node,
+		instances:  map[string]bool{},
+	}
+}
+
+// Defines the rule for a template used with a list of arguments, and returns its name, like Delimited<LParen, Expr, RParen>.
+// Names given with `as` inside the template are prefixed with the name of the instance, like Delimited<LParen, Expr, RParen>.Items
+func processTemplateInstance(grammar *Grammar, node *model.Node) string {
+	templateName := node.Rules[0].Token.Value
+
+	args := []string{}
+	for _, arg := range node.GetNodeWithType("TemplateArguments").GetNodesWithType("TemplateArgument") {
+		args = append(args, processSeqOrExpressionItem(grammar, arg))
+	}
+
+	template := grammar.templates[templateName]
+	if template == nil {
+		panic(fmt.Errorf("Undefined template %q", templateName))
+	}
+	if len(args) != len(template.params) {
+		panic(fmt.Errorf("Template %q expects %d arguments, but got %d", templateName, len(template.params), len(args)))
+	}
+
+	instanceName := fmt.Sprintf("%s<%s>", templateName, strings.Join(args, ", "))
+	if template.instances[instanceName] {
+		return instanceName
+	}
+	template.instances[instanceName] = true
+
+	bindings := map[string]string{}
+	for i, param := range template.params {
+		bindings[param] = args[i]
+	}
+
+	definition := substituteTemplateNames(grammar, *template.definition, bindings, instanceName)
+	combinator := createRules(grammar, definition.GetNodeWithType("RuleExpression"))
+	if combinator != nil {
+		grammar.DefineRule(instanceName, *combinator)
+	}
+	processRuleFlags(grammar, instanceName, &definition)
+
+	if strings.HasPrefix(templateName, InlinePrefix) {
+		grammar.Inline(instanceName)
+	}
+	return instanceName
+}
+
+// Returns a copy of the node where template parameters are replaced by the arguments bound to them,
+// and where rule names given with `as` are prefixed with the instance name
+func substituteTemplateNames(grammar *Grammar, node model.Node, bindings map[string]string, instanceName string) model.Node {
+	if node.Rules == nil {
+		if node.Type == "RuleName" {
+			if arg, ok := bindings[node.Token.Value]; ok {
+				token := *node.Token
+				token.Value = arg
+				node.Token = &token
+			}
+		}
+		return node
+	}
+
+	rules := make([]model.Node, len(node.Rules))
+	for i, child := range node.Rules {
+		switch {
+		case child.Type == "RuleName" && i > 0 && node.Rules[i-1].Type == "As":
+			token := *child.Token
+			token.Value = fmt.Sprintf("%s.%s", instanceName, child.Token.Value)
+			if strings.HasPrefix(child.Token.Value, InlinePrefix) {
+				grammar.Inline(token.Value)
+			}
+			child.Token = &token
+			rules[i] = child
+		case node.Type == "TemplateInstance" && i == 0:
+			rules[i] = child
+		default:
+			rules[i] = substituteTemplateNames(grammar, child, bindings, instanceName)
+		}
+	}
+	node.Rules = rules
+	return node
+}
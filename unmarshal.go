@@ -0,0 +1,180 @@
+package grammatic
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"github.com/jsanchesleao/grammatic/model"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// The struct tag read by Unmarshal
+const UnmarshalTag = "grammatic"
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// Fills v, which must be a pointer, with the contents of the node.
+//
+// Struct fields are filled from the nodes found by the path in their `grammatic` tag, like `grammatic:"Credentials/AuthUser"`,
+// where every part of the path is the type of a child node of the previous one, starting from the node given to Unmarshal.
+// Fields without the tag are left unchanged. Then the value of each field is built from the node it was given:
+//   - strings get the text of the node, which is the value of its token or of all the tokens under it
+//   - numbers and bools are parsed from the text, and types implementing encoding.TextUnmarshaler unmarshal it
+//   - structs are filled from the node, with the paths of their fields starting at it
+//   - slices get one item for every node that matches the path, which can go through many nodes at each part
+//   - pointers are left nil when the node is not found, which is useful for optional rules
+func Unmarshal(node *model.Node, v any) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Pointer || value.IsNil() {
+		return errors.New("Unmarshal needs a non-nil pointer")
+	}
+	if node == nil {
+		return errors.New("Unmarshal needs a non-nil node")
+	}
+	return unmarshalNode(node, value.Elem(), reflect.TypeOf(v).Elem().String())
+}
+
+func unmarshalNode(node *model.Node, value reflect.Value, path string) error {
+	if value.CanAddr() && value.Addr().Type().Implements(textUnmarshalerType) {
+		unmarshaler := value.Addr().Interface().(encoding.TextUnmarshaler)
+		if err := unmarshaler.UnmarshalText([]byte(nodeText(node))); err != nil {
+			return unmarshalError(node, path, value.Type(), err)
+		}
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.Pointer:
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		return unmarshalNode(node, value.Elem(), path)
+
+	case reflect.Struct:
+		return unmarshalStruct(node, value, path)
+
+	case reflect.Slice:
+		slice := reflect.MakeSlice(value.Type(), len(node.Rules), len(node.Rules))
+		for i := range node.Rules {
+			if err := unmarshalNode(&node.Rules[i], slice.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		value.Set(slice)
+		return nil
+
+	case reflect.String:
+		value.SetString(nodeText(node))
+		return nil
+
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(nodeText(node))
+		if err != nil {
+			return unmarshalError(node, path, value.Type(), err)
+		}
+		value.SetBool(parsed)
+		return nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(nodeText(node), 10, value.Type().Bits())
+		if err != nil {
+			return unmarshalError(node, path, value.Type(), err)
+		}
+		value.SetInt(parsed)
+		return nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(nodeText(node), 10, value.Type().Bits())
+		if err != nil {
+			return unmarshalError(node, path, value.Type(), err)
+		}
+		value.SetUint(parsed)
+		return nil
+
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(nodeText(node), value.Type().Bits())
+		if err != nil {
+			return unmarshalError(node, path, value.Type(), err)
+		}
+		value.SetFloat(parsed)
+		return nil
+	}
+
+	return fmt.Errorf("cannot unmarshal into %s: unsupported type %s", path, value.Type())
+}
+
+func unmarshalStruct(node *model.Node, value reflect.Value, path string) error {
+	structType := value.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag, ok := field.Tag.Lookup(UnmarshalTag)
+		if !ok || tag == "" || tag == "-" || !field.IsExported() {
+			continue
+		}
+
+		fieldPath := path + "." + field.Name
+		fieldValue := value.Field(i)
+		nodes := findNodes(node, strings.Split(tag, "/"))
+
+		if fieldValue.Kind() == reflect.Slice && !fieldValue.Addr().Type().Implements(textUnmarshalerType) {
+			if len(nodes) == 0 {
+				continue
+			}
+			if err := unmarshalNode(&model.Node{Rules: nodeValues(nodes)}, fieldValue, fieldPath); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if len(nodes) == 0 {
+			continue
+		}
+		if err := unmarshalNode(nodes[0], fieldValue, fieldPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns every node reached by following the path from the node, in the order they appear in the tree
+func findNodes(node *model.Node, path []string) []*model.Node {
+	nodes := []*model.Node{node}
+	for _, part := range path {
+		children := []*model.Node{}
+		for _, parent := range nodes {
+			children = append(children, parent.GetNodesWithType(part)...)
+		}
+		nodes = children
+	}
+	return nodes
+}
+
+func nodeValues(nodes []*model.Node) []model.Node {
+	values := []model.Node{}
+	for _, node := range nodes {
+		values = append(values, *node)
+	}
+	return values
+}
+
+// Returns the value of the token of the node, or the values of all the tokens under it joined together
+func nodeText(node *model.Node) string {
+	if node.Token != nil {
+		return node.Token.Value
+	}
+	text := strings.Builder{}
+	for i := range node.Rules {
+		text.WriteString(nodeText(&node.Rules[i]))
+	}
+	return text.String()
+}
+
+func unmarshalError(node *model.Node, path string, valueType reflect.Type, err error) error {
+	location := ""
+	if token := firstToken(node); token != nil {
+		location = fmt.Sprintf(" at line %d, column %d", token.Line, token.Col)
+	}
+	return fmt.Errorf("cannot unmarshal %q into %s of type %s%s: %w", nodeText(node), path, valueType, location, err)
+}
@@ -0,0 +1,122 @@
+package grammatic
+
+import (
+	"strings"
+	"testing"
+)
+
+const configGrammar = `
+Config := Entry*
+
+Entry := Name Equals Value Settings? as MaybeSettings
+
+Settings := LBrackets Setting[Comma]* as SettingList RBrackets
+
+Setting := Flag | Level
+
+Value := Number | Bool | Name
+
+Level := /[a-z]+:\d+/
+Flag := /\+[a-z]+/
+
+Equals := /=/
+Comma := /,/
+LBrackets := /\[/
+RBrackets := /\]/
+Number := /-?\d+(\.\d+)?/
+Bool := /true|false/
+Name := /[a-z]+/
+Space := $EmptySpaceFormat (ignore)`
+
+type level struct {
+	Name  string
+	Value int
+}
+
+func (l *level) UnmarshalText(text []byte) error {
+	parts := strings.Split(string(text), ":")
+	l.Name = parts[0]
+	l.Value = len(parts[1])
+	return nil
+}
+
+type configEntry struct {
+	Name     string   `grammatic:"Name"`
+	Value    string   `grammatic:"Value"`
+	Flags    []string `grammatic:"MaybeSettings/Settings/SettingList/Setting/Flag"`
+	Settings *struct {
+		Flags  []string `grammatic:"SettingList/Setting/Flag"`
+		Levels []level  `grammatic:"SettingList/Setting/Level"`
+	} `grammatic:"MaybeSettings/Settings"`
+	Ignored string
+}
+
+type numberEntry struct {
+	Value float64 `grammatic:"Value/Number"`
+	Int   *int    `grammatic:"Value/Number"`
+}
+
+func TestUnmarshal(t *testing.T) {
+	grammar := Compile(configGrammar)
+
+	tree, err := grammar.Parse("Config", "size = 10 [+fast, level:123] debug = true")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := struct {
+		Entries []configEntry `grammatic:"Config/Entry"`
+	}{}
+	if err := Unmarshal(tree, &config); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(config.Entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(config.Entries))
+	}
+
+	size := config.Entries[0]
+	if size.Name != "size" || size.Value != "10" {
+		t.Fatalf("Unexpected entry %+v", size)
+	}
+	if size.Settings == nil || len(size.Settings.Flags) != 1 || size.Settings.Flags[0] != "+fast" {
+		t.Fatalf("Unexpected settings %+v", size.Settings)
+	}
+	if len(size.Settings.Levels) != 1 || size.Settings.Levels[0] != (level{Name: "level", Value: 3}) {
+		t.Fatalf("Unexpected levels %+v", size.Settings.Levels)
+	}
+	if len(size.Flags) != 1 {
+		t.Fatalf("Expected the flags to be found by a long path, got %+v", size.Flags)
+	}
+
+	debug := config.Entries[1]
+	if debug.Name != "debug" || debug.Value != "true" || debug.Settings != nil || debug.Flags != nil {
+		t.Fatalf("Unexpected entry %+v", debug)
+	}
+}
+
+func TestUnmarshalNumbers(t *testing.T) {
+	grammar := Compile(configGrammar)
+
+	tree, err := grammar.Parse("Entry", "size = 10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := numberEntry{}
+	if err := Unmarshal(tree.GetNodeWithType("Entry"), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Value != 10 || entry.Int == nil || *entry.Int != 10 {
+		t.Fatalf("Unexpected entry %+v", entry)
+	}
+
+	tree, err = grammar.Parse("Entry", "size = 1.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Unmarshal(tree.GetNodeWithType("Entry"), &entry)
+	expectedError := `cannot unmarshal "1.5" into grammatic.numberEntry.Int of type int at line 1, column 8: strconv.ParseInt: parsing "1.5": invalid syntax`
+	if err == nil || err.Error() != expectedError {
+		t.Fatalf("Expected error %q, got %v", expectedError, err)
+	}
+}